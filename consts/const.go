@@ -24,6 +24,9 @@ const (
   CardActionTopup  CardAction = 1
   CardActionPay    CardAction = 2
   CardActionRefund CardAction = 3
+  // CardActionTransferOut và CardActionTransferIn là cặp bút toán của một lần chuyển số dư giữa hai thẻ
+  CardActionTransferOut CardAction = 4
+  CardActionTransferIn  CardAction = 5
 )
 
 func (a CardAction) ToText() string {
@@ -34,6 +37,10 @@ func (a CardAction) ToText() string {
     return "pay"
   case CardActionRefund:
     return "refund"
+  case CardActionTransferOut:
+    return "transfer_out"
+  case CardActionTransferIn:
+    return "transfer_in"
   default:
     return "unknown"
   }
@@ -113,4 +120,317 @@ const (
   ActiveStatus   Status = "active"
   InactiveStatus Status = "inactive"
   BlockedStatus  Status = "blocked"
+  ExpiredStatus  Status = "expired"
+)
+
+// HotlistStatuses là các trạng thái thẻ bị cổng soát vé từ chối
+var HotlistStatuses = []Status{BlockedStatus, ExpiredStatus}
+
+// IsHotlisted cho biết thẻ có nằm trong danh sách chặn gửi xuống cổng hay không
+func (s Status) IsHotlisted() bool {
+  for _, status := range HotlistStatuses {
+    if s == status {
+      return true
+    }
+  }
+  return false
+}
+
+// Direction theo quy ước direction_id của GTFS
+type Direction int
+
+const (
+  OutboundDirection Direction = 0
+  InboundDirection  Direction = 1
+)
+
+func (d Direction) ToText() string {
+  switch d {
+  case OutboundDirection:
+    return "outbound"
+  case InboundDirection:
+    return "inbound"
+  default:
+    return "unknown"
+  }
+}
+
+// ServiceExceptionType theo quy ước exception_type của GTFS calendar_dates
+type ServiceExceptionType int
+
+const (
+  ServiceAdded   ServiceExceptionType = 1
+  ServiceRemoved ServiceExceptionType = 2
+)
+
+// TripStatus là trạng thái thời gian thực của một chuyến tàu
+type TripStatus string
+
+const (
+  TripScheduled TripStatus = "scheduled"
+  TripAtStation TripStatus = "at_station"
+  TripInTransit TripStatus = "in_transit"
+  TripCompleted TripStatus = "completed"
+  TripCancelled TripStatus = "cancelled"
+)
+
+type StationStatus string
+
+const (
+  StationActive      StationStatus = "active"
+  StationInactive    StationStatus = "inactive"
+  StationMaintenance StationStatus = "maintenance"
+  StationClosed      StationStatus = "closed"
+)
+
+// IsClosure cho biết trạm có đang đóng cửa với hành khách hay không
+func (s StationStatus) IsClosure() bool {
+  switch s {
+  case StationInactive, StationMaintenance, StationClosed:
+    return true
+  default:
+    return false
+  }
+}
+
+type AlertSeverity string
+
+const (
+  AlertInfo    AlertSeverity = "info"
+  AlertWarning AlertSeverity = "warning"
+  AlertSevere  AlertSeverity = "severe"
+)
+
+type AlertEffect string
+
+const (
+  AlertEffectDelay          AlertEffect = "delay"
+  AlertEffectStationClosure AlertEffect = "station_closure"
+  AlertEffectLineClosure    AlertEffect = "line_closure"
+  AlertEffectStationOutage  AlertEffect = "station_outage"
+  AlertEffectOther          AlertEffect = "other"
+)
+
+// Permission là quyền thao tác dạng "tài_nguyên:hành_động", được gán cho từng Role
+type Permission string
+
+const (
+  PermissionCardRead       Permission = "card:read"
+  PermissionCardCreate     Permission = "card:create"
+  PermissionCardUpdate     Permission = "card:update"
+  PermissionCardDelete     Permission = "card:delete"
+  PermissionCardTopup      Permission = "card:topup"
+  PermissionStationManage  Permission = "station:manage"
+  PermissionStationOperate Permission = "station:operate"
+  PermissionTrainManage    Permission = "train:manage"
+  PermissionTripManage     Permission = "trip:manage"
+  PermissionTripReport     Permission = "trip:report"
+  PermissionScheduleManage Permission = "schedule:manage"
+  PermissionAlertManage    Permission = "alert:manage"
+  PermissionDeviceManage   Permission = "device:manage"
+  PermissionReportRead     Permission = "report:read"
+  PermissionUserManage     Permission = "user:manage"
+  PermissionRoleManage     Permission = "role:manage"
+  PermissionAuditRead      Permission = "audit:read"
+  PermissionOrgManage      Permission = "org:manage"
+  PermissionWebhookManage  Permission = "webhook:manage"
+  PermissionCardTransfer   Permission = "card:transfer" // chuyển số dư tại quầy; mặc định chỉ admin
+)
+
+// AllPermissions là danh sách tất cả các quyền hệ thống hỗ trợ
+var AllPermissions = []Permission{
+  PermissionCardRead,
+  PermissionCardCreate,
+  PermissionCardUpdate,
+  PermissionCardDelete,
+  PermissionCardTopup,
+  PermissionStationManage,
+  PermissionStationOperate,
+  PermissionTrainManage,
+  PermissionTripManage,
+  PermissionTripReport,
+  PermissionScheduleManage,
+  PermissionAlertManage,
+  PermissionDeviceManage,
+  PermissionReportRead,
+  PermissionUserManage,
+  PermissionRoleManage,
+  PermissionAuditRead,
+  PermissionOrgManage,
+  PermissionWebhookManage,
+  PermissionCardTransfer,
+}
+
+func (p Permission) IsValid() bool {
+  for _, permission := range AllPermissions {
+    if p == permission {
+      return true
+    }
+  }
+  return false
+}
+
+// DefaultRolePermissions là bảng phân quyền khởi tạo khi migrate lần đầu
+var DefaultRolePermissions = map[Role][]Permission{
+  AdminRole: AllPermissions,
+  StaffRole: {
+    PermissionCardRead,
+    PermissionCardCreate,
+    PermissionCardUpdate,
+    PermissionCardTopup,
+    PermissionStationOperate,
+    PermissionTripReport,
+    PermissionReportRead,
+  },
+  UserRole: {},
+}
+
+// DeviceType là loại thiết bị đặt tại trạm
+type DeviceType string
+
+const (
+  GateDevice          DeviceType = "gate"
+  TicketMachineDevice DeviceType = "ticket_machine"
+)
+
+// DeviceConnectivity là trạng thái kết nối của thiết bị theo heartbeat
+type DeviceConnectivity string
+
+const (
+  DeviceUnknown DeviceConnectivity = "unknown" // chưa từng gửi request
+  DeviceOnline  DeviceConnectivity = "online"
+  DeviceOffline DeviceConnectivity = "offline" // im lặng quá thời gian cho phép
+)
+
+// OrgType là loại tài khoản tổ chức
+type OrgType string
+
+const (
+  FamilyOrg    OrgType = "family"
+  CorporateOrg OrgType = "corporate"
+)
+
+func (t OrgType) IsValid() bool {
+  return t == FamilyOrg || t == CorporateOrg
+}
+
+// OrgRole là vai trò của thành viên trong tổ chức
+type OrgRole string
+
+const (
+  OrgOwner   OrgRole = "owner"   // chủ tài khoản (phụ huynh, công ty)
+  OrgManager OrgRole = "manager" // quản lý thẻ, hạn mức và ví
+  OrgMember  OrgRole = "member"  // chỉ dùng thẻ của mình
+)
+
+func (r OrgRole) IsValid() bool {
+  return r == OrgOwner || r == OrgManager || r == OrgMember
+}
+
+// CanManage cho biết vai trò có được quản lý thẻ, hạn mức và xem sao kê của tổ chức không
+func (r OrgRole) CanManage() bool {
+  return r == OrgOwner || r == OrgManager
+}
+
+// WalletEntryType là loại giao dịch của ví chung
+type WalletEntryType string
+
+const (
+  WalletDeposit WalletEntryType = "deposit" // nạp tiền vào ví
+  WalletTopup   WalletEntryType = "topup"   // ví nạp tiền cho thẻ thành viên
+)
+
+// CardDebitType là loại khoản chi của thẻ tổ chức trong sao kê
+type CardDebitType string
+
+const (
+  CardDebitFare     CardDebitType = "fare"     // tiền vé khi ra ga
+  CardDebitTransfer CardDebitType = "transfer" // chuyển số dư sang thẻ khác
+)
+
+// TransferStatus là trạng thái của một lần chuyển số dư giữa hai thẻ
+type TransferStatus string
+
+const (
+  TransferPending   TransferStatus = "pending"   // chờ chủ thẻ nhận đồng ý
+  TransferCompleted TransferStatus = "completed" // đã chuyển tiền
+  TransferRejected  TransferStatus = "rejected"  // chủ thẻ nhận từ chối
+  TransferCancelled TransferStatus = "cancelled" // người gửi hủy trước khi được duyệt
+)
+
+// NotificationEvent là loại sự kiện tài khoản được báo cho khách
+type NotificationEvent string
+
+const (
+  NotifyLowBalance   NotificationEvent = "low_balance"   // số dư thẻ xuống dưới ngưỡng khách đặt
+  NotifyTopUp        NotificationEvent = "topup"         // xác nhận nạp tiền
+  NotifyCardBlocked  NotificationEvent = "card_blocked"  // thẻ bị khóa
+  NotifyCardExpiring NotificationEvent = "card_expiring" // thẻ sắp hết hạn
+)
+
+// NotificationChannel là kênh gửi thông báo
+type NotificationChannel string
+
+const (
+  ChannelEmail NotificationChannel = "email"
+  ChannelSMS   NotificationChannel = "sms"
+  ChannelPush  NotificationChannel = "push"
+)
+
+// NotificationStatus là trạng thái gửi của một thông báo
+type NotificationStatus string
+
+const (
+  NotificationPending NotificationStatus = "pending" // chờ gửi hoặc chờ gửi lại
+  NotificationSent    NotificationStatus = "sent"
+  NotificationFailed  NotificationStatus = "failed" // hết số lần thử
+)
+
+// DomainEventType là loại sự kiện nghiệp vụ công bố cho hệ thống khác qua outbox
+type DomainEventType string
+
+const (
+  DomainCardSold       DomainEventType = "card.sold"
+  DomainCardToppedUp   DomainEventType = "card.topped_up"
+  DomainCardCheckedIn  DomainEventType = "card.checked_in"
+  DomainCardCheckedOut DomainEventType = "card.checked_out"
+  // DomainJourneyCompleted được ghi cùng card.checked_out, ghép với lượt vào ga trước đó của thẻ
+  DomainJourneyCompleted DomainEventType = "journey.completed"
+)
+
+// DomainEventTypes là danh sách các loại sự kiện nghiệp vụ, dùng khi đăng ký webhook
+var DomainEventTypes = []DomainEventType{
+  DomainCardSold,
+  DomainCardToppedUp,
+  DomainCardCheckedIn,
+  DomainCardCheckedOut,
+  DomainJourneyCompleted,
+}
+
+func (t DomainEventType) IsValid() bool {
+  for _, eventType := range DomainEventTypes {
+    if t == eventType {
+      return true
+    }
+  }
+  return false
+}
+
+// Version là phiên bản schema của phần data trong sự kiện; tăng khi đổi data theo cách không tương thích
+func (t DomainEventType) Version() int {
+  switch t {
+  case DomainCardSold, DomainCardToppedUp, DomainCardCheckedIn, DomainCardCheckedOut, DomainJourneyCompleted:
+    return 1
+  default:
+    return 0
+  }
+}
+
+// WebhookDeliveryStatus là trạng thái gửi một sự kiện tới một webhook
+type WebhookDeliveryStatus string
+
+const (
+  WebhookPending   WebhookDeliveryStatus = "pending"   // chờ gửi hoặc chờ gửi lại
+  WebhookDelivered WebhookDeliveryStatus = "delivered" // bên nhận trả 2xx
+  WebhookDead      WebhookDeliveryStatus = "dead"      // hết số lần thử, nằm trong dead-letter chờ gửi lại thủ công
 )
@@ -24,14 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/users": {
-            "get": {
+        "/admin/alerts": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve users with pagination, role filtering, and name search (Admin access required)",
+                "description": "Announce a closure, delay or station outage affecting lines, stations or trips",
                 "consumes": [
                     "application/json"
                 ],
@@ -39,131 +39,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin"
+                    "alert"
                 ],
-                "summary": "Get all users with advanced features (Admin only)",
+                "summary": "Create a service alert (Admin only)",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Items per page (default: 10, max: 100)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 3,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Filter by role (1: Admin, 2: Staff, 3: User)",
-                        "name": "role",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search by full name (partial match)",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "active",
-                            "inactive"
-                        ],
-                        "type": "string",
-                        "description": "Filter by status",
-                        "name": "status",
-                        "in": "query"
-                    }
-                ],
-                "responses": {}
-            }
-        },
-        "/admin/users/simple": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
+                        "description": "Service alert information",
+                        "name": "alert",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ServiceAlertReq"
+                        }
                     }
                 ],
-                "description": "Alternative implementation with manual parameter parsing",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "admin"
-                ],
-                "summary": "Get all users with query parameters (Admin only)",
-                "responses": {}
-            }
-        },
-        "/admin/users/statistics": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
+                "responses": {
+                    "201": {
+                        "description": "Service alert created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServiceAlert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
                     }
-                ],
-                "description": "Get statistics of users grouped by role (Admin, Staff, User) and active status count",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "statistics"
-                ],
-                "summary": "Get user statistics",
-                "responses": {}
+                }
             }
         },
-        "/admin/users/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve a specific user by their ID (Admin access required)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "admin"
-                ],
-                "summary": "Get user by ID (Admin only)",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {}
-            },
+        "/admin/alerts/{id}": {
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a specific user's information (Admin access required)",
+                "description": "Update an unresolved service alert and its affected lines, stations and trips",
                 "consumes": [
                     "application/json"
                 ],
@@ -171,76 +102,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin"
+                    "alert"
                 ],
-                "summary": "Update user (Admin only)",
+                "summary": "Update a service alert (Admin only)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Service alert ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User update data",
-                        "name": "request",
+                        "description": "Updated service alert information",
+                        "name": "alert",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/handlers.ServiceAlertReq"
                         }
                     }
                 ],
-                "responses": {}
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Delete a specific user from the system (Admin access required)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "admin"
-                ],
-                "summary": "Delete user (Admin only)",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
                 "responses": {
                     "200": {
-                        "description": "User deleted successfully",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                        "description": "Service alert updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/utils.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServiceAlert"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "403": {
-                        "description": "Forbidden - Admin access required",
+                    "400": {
+                        "description": "Bad request - validation error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "404": {
-                        "description": "Thông tin không tồn tại",
+                        "description": "Service alert not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -254,36 +163,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
+        "/admin/alerts/{id}/resolve": {
             "post": {
-                "description": "Authenticate user with email and password, returns JWT token",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "User login",
-                "parameters": [
+                "security": [
                     {
-                        "description": "Login credentials",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginReq"
-                        }
+                        "BearerAuth": []
                     }
                 ],
-                "responses": {}
-            }
-        },
-        "/auth/register": {
-            "post": {
-                "description": "Register a new user account with username, password, email, and full name",
+                "description": "Mark a service alert as resolved; it stops being shown and closed stations reopen",
                 "consumes": [
                     "application/json"
                 ],
@@ -291,23 +178,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "alert"
                 ],
-                "summary": "Register a new user",
+                "summary": "Resolve a service alert (Admin only)",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.RegisterReq"
-                        }
+                        "type": "integer",
+                        "description": "Service alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Đã tạo tài khoản thành công",
+                    "200": {
+                        "description": "Service alert resolved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -317,7 +202,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.User"
+                                            "$ref": "#/definitions/models.ServiceAlert"
                                         }
                                     }
                                 }
@@ -325,7 +210,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error, email/username already exists",
+                        "description": "Service alert already resolved",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Service alert not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -339,23 +230,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/card": {
+        "/admin/audit": {
             "get": {
-                "description": "Retrieve all metro cards in the system",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "card"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
-                "summary": "Get all cards",
-                "responses": {}
-            },
-            "post": {
-                "description": "Create a new metro card with auto-generated card ID and user ID",
+                "description": "Administrative changes with actor, target and before/after diff, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -363,51 +245,104 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "admin"
                 ],
-                "summary": "Create a new card",
+                "summary": "List audit log",
                 "parameters": [
                     {
-                        "description": "Card information",
-                        "name": "card",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Actor user ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action (user.update, user.delete, card.update, card.delete, station.update, train.delete)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target type (user, card, station, train)",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit log retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CardReq"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.AuditLog"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
                         }
                     }
-                ],
-                "responses": {}
+                }
             }
         },
-        "/card/cardid/{rf_id}": {
+        "/admin/audit/verify": {
             "get": {
-                "description": "Retrieve a specific card by its card ID (physical card number)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "card"
-                ],
-                "summary": "Get card by Card ID",
-                "parameters": [
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Card ID (physical card number)",
-                        "name": "rf_id",
-                        "in": "path",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
-                "responses": {}
-            }
-        },
-        "/card/status/{status}": {
-            "get": {
-                "description": "Retrieve all cards with a specific status (active, inactive, blocked)",
+                "description": "Recompute the hash chain of the whole audit log and report the first entry that was modified, removed or reordered",
                 "consumes": [
                     "application/json"
                 ],
@@ -415,29 +350,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "admin"
                 ],
-                "summary": "Get cards by status",
-                "parameters": [
-                    {
-                        "enum": [
-                            "active",
-                            "inactive",
-                            "blocked"
-                        ],
-                        "type": "string",
-                        "description": "Card status",
-                        "name": "status",
-                        "in": "path",
-                        "required": true
+                "summary": "Verify audit log integrity",
+                "responses": {
+                    "200": {
+                        "description": "Verification result",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.AuditChainReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
                     }
-                ],
-                "responses": {}
+                }
             }
         },
-        "/card/user/{user_id}": {
+        "/admin/deleted/cards": {
             "get": {
-                "description": "Retrieve all cards belonging to a specific user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-deleted cards that can still be restored, most recently deleted first",
                 "consumes": [
                     "application/json"
                 ],
@@ -445,24 +396,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "admin"
                 ],
-                "summary": "Get cards by user ID",
+                "summary": "List deleted cards",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
-                "responses": {}
+                "responses": {
+                    "200": {
+                        "description": "Deleted cards retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Card"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
             }
         },
-        "/card/{id}": {
-            "get": {
-                "description": "Retrieve a specific card by its database ID",
+        "/admin/deleted/cards/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore a soft-deleted card. A blocked card goes back on the gate hotlist.",
                 "consumes": [
                     "application/json"
                 ],
@@ -470,9 +459,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "admin"
                 ],
-                "summary": "Get card by ID",
+                "summary": "Restore deleted card",
                 "parameters": [
                     {
                         "type": "integer",
@@ -484,7 +473,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Card retrieved successfully",
+                        "description": "Card restored",
                         "schema": {
                             "allOf": [
                                 {
@@ -502,15 +491,28 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thẻ không tồn tại",
+                        "description": "Deleted card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a specific card from the system",
+            }
+        },
+        "/admin/deleted/stations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-deleted stations that can still be restored, most recently deleted first",
                 "consumes": [
                     "application/json"
                 ],
@@ -518,105 +520,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "admin"
                 ],
-                "summary": "Delete card",
+                "summary": "List deleted stations",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Card ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
-                "responses": {}
-            }
-        },
-        "/card/{rf_id}": {
-            "put": {
-                "description": "Update a specific card's information",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "card"
-                ],
-                "summary": "Update card",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "rf_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated card information",
-                        "name": "card",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateCardReq"
-                        }
-                    }
-                ],
-                "responses": {}
-            }
-        },
-        "/card/{rf_id}/topup": {
-            "post": {
-                "description": "Add money to a card's balance",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "card"
-                ],
-                "summary": "Top up card balance",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "rf_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Top-up amount",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
-                    }
-                ],
-                "responses": {}
-            }
-        },
-        "/history": {
-            "get": {
-                "description": "Retrieve all transaction history records",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "history"
-                ],
-                "summary": "Get all history records",
                 "responses": {
                     "200": {
-                        "description": "Histories retrieved successfully",
+                        "description": "Deleted stations retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -628,7 +551,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.History"
+                                                "$ref": "#/definitions/models.Station"
                                             }
                                         }
                                     }
@@ -645,9 +568,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/history/{id}": {
-            "get": {
-                "description": "Retrieve a specific history record by its ID",
+        "/admin/deleted/stations/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore a soft-deleted station",
                 "consumes": [
                     "application/json"
                 ],
@@ -655,13 +583,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "history"
+                    "admin"
                 ],
-                "summary": "Get history by ID",
+                "summary": "Restore deleted station",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "History ID",
+                        "description": "Station ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -669,7 +597,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "History retrieved successfully",
+                        "description": "Station restored",
                         "schema": {
                             "allOf": [
                                 {
@@ -679,7 +607,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.History"
+                                            "$ref": "#/definitions/models.Station"
                                         }
                                     }
                                 }
@@ -687,7 +615,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "History not found",
+                        "description": "Deleted station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -695,9 +629,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/sell-history": {
+        "/admin/deleted/trains": {
             "get": {
-                "description": "Retrieve all sell history records with optional filtering",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-deleted trains that can still be restored, most recently deleted first",
                 "consumes": [
                     "application/json"
                 ],
@@ -705,9 +644,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sell-history"
+                    "admin"
                 ],
-                "summary": "Get all sell history records",
+                "summary": "List deleted trains",
                 "parameters": [
                     {
                         "type": "integer",
@@ -720,23 +659,11 @@ const docTemplate = `{
                         "description": "Number of records per page",
                         "name": "limit",
                         "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by card ID",
-                        "name": "card_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by seller ID",
-                        "name": "seller_id",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sell histories retrieved successfully",
+                        "description": "Deleted trains retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -748,7 +675,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.SellHistory"
+                                                "$ref": "#/definitions/models.Train"
                                             }
                                         }
                                     }
@@ -765,9 +692,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/sell-history/card/{card_id}": {
-            "get": {
-                "description": "Retrieve all sell history records for a specific card",
+        "/admin/deleted/trains/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore a soft-deleted train",
                 "consumes": [
                     "application/json"
                 ],
@@ -775,21 +707,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sell-history"
+                    "admin"
                 ],
-                "summary": "Get sell histories by card ID",
+                "summary": "Restore deleted train",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "card_id",
+                        "type": "integer",
+                        "description": "Train ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sell histories retrieved successfully",
+                        "description": "Train restored",
                         "schema": {
                             "allOf": [
                                 {
@@ -799,16 +731,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.SellHistory"
-                                            }
+                                            "$ref": "#/definitions/models.Train"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "404": {
+                        "description": "Deleted train not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -818,9 +753,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/sell-history/seller/{seller_id}": {
+        "/admin/deleted/users": {
             "get": {
-                "description": "Retrieve all sell history records for a specific seller",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-deleted users that can still be restored, most recently deleted first",
                 "consumes": [
                     "application/json"
                 ],
@@ -828,21 +768,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sell-history"
+                    "admin"
                 ],
-                "summary": "Get sell histories by seller ID",
+                "summary": "List deleted users",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Seller ID",
-                        "name": "seller_id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sell histories retrieved successfully",
+                        "description": "Deleted users retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -854,7 +799,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.SellHistory"
+                                                "$ref": "#/definitions/models.User"
                                             }
                                         }
                                     }
@@ -871,9 +816,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/sell-history/{id}": {
-            "get": {
-                "description": "Retrieve a specific sell history record by its ID",
+        "/admin/deleted/users/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore a soft-deleted user. Fails if another active user has taken the same email.",
                 "consumes": [
                     "application/json"
                 ],
@@ -881,13 +831,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sell-history"
+                    "admin"
                 ],
-                "summary": "Get sell history by ID",
+                "summary": "Restore deleted user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Sell History ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -895,7 +845,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sell history retrieved successfully",
+                        "description": "User restored",
                         "schema": {
                             "allOf": [
                                 {
@@ -905,7 +855,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.SellHistory"
+                                            "$ref": "#/definitions/models.User"
                                         }
                                     }
                                 }
@@ -913,7 +863,19 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Sell history not found",
+                        "description": "Deleted user not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already in use",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -921,9 +883,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station": {
+        "/admin/orgs": {
             "get": {
-                "description": "Retrieve all metro stations with optional status filter and name search",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Family and corporate accounts with their wallet balance",
                 "consumes": [
                     "application/json"
                 ],
@@ -931,56 +898,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station"
+                    "organization"
                 ],
-                "summary": "Get all stations with filters",
+                "summary": "List organizations",
                 "parameters": [
                     {
-                        "enum": [
-                            "active",
-                            "inactive",
-                            "maintenance"
-                        ],
                         "type": "string",
-                        "description": "Filter by status",
-                        "name": "status",
+                        "description": "Filter by type (family, corporate)",
+                        "name": "type",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Search by station name (partial match, case-insensitive)",
-                        "name": "name",
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
-                "responses": {}
-            },
-            "post": {
-                "description": "Create a new metro station",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "station"
-                ],
-                "summary": "Create a new station",
-                "parameters": [
-                    {
-                        "description": "Station information",
-                        "name": "station",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StationReq"
-                        }
-                    }
-                ],
                 "responses": {
                     "200": {
-                        "description": "Station created successfully",
+                        "description": "Organizations retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -990,19 +933,16 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Station"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Organization"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad request - validation error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1010,11 +950,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/station-history": {
-            "get": {
-                "description": "Retrieve all station history records with optional filtering",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a family or corporate account with a shared wallet. The owner must not belong to another organization.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1022,44 +965,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station-history"
+                    "organization"
                 ],
-                "summary": "Get all station history records",
+                "summary": "Create organization",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of records per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by card ID",
-                        "name": "card_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Filter by station ID",
-                        "name": "station_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by action (checkin/checkout)",
-                        "name": "action",
-                        "in": "query"
+                        "description": "Organization",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateOrganizationReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Station histories retrieved successfully",
+                    "201": {
+                        "description": "Organization created",
                         "schema": {
                             "allOf": [
                                 {
@@ -1069,16 +991,31 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.StationHistory"
-                                            }
+                                            "$ref": "#/definitions/models.Organization"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Owner not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Owner already belongs to an organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1088,9 +1025,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station-history/action/{action}": {
+        "/admin/orgs/{id}": {
             "get": {
-                "description": "Retrieve all station history records for a specific action (checkin/checkout)",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Organization with its members and cards, including each card's monthly funding limit and what the wallet has funded this month",
                 "consumes": [
                     "application/json"
                 ],
@@ -1098,21 +1040,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station-history"
+                    "organization"
                 ],
-                "summary": "Get station histories by action",
+                "summary": "Get organization",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Action (checkin/checkout)",
-                        "name": "action",
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Station histories retrieved successfully",
+                        "description": "Organization retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1122,16 +1064,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.StationHistory"
-                                            }
+                                            "$ref": "#/definitions/handlers.OrganizationDetail"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1141,9 +1086,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station-history/card/{card_id}": {
-            "get": {
-                "description": "Retrieve all station history records for a specific card",
+        "/admin/orgs/{id}/cards": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Put a card under the organization so the shared wallet can fund its top-ups. user_id hands the card to a member; a card that already has an owner can only join that owner's organization.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1151,21 +1101,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station-history"
+                    "organization"
                 ],
-                "summary": "Get station histories by card ID",
+                "summary": "Add card to organization",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "card_id",
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Card",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OrganizationCardReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Station histories retrieved successfully",
+                    "201": {
+                        "description": "Card added",
                         "schema": {
                             "allOf": [
                                 {
@@ -1175,16 +1134,31 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.StationHistory"
-                                            }
+                                            "$ref": "#/definitions/models.OrganizationCard"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Card holder is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Card already belongs to an organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1194,9 +1168,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station-history/station/{station_id}": {
-            "get": {
-                "description": "Retrieve all station history records for a specific station",
+        "/admin/orgs/{id}/cards/{rf_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop funding a card from the shared wallet. The card stays with its holder.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1204,38 +1183,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station-history"
+                    "organization"
                 ],
-                "summary": "Get station histories by station ID",
+                "summary": "Remove card from organization",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
-                        "name": "station_id",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Station histories retrieved successfully",
+                        "description": "Card removed",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.StationHistory"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card does not belong to this organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "500": {
@@ -1247,9 +1224,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station-history/{id}": {
-            "get": {
-                "description": "Retrieve a specific station history record by its ID",
+        "/admin/orgs/{id}/members": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a user to the organization or change their role. A user belongs to at most one organization, and an organization always keeps one owner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1257,21 +1239,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station-history"
+                    "organization"
                 ],
-                "summary": "Get station history by ID",
+                "summary": "Add organization member",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station History ID",
+                        "description": "Organization ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Member",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OrganizationMemberReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Station history retrieved successfully",
+                        "description": "Member saved",
                         "schema": {
                             "allOf": [
                                 {
@@ -1281,15 +1272,33 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.StationHistory"
+                                            "$ref": "#/definitions/models.OrganizationMember"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "404": {
-                        "description": "Station history not found",
+                        "description": "Organization or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "User already belongs to another organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1297,9 +1306,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station/{id}": {
-            "get": {
-                "description": "Retrieve a specific station by its ID",
+        "/admin/orgs/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a user from the organization. Cards they hold stay with the organization.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1307,102 +1321,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station"
+                    "organization"
                 ],
-                "summary": "Get station by ID",
+                "summary": "Remove organization member",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
+                        "description": "Organization ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Station retrieved successfully",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Station"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
                     },
-                    "404": {
-                        "description": "Station not found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "Update an existing station",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "station"
-                ],
-                "summary": "Update station",
-                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
-                        "name": "id",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated station information",
-                        "name": "station",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StationReq"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Station updated successfully",
+                        "description": "Member removed",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Station"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Last owner cannot be removed",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "404": {
-                        "description": "Station not found",
+                        "description": "Member not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1414,9 +1366,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a station",
+            }
+        },
+        "/admin/orgs/{id}/statements/{month}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shared wallet statement of an organization for a month: opening and closing balance, deposits, top-ups per card, every wallet transaction, and the fares and balance transfers paid by the organization's cards",
                 "consumes": [
                     "application/json"
                 ],
@@ -1424,27 +1383,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station"
+                    "organization"
                 ],
-                "summary": "Delete station",
+                "summary": "Get monthly statement",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
+                        "description": "Organization ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Month (YYYY-MM)",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Station deleted successfully",
+                        "description": "Statement retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.OrgStatement"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid month",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "404": {
-                        "description": "Station not found",
+                        "description": "Organization not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1458,9 +1442,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station/{id}/checkin": {
+        "/admin/orgs/{id}/wallet/deposit": {
             "post": {
-                "description": "Check in a card at a specific station",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record money received from the organization into its shared wallet",
                 "consumes": [
                     "application/json"
                 ],
@@ -1468,30 +1457,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station"
+                    "organization"
                 ],
-                "summary": "Check in at station",
+                "summary": "Deposit into shared wallet",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
+                        "description": "Organization ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Check-in information",
+                        "description": "Deposit",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CheckInRequest"
+                            "$ref": "#/definitions/handlers.WalletDepositReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Check-in successful",
+                        "description": "Deposit recorded",
                         "schema": {
                             "allOf": [
                                 {
@@ -1501,7 +1490,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.StationHistory"
+                                            "$ref": "#/definitions/models.WalletTransaction"
                                         }
                                     }
                                 }
@@ -1509,13 +1498,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Invalid amount",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "404": {
-                        "description": "Station or card not found",
+                        "description": "Organization not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1529,9 +1518,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/station/{id}/checkout": {
-            "post": {
-                "description": "Check out a card at a specific station and deduct fare",
+        "/admin/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every permission the system knows and the permissions granted to each role",
                 "consumes": [
                     "application/json"
                 ],
@@ -1539,30 +1533,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "station"
-                ],
-                "summary": "Check out at station",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Station ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Check-out information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CheckOutRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Get the permission matrix (Admin only)",
                 "responses": {
                     "200": {
-                        "description": "Check-out successful",
+                        "description": "Permissions retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1572,25 +1548,27 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.StationHistory"
+                                            "type": "object",
+                                            "properties": {
+                                                "permissions": {
+                                                    "type": "array",
+                                                    "items": {
+                                                        "type": "string"
+                                                    }
+                                                },
+                                                "roles": {
+                                                    "type": "array",
+                                                    "items": {
+                                                        "$ref": "#/definitions/handlers.RolePermissionsRes"
+                                                    }
+                                                }
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad request - validation error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
-                    "404": {
-                        "description": "Station or card not found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1600,9 +1578,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/train": {
+        "/admin/roles/{role}/permissions": {
             "get": {
-                "description": "Retrieve all train records with optional filtering",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the permissions granted to a role",
                 "consumes": [
                     "application/json"
                 ],
@@ -1610,38 +1593,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "train"
+                    "admin"
                 ],
-                "summary": "Get all train records",
+                "summary": "Get permissions of a role (Admin only)",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of records per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by train type",
-                        "name": "type",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "Filter by company",
-                        "name": "company",
-                        "in": "query"
+                        "description": "Role (1/admin, 2/staff, 3/user)",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trains retrieved successfully",
+                        "description": "Permissions retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1651,16 +1617,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.Train"
-                                            }
+                                            "$ref": "#/definitions/handlers.RolePermissionsRes"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1669,8 +1638,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "Create a new train record",
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the full list of permissions granted to a role. Admin always keeps role:manage.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1678,23 +1652,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "train"
+                    "admin"
                 ],
-                "summary": "Create a new train record",
+                "summary": "Replace permissions of a role (Admin only)",
                 "parameters": [
                     {
-                        "description": "Train information",
-                        "name": "train",
+                        "type": "string",
+                        "description": "Role (1/admin, 2/staff, 3/user)",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Permissions to grant",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Train"
+                            "$ref": "#/definitions/handlers.RolePermissionsReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Train created successfully",
+                        "description": "Permissions updated successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1704,7 +1685,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Train"
+                                            "$ref": "#/definitions/handlers.RolePermissionsRes"
                                         }
                                     }
                                 }
@@ -1712,7 +1693,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Bad request - unknown permission",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1726,9 +1713,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/train/company/{company}": {
+        "/admin/security/events": {
             "get": {
-                "description": "Retrieve all train records for a specific company",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Audit trail of logins, failed attempts, lockouts and unlocks, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -1736,21 +1728,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "train"
+                    "admin"
                 ],
-                "summary": "Get trains by company",
+                "summary": "List security events",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Company name",
-                        "name": "company",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Trains retrieved successfully",
+                        "description": "Event type (login_succeeded, login_failed, login_throttled, account_locked, ip_locked, account_unlocked, ip_unlocked)",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IP address",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events before this time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Security events retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1762,7 +1795,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.Train"
+                                                "$ref": "#/definitions/models.SecurityEvent"
                                             }
                                         }
                                     }
@@ -1770,6 +1803,12 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1779,9 +1818,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/train/type/{type}": {
+        "/admin/security/lockouts": {
             "get": {
-                "description": "Retrieve all train records for a specific type",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List accounts and IPs that are currently in backoff or temporarily locked",
                 "consumes": [
                     "application/json"
                 ],
@@ -1789,21 +1833,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "train"
+                    "admin"
                 ],
-                "summary": "Get trains by type",
+                "summary": "List login lockouts",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Train type",
-                        "name": "type",
-                        "in": "path",
-                        "required": true
+                        "description": "account or ip",
+                        "name": "scope",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trains retrieved successfully",
+                        "description": "Lockouts retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -1815,7 +1858,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.Train"
+                                                "$ref": "#/definitions/models.LoginThrottle"
                                             }
                                         }
                                     }
@@ -1832,57 +1875,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/train/{id}": {
-            "get": {
-                "description": "Retrieve a specific train record by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "train"
-                ],
-                "summary": "Get train by ID",
-                "parameters": [
+        "/admin/security/unlock-ip": {
+            "post": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Train ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
-                "responses": {
-                    "200": {
-                        "description": "Train retrieved successfully",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Train"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "404": {
-                        "description": "Train not found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "Update an existing train record",
+                "description": "Clear the failed-login counter, backoff and temporary lock of an IP address",
                 "consumes": [
                     "application/json"
                 ],
@@ -1890,96 +1890,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "train"
+                    "admin"
                 ],
-                "summary": "Update train",
+                "summary": "Unlock IP address",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Train ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated train information",
-                        "name": "train",
+                        "description": "IP address",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Train"
+                            "$ref": "#/definitions/handlers.UnlockIPReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Train updated successfully",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Train"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request - validation error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
-                    "404": {
-                        "description": "Train not found",
+                        "description": "IP unlocked",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Delete a train record",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "train"
-                ],
-                "summary": "Delete train",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Train ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Train deleted successfully",
+                    "400": {
+                        "description": "Invalid IP",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "404": {
-                        "description": "Train not found",
+                        "description": "IP not locked",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -1993,9 +1932,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/trip": {
+        "/admin/users": {
             "get": {
-                "description": "Retrieve all trip records with optional filtering",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve users with pagination, role filtering, and name search (Admin access required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -2003,67 +1947,61 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
                 ],
-                "summary": "Get all trip records",
+                "summary": "Get all users with advanced features (Admin only)",
                 "parameters": [
                     {
+                        "minimum": 1,
                         "type": "integer",
-                        "description": "Page number",
+                        "description": "Page number (default: 1)",
                         "name": "page",
                         "in": "query"
                     },
                     {
+                        "maximum": 100,
+                        "minimum": 1,
                         "type": "integer",
-                        "description": "Number of records per page",
+                        "description": "Items per page (default: 10, max: 100)",
                         "name": "limit",
                         "in": "query"
                     },
                     {
+                        "maximum": 3,
+                        "minimum": 1,
                         "type": "integer",
-                        "description": "Filter by train ID",
-                        "name": "train_id",
+                        "description": "Filter by role (1: Admin, 2: Staff, 3: User)",
+                        "name": "role",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by direction",
-                        "name": "direction",
+                        "description": "Search by full name (partial match)",
+                        "name": "name",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Trips retrieved successfully",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.Trip"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
-                        }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
+                    {
+                        "enum": [
+                            "active",
+                            "inactive"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
                     }
-                }
-            },
-            "post": {
-                "description": "Create a new trip record for train journeys",
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/users/simple": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Alternative implementation with manual parameter parsing",
                 "consumes": [
                     "application/json"
                 ],
@@ -2071,41 +2009,146 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
                 ],
-                "summary": "Create a new trip record",
+                "summary": "Get all users with query parameters (Admin only)",
+                "responses": {}
+            }
+        },
+        "/admin/users/statistics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get statistics of users grouped by role (Admin, Staff, User) and active status count",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Get user statistics",
+                "responses": {}
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific user by their ID (Admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get user by ID (Admin only)",
                 "parameters": [
                     {
-                        "description": "Trip information",
-                        "name": "trip",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a specific user's information (Admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update user (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update data",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Trip"
+                            "type": "object"
                         }
                     }
                 ],
+                "responses": {}
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific user from the system (Admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete user (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Trip created successfully",
+                        "description": "User deleted successfully",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Trip"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/utils.Response"
                         }
                     },
-                    "400": {
-                        "description": "Bad request - validation error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin access required",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Thông tin không tồn tại",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -2119,9 +2162,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/trip/active": {
-            "get": {
-                "description": "Retrieve all currently active trips (where end_time is null or in the future)",
+        "/admin/users/{id}/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the TOTP secret and recovery codes of a user who lost their device, and sign out all their sessions. Users whose role requires two-factor authentication must enrol again on next login.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2129,29 +2177,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
+                ],
+                "summary": "Reset user two-factor authentication",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get active trips",
                 "responses": {
                     "200": {
-                        "description": "Active trips retrieved successfully",
+                        "description": "Two-factor authentication reset",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.Trip"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
                         }
                     },
                     "500": {
@@ -2163,9 +2217,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/trip/direction/{direction}": {
+        "/admin/users/{id}/stations": {
             "get": {
-                "description": "Retrieve all trip records for a specific direction",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the stations a staff member is assigned to. Staff only sell cards and see history at these stations.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2173,21 +2232,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
                 ],
-                "summary": "Get trips by direction",
+                "summary": "Get assigned stations of a user (Admin only)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Direction",
-                        "name": "direction",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trips retrieved successfully",
+                        "description": "Stations retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -2199,7 +2258,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.Trip"
+                                                "$ref": "#/definitions/models.Station"
                                             }
                                         }
                                     }
@@ -2207,18 +2266,21 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     }
                 }
-            }
-        },
-        "/trip/train/{train_id}": {
-            "get": {
-                "description": "Retrieve all trip records for a specific train",
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the list of stations a staff member is assigned to",
                 "consumes": [
                     "application/json"
                 ],
@@ -2226,21 +2288,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
                 ],
-                "summary": "Get trips by train ID",
+                "summary": "Assign stations to a user (Admin only)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Train ID",
-                        "name": "train_id",
+                        "description": "User ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Assigned station IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserStationsReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trips retrieved successfully",
+                        "description": "Stations assigned successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -2252,7 +2323,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.Trip"
+                                                "$ref": "#/definitions/models.Station"
                                             }
                                         }
                                     }
@@ -2260,6 +2331,18 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad request - station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -2269,9 +2352,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/trip/{id}": {
-            "get": {
-                "description": "Retrieve a specific trip record by its ID",
+        "/admin/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear the failed-login counter, backoff and temporary lock of a user account",
                 "consumes": [
                     "application/json"
                 ],
@@ -2279,13 +2367,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "admin"
                 ],
-                "summary": "Get trip by ID",
+                "summary": "Unlock user login",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Trip ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2293,7 +2381,61 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trip retrieved successfully",
+                        "description": "Account unlocked",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found or not locked",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Partner webhook subscriptions without their signing secrets",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhook subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -2303,23 +2445,31 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Trip"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookSubscription"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Trip not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing trip record",
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a partner URL to receive card and journey events by HTTP POST. Each request is signed with HMAC-SHA256; the signing secret is only returned in this response.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2327,30 +2477,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "webhook"
                 ],
-                "summary": "Update trip",
+                "summary": "Create webhook subscription",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Trip ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated trip information",
-                        "name": "trip",
+                        "description": "Subscription",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Trip"
+                            "$ref": "#/definitions/handlers.WebhookSubscriptionReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Trip updated successfully",
+                    "201": {
+                        "description": "Subscription created",
                         "schema": {
                             "allOf": [
                                 {
@@ -2360,7 +2503,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Trip"
+                                            "$ref": "#/definitions/models.WebhookSubscription"
                                         }
                                     }
                                 }
@@ -2368,13 +2511,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
-                    "404": {
-                        "description": "Trip not found",
+                        "description": "Invalid URL or event type",
                         "schema": {
                             "$ref": "#/definitions/utils.Response"
                         }
@@ -2386,9 +2523,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a trip record",
+            }
+        },
+        "/admin/webhooks/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deliveries of every subscription that ran out of attempts or whose subscription was turned off, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -2396,29 +2540,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trip"
+                    "webhook"
                 ],
-                "summary": "Delete trip",
+                "summary": "List webhook dead letters",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Trip ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by subscription",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trip deleted successfully",
-                        "schema": {
-                            "$ref": "#/definitions/utils.Response"
-                        }
-                    },
-                    "404": {
-                        "description": "Trip not found",
+                        "description": "Dead letters retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/utils.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -2430,14 +2594,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/password": {
-            "put": {
+        "/admin/webhooks/deliveries/{id}/redeliver": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Change current user's password",
+                "description": "Put a dead delivery back in the queue with a fresh set of attempts. The payload is sent unchanged with the same event ID so partners can drop duplicates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2445,50 +2609,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "webhook"
                 ],
-                "summary": "Change user password",
+                "summary": "Redeliver webhook",
                 "parameters": [
                     {
-                        "description": "Password change data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Delivery queued",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ChangePasswordReq"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.WebhookDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Delivery is not in the dead letters",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
                         }
                     }
-                ],
-                "responses": {}
+                }
             }
         },
-        "/user/profile": {
+        "/admin/webhooks/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get current user's profile information",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "user"
-                ],
-                "summary": "Get user profile",
-                "responses": {}
-            },
-            "put": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Update current user's email and full name",
                 "consumes": [
                     "application/json"
                 ],
@@ -2496,23 +2675,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "webhook"
                 ],
-                "summary": "Update user profile",
+                "summary": "Get webhook subscription",
                 "parameters": [
                     {
-                        "description": "Password change data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Profile updated successfully",
+                        "description": "Subscription retrieved successfully",
                         "schema": {
                             "allOf": [
                                 {
@@ -2522,488 +2699,10508 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.User"
+                                            "$ref": "#/definitions/models.WebhookSubscription"
                                         }
                                     }
                                 }
                             ]
                         }
-                    }
-                }
-            }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the URL, event types or description, or turn the subscription on or off. Pending deliveries of an inactive subscription are moved to the dead letters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Update webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateWebhookSubscriptionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.WebhookSubscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL or event type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the subscription together with its delivery history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription deleted",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deliveries of a subscription with their attempts and last error, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (pending, delivered, dead)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by event type",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}/rotate-secret": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the signing secret; deliveries from now on are signed with the new secret, which is only returned in this response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Rotate webhook signing secret",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Secret rotated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.WebhookSubscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts": {
+            "get": {
+                "description": "Retrieve service alerts, by default only those active now",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert"
+                ],
+                "summary": "Get service alerts",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "resolved",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "active (default), resolved or all",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only alerts affecting this station (directly or via its lines and trips)",
+                        "name": "station_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only alerts affecting this line",
+                        "name": "line_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only alerts affecting this trip",
+                        "name": "trip_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "info",
+                            "warning",
+                            "severe"
+                        ],
+                        "type": "string",
+                        "description": "Filter by severity",
+                        "name": "severity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service alerts retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ServiceAlert"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/{id}": {
+            "get": {
+                "description": "Retrieve a specific service alert by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert"
+                ],
+                "summary": "Get service alert by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service alert retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServiceAlert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Service alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user with email and password, returns a short-lived JWT access token and a refresh token. If two-factor authentication is enabled, returns a challenge_token instead; complete the login at /auth/login/2fa. Repeated failures per account or per IP trigger exponential backoff and then a temporary lock.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Logged in, or two-factor challenge issued",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Wrong email or password, or account disabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login/2fa": {
+            "post": {
+                "description": "Exchange the challenge_token from /auth/login and an authenticator code (or a recovery code) for an access token and a refresh token. A wrong code keeps the challenge valid until it expires and counts as a failed login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete two-factor login",
+                "parameters": [
+                    {
+                        "description": "Challenge token and code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorLoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Logged in",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired challenge, or wrong code",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Revoke the session of the given refresh token. Access tokens already issued stay valid until they expire (ACCESS_TOKEN_TTL_MINUTES).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Logout",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Logged out",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Email a single-use password reset link. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request password reset",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset email sent if the account exists",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Set a new password with a reset token. The token works once; all sessions are signed out. Resetting also verifies the email address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResetPasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token or weak password",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access/refresh token pair. Each refresh token can be used once; reusing an old one revokes the whole session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token refreshed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.TokenPair"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Register a new user account with username, password, email, and full name. A verification link is emailed; the account is restricted until the email is verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Đã tạo tài khoản thành công",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error, email/username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/resend-verification": {
+            "post": {
+                "description": "Send a new verification link. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification email sent if the account needs it",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Confirm the email address with the single-use token sent after registration. Refresh the access token afterwards to lift the unverified restriction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify email address",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VerifyEmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid, used or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all metro cards in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Get all cards",
+                "responses": {}
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new metro card with auto-generated card ID. Omit user_id to sell an anonymous card; the response then includes a one-time verification_code to print on the card so the rider can claim it. The sale is recorded at station_id, which must be one of the staff member's assigned stations (admins may sell at any station).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Create a new card",
+                "parameters": [
+                    {
+                        "description": "Card information",
+                        "name": "card",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardReq"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/cardid/{rf_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific card by its card ID (physical card number)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Get card by Card ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID (physical card number)",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/status/{status}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all cards with a specific status (active, inactive, blocked, expired)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Get cards by status",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "inactive",
+                            "blocked",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Card status",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/transfers": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move balance immediately between two cards of the same registered owner. Transferring from a blocked or expired card needs override with a reason, and only then may the destination belong to someone else. Transfers between different owners must be requested by the card holder and approved by the recipient.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Transfer balance between cards at the counter",
+                "parameters": [
+                    {
+                        "description": "Cards, amount and override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StaffTransferReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer completed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid transfer, cards of different owners, insufficient balance or daily limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Source card is blocked or expired and no override was given",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/user/{owner_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all cards belonging to a specific user (staff only; riders use /me/cards)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Get cards by user ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "owner_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific card by its database ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Get card by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Card ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Thẻ không tồn tại",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific card from the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Delete card",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Card ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/{rf_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a card's balance, status or type (the price follows the type). Omitted fields are left unchanged; blocking or expiring the card adds it to the gate hotlist.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Update card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated card information",
+                        "name": "card",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateCardReq"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/{rf_id}/block": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Block a card (lost, stolen, fraud). The card is added to the gate hotlist within seconds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Block card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card blocked",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/{rf_id}/expiry": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set or clear the expiry date of a card (student cards, monthly passes). Holders are notified CARD_EXPIRY_NOTICE_DAYS before the date; an hourly job then expires the card and adds it to the gate hotlist.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Set card expiry date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Expiry date",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardExpiryReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card expiry updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/{rf_id}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Refund the remaining balance of a registered card and retire it (status expired, added to the gate hotlist). Anonymous cards must be claimed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Refund card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card refunded",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.CardRefundRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Card is anonymous, already retired or has no balance",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/{rf_id}/replace": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue a new card to the owner of a registered card (lost or damaged). The balance moves to the new card and the old card is retired (status expired, added to the gate hotlist). Anonymous cards must be claimed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Replace card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID of the card being replaced",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Station issuing the new card",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReplaceCardReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Replacement card issued",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Card is anonymous or already retired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Staff is not assigned to this station",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/{rf_id}/topup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add money to a card's balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Top up card balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Top-up amount",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/card/{rf_id}/unblock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reactivate a blocked card and remove it from the gate hotlist. Expired cards cannot be unblocked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "card"
+                ],
+                "summary": "Unblock card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card unblocked",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Card is not blocked",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve registered devices with optional station, type and status filters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Get registered devices",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Filter by station ID",
+                        "name": "station_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "gate",
+                            "ticket_machine"
+                        ],
+                        "type": "string",
+                        "description": "Filter by device type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "inactive",
+                            "blocked"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Devices retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Device"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a gate or ticket machine at a station. The returned secret is shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Register a station device",
+                "parameters": [
+                    {
+                        "description": "Device information",
+                        "name": "device",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeviceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Device registered successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.DeviceCredentialsRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/fleet": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Connectivity of all active devices, per-station summary and stations whose gates have all gone silent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Fleet health dashboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit to one station",
+                        "name": "station_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "online",
+                            "offline",
+                            "unknown"
+                        ],
+                        "type": "string",
+                        "description": "Only list devices with this connectivity",
+                        "name": "connectivity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fleet status retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.FleetDashboard"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Report firmware version, clock, offline queue size and error counters. Devices that stay silent longer than DEVICE_OFFLINE_TIMEOUT_SECONDS are marked offline.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Device heartbeat",
+                "parameters": [
+                    {
+                        "description": "Device status",
+                        "name": "heartbeat",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HeartbeatReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Heartbeat recorded",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.HeartbeatRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific registered device by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Get device by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Device"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a registered device, e.g. move it to another station or deactivate it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Update device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated device information",
+                        "name": "device",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeviceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Device"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a device from the registry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Delete device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/{id}/heartbeats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve recent heartbeats of a device, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Get device heartbeats",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heartbeats received at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device heartbeats retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DeviceHeartbeat"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/{id}/rotate-key": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue new credentials for a device; the old secret stops working immediately",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Rotate device secret",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device key rotated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.DeviceCredentialsRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/{id}/taps": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve offline taps a gate uploaded and how each was resolved, newest sequence first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device"
+                ],
+                "summary": "Get offline taps uploaded by a device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "accepted",
+                            "accepted_with_debt",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Filter by result",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by card RFID",
+                        "name": "card_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device taps retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DeviceTap"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/gtfs/export": {
+            "get": {
+                "description": "Download stations, lines and service patterns as a GTFS static zip (agency, stops, routes, trips, stop_times, calendar, calendar_dates)",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "gtfs"
+                ],
+                "summary": "Export GTFS static feed",
+                "responses": {
+                    "200": {
+                        "description": "GTFS zip archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/gtfs/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Validate and upsert stations, lines and service patterns from a GTFS static zip in one transaction. Invalid rows are skipped and listed in the report.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gtfs"
+                ],
+                "summary": "Import GTFS static feed",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "GTFS zip archive",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Train used for trips whose block_id is not a known train ID",
+                        "name": "default_train_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate only, roll back all changes",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GTFS feed imported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.GTFSImportReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid feed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all transaction history records",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Get all history records",
+                "responses": {
+                    "200": {
+                        "description": "Histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.History"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/history/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific history record by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Get history by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "History ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "History retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.History"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "History not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/hotlist": {
+            "get": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Signed list of blocked and expired cards for gates. Pass since=\u003cversion\u003e to receive only the changes after that version; omit it for the full list. The payload is base64 JSON signed with Ed25519 (see GET /hotlist/key).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hotlist"
+                ],
+                "summary": "Download card hotlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Version the device already holds",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hotlist retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.SignedHotlist"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/hotlist/key": {
+            "get": {
+                "description": "Public key devices use to verify hotlist signatures",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hotlist"
+                ],
+                "summary": "Hotlist signing key",
+                "responses": {
+                    "200": {
+                        "description": "Hotlist key retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.HotlistKey"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/hotlist/stream": {
+            "get": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Server-Sent Events stream for gates. Sends a \"version\" event with the current hotlist version on connect and whenever a card is added or removed, and a \"heartbeat\" event every 15 seconds. Devices download the delta with GET /hotlist?since=\u003cversion\u003e.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "hotlist"
+                ],
+                "summary": "Hotlist change stream",
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/line": {
+            "get": {
+                "description": "Retrieve all metro lines",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "line"
+                ],
+                "summary": "Get all metro lines",
+                "responses": {
+                    "200": {
+                        "description": "Lines retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Line"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new metro line (route)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "line"
+                ],
+                "summary": "Create a new metro line",
+                "parameters": [
+                    {
+                        "description": "Line information",
+                        "name": "line",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LineReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Line created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Line"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/line/{id}": {
+            "get": {
+                "description": "Retrieve a specific metro line by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "line"
+                ],
+                "summary": "Get line by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Line ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Line retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Line"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Line not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing metro line",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "line"
+                ],
+                "summary": "Update line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Line ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated line information",
+                        "name": "line",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LineReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Line updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Line"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Line not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a metro line together with its service patterns",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "line"
+                ],
+                "summary": "Delete line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Line ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Line deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Line not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/cards": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cards owned by the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my cards",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cards retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Card"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/cards/claim": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Link an anonymous card bought at a kiosk to the authenticated user with its RFID and the verification code printed on the card. Repeated wrong codes are throttled like logins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Claim an anonymous card",
+                "parameters": [
+                    {
+                        "description": "Card ID and verification code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ClaimCardReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card claimed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid card or verification code",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/cards/{rf_id}/block": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Block a lost or stolen card owned by the authenticated user. The card is added to the gate hotlist within seconds; only staff can unblock it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Block my card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card blocked",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Card is already blocked or expired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/cards/{rf_id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Check-ins and check-outs of a card owned by the authenticated user, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List taps of my card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only taps at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only taps before this time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card history retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StationHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/journeys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Journeys of the authenticated user's cards, pairing each check-in with the following check-out, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my journeys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only journeys of this card",
+                        "name": "card_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only taps at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only taps before this time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Journeys retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/utils.Journey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Notifications sent or queued for the authenticated user, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by event (low_balance, topup, card_blocked, card_expiring)",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by delivery status (pending, sent, failed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notifications retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Notification"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Which account events the authenticated user is notified about and on which channels. Users who never saved preferences get the defaults (every event by email).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get my notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "Preferences retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.NotificationPreference"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn notifications for low balance, top-ups, blocked cards and expiring cards on or off, set the low balance threshold and choose email, SMS or push",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Update my notification preferences",
+                "parameters": [
+                    {
+                        "description": "Preferences to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationPreferenceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Preferences updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.NotificationPreference"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid preferences",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Transfers sent from or received by the authenticated user's cards, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my transfers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status (pending, completed, rejected, cancelled)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfers retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.BalanceTransfer"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move balance from a card owned by the authenticated user to another card. Between two of my cards the transfer completes immediately; to someone else's card it stays pending until the recipient approves it. Blocked or expired source cards can only be transferred from by staff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Transfer balance from my card",
+                "parameters": [
+                    {
+                        "description": "Cards and amount",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransferReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer completed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Transfer waiting for the recipient's approval",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid transfer, insufficient balance or daily limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Source card is blocked or expired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/transfers/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accept a pending transfer to a card owned by the authenticated user. Balance, card status and the daily limit are checked again before the money moves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Approve an incoming transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer completed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Insufficient balance or daily limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Source card is blocked or expired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Transfer not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Transfer is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/transfers/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraw a transfer requested by the authenticated user before the recipient approves it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Cancel my pending transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer cancelled",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Transfer not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Transfer is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/transfers/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Decline a pending transfer to a card owned by the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Reject an incoming transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer rejected",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BalanceTransfer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Transfer not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Transfer is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/org": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Organization of the authenticated user. Owners and managers see every member and card; members only see themselves and their own cards.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Get my organization",
+                "responses": {
+                    "200": {
+                        "description": "Organization retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.OrganizationDetail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not a member of an organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/org/cards/{rf_id}/limit": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change how much the shared wallet may top up a member card each month (0 = unlimited). The limit does not restrict what the card spends. Owners and managers only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Set card funding limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Monthly funding limit",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardLimitReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Limit updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.OrganizationCard"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an owner or manager",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card does not belong to the organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/org/cards/{rf_id}/topup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move money from the shared wallet onto an organization card, within the card's monthly funding limit. Owners and managers can fund any card; members only the cards they hold.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Top up card from shared wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "rf_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WalletTopupReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Card topped up",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Card"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Insufficient wallet balance or monthly funding limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Card does not belong to the organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/org/statements/{month}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shared wallet statement for a month (YYYY-MM), including the fares and balance transfers paid by the organization's cards. Owners and managers only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Get my organization's monthly statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month (YYYY-MM)",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statement retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/utils.OrgStatement"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid month",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an owner or manager",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not a member of an organization",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedule/exceptions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a service exception",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Delete a service exception",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service exception ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service exception deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Service exception not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedule/generate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate concrete trips from all service patterns for a date range (default: the next 7 days). Existing trips are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Generate trips from service patterns",
+                "parameters": [
+                    {
+                        "description": "Date range",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GenerateTripsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trips generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedule/patterns": {
+            "get": {
+                "description": "Retrieve all service patterns with their stops and exceptions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Get all service patterns",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Filter by line ID",
+                        "name": "line_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service patterns retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ServicePattern"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a service pattern with its stops, days of operation and departure window",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Create a service pattern",
+                "parameters": [
+                    {
+                        "description": "Service pattern information",
+                        "name": "pattern",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ServicePatternReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Service pattern created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServicePattern"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedule/patterns/{id}": {
+            "get": {
+                "description": "Retrieve a specific service pattern with its stops and exceptions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Get service pattern by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service pattern ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service pattern retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServicePattern"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Service pattern not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace a service pattern and its stops. Generated trips that have not started yet are removed and must be generated again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Update service pattern",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service pattern ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated service pattern information",
+                        "name": "pattern",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ServicePatternReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service pattern updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServicePattern"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Service pattern not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a service pattern together with its stops, exceptions and generated trips",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Delete service pattern",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service pattern ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service pattern deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Service pattern not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedule/patterns/{id}/exceptions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add or remove a single service day (e.g. a public holiday) for a service pattern",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Add a service exception",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service pattern ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Service exception information",
+                        "name": "exception",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ServiceExceptionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Service exception created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ServiceException"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Service pattern not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "The pattern already has an exception for this date",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sell-history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all sell history records with optional filtering. Staff only see sales at their assigned stations.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sell-history"
+                ],
+                "summary": "Get all sell history records",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by card ID",
+                        "name": "card_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by seller ID",
+                        "name": "seller_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by station where the card was sold",
+                        "name": "station_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sell histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SellHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sell-history/card/{card_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all sell history records for a specific card",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sell-history"
+                ],
+                "summary": "Get sell histories by card ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "card_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sell histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SellHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sell-history/seller/{seller_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all sell history records for a specific seller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sell-history"
+                ],
+                "summary": "Get sell histories by seller ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Seller ID",
+                        "name": "seller_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sell histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SellHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sell-history/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific sell history record by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sell-history"
+                ],
+                "summary": "Get sell history by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Sell History ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sell history retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.SellHistory"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Sell history not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station": {
+            "get": {
+                "description": "Retrieve all metro stations with optional status filter and name search",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Get all stations with filters",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "inactive",
+                            "maintenance",
+                            "closed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by station name (partial match, case-insensitive)",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new metro station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Create a new station",
+                "parameters": [
+                    {
+                        "description": "Station information",
+                        "name": "station",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Station"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station-history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all station history records with optional filtering. Staff only see records at their assigned stations.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station-history"
+                ],
+                "summary": "Get all station history records",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by card ID",
+                        "name": "card_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by station ID",
+                        "name": "station_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action (checkin/checkout)",
+                        "name": "action",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StationHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station-history/action/{action}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all station history records for a specific action (checkin/checkout)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station-history"
+                ],
+                "summary": "Get station histories by action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Action (checkin/checkout)",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StationHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station-history/card/{card_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all station history records for a specific card",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station-history"
+                ],
+                "summary": "Get station histories by card ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "card_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StationHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station-history/station/{station_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all station history records for a specific station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station-history"
+                ],
+                "summary": "Get station histories by station ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "station_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station histories retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StationHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Staff is not assigned to this station",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station-history/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific station history record by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station-history"
+                ],
+                "summary": "Get station history by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station History ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station history retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.StationHistory"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Station history not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}": {
+            "get": {
+                "description": "Retrieve a specific station by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Get station by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Station"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Update station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated station information",
+                        "name": "station",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Station"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Delete station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}/board/stream": {
+            "get": {
+                "description": "Server-Sent Events stream for a station screen. Sends a \"departures\" snapshot on connect and whenever departures or delays change, and a \"heartbeat\" event every 15 seconds. Clients reconnect automatically (retry: 3s) and always receive a fresh snapshot.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Live departure board stream",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/utils.BoardSnapshot"
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}/checkin": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Check in a card at a specific station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Check in at station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Check-in information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CheckInRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Check-in successful",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.StationHistory"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Device inactive, not a gate of this station, or card blocked/expired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station or card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}/checkout": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Check out a card at a specific station and deduct fare",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Check out at station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Check-out information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CheckOutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Check-out successful",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.StationHistory"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Device inactive, not a gate of this station, or card blocked/expired",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station or card not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}/departures": {
+            "get": {
+                "description": "List the next scheduled departures from a station, based on trips generated from service patterns",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Get next departures from a station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of departures (default: 10, max: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time in RFC3339 format (default: now)",
+                        "name": "from",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Departures retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Departure"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/station/{id}/gate/batch": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceID": [],
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Replay taps a gate stored while offline, in device sequence order. Taps already uploaded are reported as duplicate with their stored result. Insufficient balance and blocked cards are resolved by the offline policy (OFFLINE_BALANCE_POLICY, OFFLINE_BLOCKED_CARD_POLICY).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "station"
+                ],
+                "summary": "Upload offline gate taps",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Offline taps",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OfflineTapBatchReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Offline taps processed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.OfflineTapBatchRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Device not registered or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Device inactive or not a gate of this station",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/train": {
+            "get": {
+                "description": "Retrieve all train records with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Get all train records",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by train type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by company",
+                        "name": "company",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trains retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Train"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new train record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Create a new train record",
+                "parameters": [
+                    {
+                        "description": "Train information",
+                        "name": "train",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Train"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Train created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Train"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/train/company/{company}": {
+            "get": {
+                "description": "Retrieve all train records for a specific company",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Get trains by company",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Company name",
+                        "name": "company",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trains retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Train"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/train/type/{type}": {
+            "get": {
+                "description": "Retrieve all train records for a specific type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Get trains by type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Train type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trains retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Train"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/train/{id}": {
+            "get": {
+                "description": "Retrieve a specific train record by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Get train by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Train ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Train retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Train"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Train not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing train record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Update train",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Train ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated train information",
+                        "name": "train",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Train"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Train updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Train"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Train not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a train record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "train"
+                ],
+                "summary": "Delete train",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Train ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Train deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Train not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip": {
+            "get": {
+                "description": "Retrieve all trip records with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get all trip records",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by train ID",
+                        "name": "train_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by direction",
+                        "name": "direction",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trips retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Trip"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new trip record for train journeys",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Create a new trip record",
+                "parameters": [
+                    {
+                        "description": "Trip information",
+                        "name": "trip",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Trip"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/active": {
+            "get": {
+                "description": "Retrieve all trips that are not completed or cancelled and whose end time, including the reported delay, is in the future",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get active trips",
+                "responses": {
+                    "200": {
+                        "description": "Active trips retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Trip"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/direction/{direction}": {
+            "get": {
+                "description": "Retrieve all trip records for a specific direction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get trips by direction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Direction",
+                        "name": "direction",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trips retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Trip"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/train/{train_id}": {
+            "get": {
+                "description": "Retrieve all trip records for a specific train",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get trips by train ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Train ID",
+                        "name": "train_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trips retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Trip"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/{id}": {
+            "get": {
+                "description": "Retrieve a specific trip record by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get trip by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Trip"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Trip not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing trip record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Update trip",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated trip information",
+                        "name": "trip",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Trip"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Trip not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a trip record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Delete trip",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Trip not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/{id}/position": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report the current station, status and delay of a trip. If delay_seconds is omitted it is computed from the schedule.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Report trip position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Position report",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TripPositionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip position updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TripProgressDetail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Trip not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Trip already completed or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/trip/{id}/progress": {
+            "get": {
+                "description": "Retrieve the live state of a trip with scheduled and predicted arrival times for each stop",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trip"
+                ],
+                "summary": "Get trip progress",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trip progress retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TripProgressDetail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Trip not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Show whether two-factor authentication is enabled or required for the current user, and how many recovery codes are left",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get two-factor status",
+                "responses": {
+                    "200": {
+                        "description": "Two-factor status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.TwoFactorStatusRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn two-factor authentication off with the password and a current code or recovery code. Not allowed for roles that require it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Password and code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisableTwoFactorReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication disabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Wrong password or code, or not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor authentication is required for this role",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/enable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm the secret from /user/2fa/setup with a current code. Returns recovery codes, shown only once. Refresh the access token afterwards to lift the two-factor restriction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Enable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Authenticator code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorCodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication enabled",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.RecoveryCodesRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Wrong code, not set up or already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace all recovery codes after confirming with a current authenticator code. Old codes stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "description": "Authenticator code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorCodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recovery codes regenerated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.RecoveryCodesRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Wrong code or not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/setup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a TOTP secret and provisioning URI after re-checking the password. Two-factor authentication is only turned on after /user/2fa/enable confirms a code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Start two-factor enrolment",
+                "parameters": [
+                    {
+                        "description": "Current password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PasswordConfirmReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Secret generated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handlers.TwoFactorSetupRes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Wrong password or already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change current user's password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Change user password",
+                "parameters": [
+                    {
+                        "description": "Password change data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChangePasswordReq"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/user/profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get current user's profile information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user profile",
+                "responses": {}
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update current user's email and full name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user profile",
+                "parameters": [
+                    {
+                        "description": "Password change data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profile updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "consts.AlertEffect": {
+            "type": "string",
+            "enum": [
+                "delay",
+                "station_closure",
+                "line_closure",
+                "station_outage",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "AlertEffectDelay",
+                "AlertEffectStationClosure",
+                "AlertEffectLineClosure",
+                "AlertEffectStationOutage",
+                "AlertEffectOther"
+            ]
+        },
+        "consts.AlertSeverity": {
+            "type": "string",
+            "enum": [
+                "info",
+                "warning",
+                "severe"
+            ],
+            "x-enum-varnames": [
+                "AlertInfo",
+                "AlertWarning",
+                "AlertSevere"
+            ]
+        },
         "consts.CardAction": {
+            "type": "integer",
+            "enum": [
+                1,
+                2,
+                3,
+                4,
+                5
+            ],
+            "x-enum-varnames": [
+                "CardActionTopup",
+                "CardActionPay",
+                "CardActionRefund",
+                "CardActionTransferOut",
+                "CardActionTransferIn"
+            ]
+        },
+        "consts.CardDebitType": {
+            "type": "string",
+            "enum": [
+                "fare",
+                "transfer"
+            ],
+            "x-enum-comments": {
+                "CardDebitFare": "tiền vé khi ra ga",
+                "CardDebitTransfer": "chuyển số dư sang thẻ khác"
+            },
+            "x-enum-descriptions": [
+                "tiền vé khi ra ga",
+                "chuyển số dư sang thẻ khác"
+            ],
+            "x-enum-varnames": [
+                "CardDebitFare",
+                "CardDebitTransfer"
+            ]
+        },
+        "consts.CardType": {
+            "type": "integer",
+            "enum": [
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "StudentCard",
+                "NormalCard",
+                "VipCard"
+            ]
+        },
+        "consts.DeviceConnectivity": {
+            "type": "string",
+            "enum": [
+                "unknown",
+                "online",
+                "offline"
+            ],
+            "x-enum-comments": {
+                "DeviceOffline": "im lặng quá thời gian cho phép",
+                "DeviceUnknown": "chưa từng gửi request"
+            },
+            "x-enum-descriptions": [
+                "chưa từng gửi request",
+                "im lặng quá thời gian cho phép"
+            ],
+            "x-enum-varnames": [
+                "DeviceUnknown",
+                "DeviceOnline",
+                "DeviceOffline"
+            ]
+        },
+        "consts.DeviceType": {
+            "type": "string",
+            "enum": [
+                "gate",
+                "ticket_machine"
+            ],
+            "x-enum-varnames": [
+                "GateDevice",
+                "TicketMachineDevice"
+            ]
+        },
+        "consts.Direction": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "OutboundDirection",
+                "InboundDirection"
+            ]
+        },
+        "consts.DomainEventType": {
+            "type": "string",
+            "enum": [
+                "card.sold",
+                "card.topped_up",
+                "card.checked_in",
+                "card.checked_out",
+                "journey.completed"
+            ],
+            "x-enum-varnames": [
+                "DomainCardSold",
+                "DomainCardToppedUp",
+                "DomainCardCheckedIn",
+                "DomainCardCheckedOut",
+                "DomainJourneyCompleted"
+            ]
+        },
+        "consts.NotificationChannel": {
+            "type": "string",
+            "enum": [
+                "email",
+                "sms",
+                "push"
+            ],
+            "x-enum-varnames": [
+                "ChannelEmail",
+                "ChannelSMS",
+                "ChannelPush"
+            ]
+        },
+        "consts.NotificationEvent": {
+            "type": "string",
+            "enum": [
+                "low_balance",
+                "topup",
+                "card_blocked",
+                "card_expiring"
+            ],
+            "x-enum-comments": {
+                "NotifyCardBlocked": "thẻ bị khóa",
+                "NotifyCardExpiring": "thẻ sắp hết hạn",
+                "NotifyLowBalance": "số dư thẻ xuống dưới ngưỡng khách đặt",
+                "NotifyTopUp": "xác nhận nạp tiền"
+            },
+            "x-enum-descriptions": [
+                "số dư thẻ xuống dưới ngưỡng khách đặt",
+                "xác nhận nạp tiền",
+                "thẻ bị khóa",
+                "thẻ sắp hết hạn"
+            ],
+            "x-enum-varnames": [
+                "NotifyLowBalance",
+                "NotifyTopUp",
+                "NotifyCardBlocked",
+                "NotifyCardExpiring"
+            ]
+        },
+        "consts.NotificationStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "sent",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "NotificationFailed": "hết số lần thử",
+                "NotificationPending": "chờ gửi hoặc chờ gửi lại"
+            },
+            "x-enum-descriptions": [
+                "chờ gửi hoặc chờ gửi lại",
+                "hết số lần thử"
+            ],
+            "x-enum-varnames": [
+                "NotificationPending",
+                "NotificationSent",
+                "NotificationFailed"
+            ]
+        },
+        "consts.OrgRole": {
+            "type": "string",
+            "enum": [
+                "owner",
+                "manager",
+                "member"
+            ],
+            "x-enum-comments": {
+                "OrgManager": "quản lý thẻ, hạn mức và ví",
+                "OrgMember": "chỉ dùng thẻ của mình",
+                "OrgOwner": "chủ tài khoản (phụ huynh, công ty)"
+            },
+            "x-enum-descriptions": [
+                "chủ tài khoản (phụ huynh, công ty)",
+                "quản lý thẻ, hạn mức và ví",
+                "chỉ dùng thẻ của mình"
+            ],
+            "x-enum-varnames": [
+                "OrgOwner",
+                "OrgManager",
+                "OrgMember"
+            ]
+        },
+        "consts.OrgType": {
+            "type": "string",
+            "enum": [
+                "family",
+                "corporate"
+            ],
+            "x-enum-varnames": [
+                "FamilyOrg",
+                "CorporateOrg"
+            ]
+        },
+        "consts.Permission": {
+            "type": "string",
+            "enum": [
+                "card:read",
+                "card:create",
+                "card:update",
+                "card:delete",
+                "card:topup",
+                "station:manage",
+                "station:operate",
+                "train:manage",
+                "trip:manage",
+                "trip:report",
+                "schedule:manage",
+                "alert:manage",
+                "device:manage",
+                "report:read",
+                "user:manage",
+                "role:manage",
+                "audit:read",
+                "org:manage",
+                "webhook:manage",
+                "card:transfer"
+            ],
+            "x-enum-comments": {
+                "PermissionCardTransfer": "chuyển số dư tại quầy; mặc định chỉ admin"
+            },
+            "x-enum-descriptions": [
+                "chuyển số dư tại quầy; mặc định chỉ admin"
+            ],
+            "x-enum-varnames": [
+                "PermissionCardRead",
+                "PermissionCardCreate",
+                "PermissionCardUpdate",
+                "PermissionCardDelete",
+                "PermissionCardTopup",
+                "PermissionStationManage",
+                "PermissionStationOperate",
+                "PermissionTrainManage",
+                "PermissionTripManage",
+                "PermissionTripReport",
+                "PermissionScheduleManage",
+                "PermissionAlertManage",
+                "PermissionDeviceManage",
+                "PermissionReportRead",
+                "PermissionUserManage",
+                "PermissionRoleManage",
+                "PermissionAuditRead",
+                "PermissionOrgManage",
+                "PermissionWebhookManage",
+                "PermissionCardTransfer"
+            ]
+        },
+        "consts.Role": {
             "type": "integer",
             "enum": [
                 1,
                 2,
                 3
             ],
-            "x-enum-varnames": [
-                "CardActionTopup",
-                "CardActionPay",
-                "CardActionRefund"
-            ]
+            "x-enum-varnames": [
+                "AdminRole",
+                "StaffRole",
+                "UserRole"
+            ]
+        },
+        "consts.ServiceExceptionType": {
+            "type": "integer",
+            "enum": [
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "ServiceAdded",
+                "ServiceRemoved"
+            ]
+        },
+        "consts.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "inactive",
+                "blocked",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "ActiveStatus",
+                "InactiveStatus",
+                "BlockedStatus",
+                "ExpiredStatus"
+            ]
+        },
+        "consts.TransferStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "completed",
+                "rejected",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "TransferCancelled": "người gửi hủy trước khi được duyệt",
+                "TransferCompleted": "đã chuyển tiền",
+                "TransferPending": "chờ chủ thẻ nhận đồng ý",
+                "TransferRejected": "chủ thẻ nhận từ chối"
+            },
+            "x-enum-descriptions": [
+                "chờ chủ thẻ nhận đồng ý",
+                "đã chuyển tiền",
+                "chủ thẻ nhận từ chối",
+                "người gửi hủy trước khi được duyệt"
+            ],
+            "x-enum-varnames": [
+                "TransferPending",
+                "TransferCompleted",
+                "TransferRejected",
+                "TransferCancelled"
+            ]
+        },
+        "consts.TripStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "at_station",
+                "in_transit",
+                "completed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "TripScheduled",
+                "TripAtStation",
+                "TripInTransit",
+                "TripCompleted",
+                "TripCancelled"
+            ]
+        },
+        "consts.UserAction": {
+            "type": "integer",
+            "enum": [
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "UserActionCheckin",
+                "UserActionCheckout"
+            ]
+        },
+        "consts.WalletEntryType": {
+            "type": "string",
+            "enum": [
+                "deposit",
+                "topup"
+            ],
+            "x-enum-comments": {
+                "WalletDeposit": "nạp tiền vào ví",
+                "WalletTopup": "ví nạp tiền cho thẻ thành viên"
+            },
+            "x-enum-descriptions": [
+                "nạp tiền vào ví",
+                "ví nạp tiền cho thẻ thành viên"
+            ],
+            "x-enum-varnames": [
+                "WalletDeposit",
+                "WalletTopup"
+            ]
+        },
+        "consts.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "dead"
+            ],
+            "x-enum-comments": {
+                "WebhookDead": "hết số lần thử, nằm trong dead-letter chờ gửi lại thủ công",
+                "WebhookDelivered": "bên nhận trả 2xx",
+                "WebhookPending": "chờ gửi hoặc chờ gửi lại"
+            },
+            "x-enum-descriptions": [
+                "chờ gửi hoặc chờ gửi lại",
+                "bên nhận trả 2xx",
+                "hết số lần thử, nằm trong dead-letter chờ gửi lại thủ công"
+            ],
+            "x-enum-varnames": [
+                "WebhookPending",
+                "WebhookDelivered",
+                "WebhookDead"
+            ]
+        },
+        "handlers.CardExpiryReq": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "null: thẻ không hết hạn",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CardLimitReq": {
+            "type": "object",
+            "properties": {
+                "monthly_funding_limit": {
+                    "description": "0: không giới hạn",
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "handlers.CardRefundRes": {
+            "type": "object",
+            "properties": {
+                "card": {
+                    "$ref": "#/definitions/models.Card"
+                },
+                "refunded": {
+                    "description": "số dư đã hoàn cho khách",
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.CardReq": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "hạn dùng (thẻ học sinh, vé tháng); bỏ trống nếu thẻ không hết hạn",
+                    "type": "string"
+                },
+                "station_id": {
+                    "description": "trạm bán thẻ; bỏ trống nếu nhân viên chỉ được phân công một trạm",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "bỏ trống để bán thẻ vô danh, khách nhận thẻ sau bằng mã xác minh",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ChangePasswordReq": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "old_password"
+            ],
+            "properties": {
+                "new_password": {
+                    "description": "độ mạnh kiểm tra theo PasswordPolicy",
+                    "type": "string"
+                },
+                "old_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CheckInRequest": {
+            "type": "object",
+            "required": [
+                "card_id"
+            ],
+            "properties": {
+                "card_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CheckOutRequest": {
+            "type": "object",
+            "required": [
+                "card_id"
+            ],
+            "properties": {
+                "card_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ClaimCardReq": {
+            "type": "object",
+            "required": [
+                "rf_id",
+                "verification_code"
+            ],
+            "properties": {
+                "rf_id": {
+                    "type": "string"
+                },
+                "verification_code": {
+                    "description": "mã in trên thẻ, dạng xxxxx-xxxxx",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateOrganizationReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "owner_id",
+                "type"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "family hoặc corporate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.OrgType"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.DeviceCredentialsRes": {
+            "type": "object",
+            "properties": {
+                "device": {
+                    "$ref": "#/definitions/models.Device"
+                },
+                "key_id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DeviceReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "station_id",
+                "type"
+            ],
+            "properties": {
+                "ip_address": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "blocked"
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "gate",
+                        "ticket_machine"
+                    ]
+                }
+            }
+        },
+        "handlers.DisableTwoFactorReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "description": "mã TOTP 6 số hoặc mã khôi phục",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.EmailReq": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.GenerateTripsReq": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-01"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-01-07"
+                }
+            }
+        },
+        "handlers.HeartbeatReq": {
+            "type": "object",
+            "required": [
+                "device_time"
+            ],
+            "properties": {
+                "device_time": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "firmware_version": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "queue_size": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "uptime_seconds": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "handlers.HeartbeatRes": {
+            "type": "object",
+            "properties": {
+                "clock_drift_ms": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "server_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LineReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "name"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "color": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginReq": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "handlers.NotificationPreferenceReq": {
+            "type": "object",
+            "properties": {
+                "card_blocked": {
+                    "type": "boolean"
+                },
+                "card_expiring": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "boolean"
+                },
+                "low_balance": {
+                    "type": "boolean"
+                },
+                "low_balance_threshold": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "push": {
+                    "description": "cần push_token",
+                    "type": "boolean"
+                },
+                "push_token": {
+                    "type": "string"
+                },
+                "sms": {
+                    "description": "gửi tới số điện thoại trong hồ sơ",
+                    "type": "boolean"
+                },
+                "topup": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.OfflineTapBatchReq": {
+            "type": "object",
+            "required": [
+                "taps"
+            ],
+            "properties": {
+                "taps": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.OfflineTapReq"
+                    }
+                }
+            }
+        },
+        "handlers.OfflineTapBatchRes": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "duplicate": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.OfflineTapResult"
+                    }
+                },
+                "station_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.OfflineTapReq": {
+            "type": "object",
+            "required": [
+                "action",
+                "card_id",
+                "sequence",
+                "tapped_at"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "checkin",
+                        "checkout"
+                    ]
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "tapped_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.OfflineTapResult": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "kết quả đã lưu khi status = duplicate",
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "station_history_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.OrganizationCardDetail": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "claimed_at": {
+                    "description": "Thẻ vô danh bán ở kiosk được khách nhận bằng RFID và mã xác minh in trên thẻ.\nChỉ lưu hash của mã; mã dạng rõ chỉ trả về một lần khi tạo thẻ để in.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "xóa mềm; lịch sử vẫn tham chiếu được",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "expires_at": {
+                    "description": "hạn dùng của thẻ (thẻ học sinh, vé tháng); nil: không hết hạn",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "monthly_funded": {
+                    "type": "number"
+                },
+                "monthly_funding_limit": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "rf_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.Status"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.CardType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "description": "nil: thẻ vô danh bán ở kiosk, chưa được khách nhận",
+                    "type": "integer"
+                },
+                "verification_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.OrganizationCardReq": {
+            "type": "object",
+            "required": [
+                "rf_id"
+            ],
+            "properties": {
+                "monthly_funding_limit": {
+                    "description": "0: không giới hạn",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "rf_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "thành viên được giao thẻ",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.OrganizationDetail": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.OrganizationCardDetail"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationMember"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.OrgType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "wallet_balance": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.OrganizationMemberReq": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "description": "owner, manager hoặc member (mặc định member)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.OrgRole"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.PasswordConfirmReq": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PatternStopReq": {
+            "type": "object",
+            "required": [
+                "station_id"
+            ],
+            "properties": {
+                "arrival_offset": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "departure_offset": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "station_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.RecoveryCodesRes": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.RefreshTokenReq": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RegisterReq": {
+            "type": "object",
+            "required": [
+                "email",
+                "full_name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "full_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "độ mạnh kiểm tra theo PasswordPolicy",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReplaceCardReq": {
+            "type": "object",
+            "properties": {
+                "station_id": {
+                    "description": "trạm cấp thẻ mới; bỏ trống nếu nhân viên chỉ được phân công một trạm",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ResetPasswordReq": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "description": "độ mạnh kiểm tra theo PasswordPolicy",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RolePermissionsReq": {
+            "type": "object",
+            "required": [
+                "permissions"
+            ],
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.RolePermissionsRes": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/consts.Permission"
+                    }
+                },
+                "role": {
+                    "$ref": "#/definitions/consts.Role"
+                },
+                "role_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ServiceAlertReq": {
+            "type": "object",
+            "required": [
+                "effect",
+                "header_vi",
+                "severity"
+            ],
+            "properties": {
+                "active_from": {
+                    "description": "mặc định là thời điểm tạo",
+                    "type": "string"
+                },
+                "active_until": {
+                    "type": "string"
+                },
+                "description_en": {
+                    "type": "string"
+                },
+                "description_vi": {
+                    "type": "string"
+                },
+                "effect": {
+                    "type": "string",
+                    "enum": [
+                        "delay",
+                        "station_closure",
+                        "line_closure",
+                        "station_outage",
+                        "other"
+                    ]
+                },
+                "header_en": {
+                    "type": "string"
+                },
+                "header_vi": {
+                    "type": "string"
+                },
+                "line_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "severity": {
+                    "type": "string",
+                    "enum": [
+                        "info",
+                        "warning",
+                        "severe"
+                    ]
+                },
+                "station_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "trip_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.ServiceExceptionReq": {
+            "type": "object",
+            "required": [
+                "date",
+                "type"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2025-04-30"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "added",
+                        "removed"
+                    ]
+                }
+            }
+        },
+        "handlers.ServicePatternReq": {
+            "type": "object",
+            "required": [
+                "first_departure",
+                "last_departure",
+                "line_id",
+                "name",
+                "stops",
+                "train_id"
+            ],
+            "properties": {
+                "direction": {
+                    "enum": [
+                        0,
+                        1
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.Direction"
+                        }
+                    ]
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
+                "first_departure": {
+                    "type": "string",
+                    "example": "05:30"
+                },
+                "friday": {
+                    "type": "boolean"
+                },
+                "headway_minutes": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                },
+                "last_departure": {
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "line_id": {
+                    "type": "integer"
+                },
+                "monday": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "saturday": {
+                    "type": "boolean"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-01-01"
+                },
+                "stops": {
+                    "type": "array",
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/handlers.PatternStopReq"
+                    }
+                },
+                "sunday": {
+                    "type": "boolean"
+                },
+                "thursday": {
+                    "type": "boolean"
+                },
+                "train_id": {
+                    "type": "integer"
+                },
+                "tuesday": {
+                    "type": "boolean"
+                },
+                "wednesday": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.StaffTransferReq": {
+            "type": "object",
+            "required": [
+                "amount",
+                "from_card_id",
+                "to_card_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "from_card_id": {
+                    "type": "string"
+                },
+                "override": {
+                    "description": "cho phép chuyển từ thẻ bị khóa hoặc hết hạn",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "description": "bắt buộc khi override",
+                    "type": "string"
+                },
+                "to_card_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.StationReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "ip_address": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "maintenance",
+                        "closed"
+                    ]
+                }
+            }
+        },
+        "handlers.TransferReq": {
+            "type": "object",
+            "required": [
+                "amount",
+                "from_card_id",
+                "to_card_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "from_card_id": {
+                    "description": "thẻ của tôi",
+                    "type": "string"
+                },
+                "to_card_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TripPositionReq": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "delay_seconds": {
+                    "description": "bỏ trống để server tự tính theo lịch",
+                    "type": "integer"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "at_station",
+                        "in_transit",
+                        "completed",
+                        "cancelled"
+                    ]
+                }
+            }
+        },
+        "handlers.TwoFactorCodeReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TwoFactorLoginReq": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "mã TOTP 6 số hoặc mã khôi phục",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TwoFactorSetupRes": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "description": "otpauth://, hiển thị dưới dạng mã QR",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TwoFactorStatusRes": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "enabled_at": {
+                    "type": "string"
+                },
+                "recovery_codes_remaining": {
+                    "type": "integer"
+                },
+                "required": {
+                    "description": "role bắt buộc bật 2FA",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.UnlockIPReq": {
+            "type": "object",
+            "required": [
+                "ip"
+            ],
+            "properties": {
+                "ip": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UpdateCardReq": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "bỏ trống nếu không đổi số dư",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "blocked",
+                        "expired"
+                    ]
+                },
+                "type": {
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UpdateWebhookSubscriptionReq": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "tắt subscription chuyển các lần gửi còn chờ sang dead-letter",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/consts.DomainEventType"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UserStationsReq": {
+            "type": "object",
+            "required": [
+                "station_ids"
+            ],
+            "properties": {
+                "station_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.VerifyEmailReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.WalletDepositReq": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.WalletTopupReq": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.WebhookSubscriptionReq": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "description": "\"*\": mọi loại sự kiện",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/consts.DomainEventType"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditChange": {
+            "type": "object",
+            "properties": {
+                "from": {},
+                "to": {}
+            }
+        },
+        "models.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "ví dụ \"user.update\", \"card.delete\"",
+                    "type": "string"
+                },
+                "actor_email": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "actor_role": {
+                    "type": "integer"
+                },
+                "after": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "before": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.AuditChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "prev_hash": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BalanceTransfer": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "approved_by": {
+                    "description": "chủ thẻ nhận đồng ý/từ chối",
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_card_id": {
+                    "description": "rf_id thẻ nguồn",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "override_by": {
+                    "description": "nhân viên cho phép chuyển từ thẻ bị khóa/hết hạn",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.TransferStatus"
+                },
+                "to_card_id": {
+                    "description": "rf_id thẻ nhận",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Card": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "claimed_at": {
+                    "description": "Thẻ vô danh bán ở kiosk được khách nhận bằng RFID và mã xác minh in trên thẻ.\nChỉ lưu hash của mã; mã dạng rõ chỉ trả về một lần khi tạo thẻ để in.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "xóa mềm; lịch sử vẫn tham chiếu được",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "expires_at": {
+                    "description": "hạn dùng của thẻ (thẻ học sinh, vé tháng); nil: không hết hạn",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "rf_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.Status"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.CardType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "description": "nil: thẻ vô danh bán ở kiosk, chưa được khách nhận",
+                    "type": "integer"
+                },
+                "verification_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Departure": {
+            "type": "object",
+            "properties": {
+                "arrival_time": {
+                    "type": "string"
+                },
+                "delay_seconds": {
+                    "type": "integer"
+                },
+                "departure_time": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "expected_departure_time": {
+                    "type": "string"
+                },
+                "late": {
+                    "type": "boolean"
+                },
+                "line_code": {
+                    "type": "string"
+                },
+                "line_name": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.TripStatus"
+                },
+                "stop_sequence": {
+                    "type": "integer"
+                },
+                "train_name": {
+                    "type": "string"
+                },
+                "trip_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Device": {
+            "type": "object",
+            "properties": {
+                "clock_drift_ms": {
+                    "description": "đồng hồ thiết bị trừ đồng hồ máy chủ",
+                    "type": "integer"
+                },
+                "connectivity": {
+                    "description": "Tình trạng theo heartbeat gần nhất",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.DeviceConnectivity"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error_count": {
+                    "description": "tổng bộ đếm lỗi trong heartbeat gần nhất",
+                    "type": "integer"
+                },
+                "firmware_version": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "description": "nếu có, chỉ chấp nhận request từ IP này",
+                    "type": "string"
+                },
+                "key_id": {
+                    "type": "string"
+                },
+                "last_heartbeat_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "description": "request đã xác thực gần nhất",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "queue_size": {
+                    "description": "số lượt quẹt ngoại tuyến chưa gửi",
+                    "type": "integer"
+                },
+                "station": {
+                    "$ref": "#/definitions/models.Station"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.Status"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.DeviceType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeviceHeartbeat": {
+            "type": "object",
+            "properties": {
+                "clock_drift_ms": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_time": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "bộ đếm lỗi theo loại, vd. {\"reader\": 2}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "firmware_version": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "queue_size": {
+                    "type": "integer"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeviceTap": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "số dư thẻ sau khi xử lý",
+                    "type": "number"
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "station_history_id": {
+                    "type": "integer"
+                },
+                "tapped_at": {
+                    "description": "thời gian theo đồng hồ của thiết bị",
+                    "type": "string"
+                }
+            }
+        },
+        "models.History": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "card_action": {
+                    "$ref": "#/definitions/consts.CardAction"
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "transfer_id": {
+                    "description": "cặp bút toán transfer_out/transfer_in của cùng một lần chuyển số dư",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_action": {
+                    "$ref": "#/definitions/consts.UserAction"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Line": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginThrottle": {
+            "type": "object",
+            "properties": {
+                "blocked_until": {
+                    "description": "backoff: chưa được thử lại trước thời điểm này",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "email (chữ thường) hoặc IP",
+                    "type": "string"
+                },
+                "last_failure_at": {
+                    "type": "string"
+                },
+                "locked_until": {
+                    "description": "khóa tạm thời sau khi vượt ngưỡng",
+                    "type": "string"
+                },
+                "scope": {
+                    "description": "\"account\" hoặc \"ip\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "channel": {
+                    "$ref": "#/definitions/consts.NotificationChannel"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dedup_key": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/consts.NotificationEvent"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.NotificationStatus"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NotificationPreference": {
+            "type": "object",
+            "properties": {
+                "card_blocked": {
+                    "type": "boolean"
+                },
+                "card_expiring": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "boolean"
+                },
+                "low_balance": {
+                    "type": "boolean"
+                },
+                "low_balance_threshold": {
+                    "description": "báo khi số dư thẻ xuống dưới mức này",
+                    "type": "number"
+                },
+                "push": {
+                    "description": "gửi tới PushToken",
+                    "type": "boolean"
+                },
+                "push_token": {
+                    "description": "token thiết bị của ứng dụng di động",
+                    "type": "string"
+                },
+                "sms": {
+                    "description": "gửi tới số điện thoại trong hồ sơ",
+                    "type": "boolean"
+                },
+                "topup": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Organization": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.OrgType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "wallet_balance": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.OrganizationCard": {
+            "type": "object",
+            "properties": {
+                "card_id": {
+                    "description": "rf_id của thẻ",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "monthly_funding_limit": {
+                    "type": "number"
+                },
+                "organization_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationMember": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "organization_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/consts.OrgRole"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PatternStop": {
+            "type": "object",
+            "properties": {
+                "arrival_offset": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "departure_offset": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pattern_id": {
+                    "type": "integer"
+                },
+                "station": {
+                    "$ref": "#/definitions/models.Station"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "stop_sequence": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SecurityEvent": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "description": "admin thực hiện (mở khóa)",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SellHistory": {
+            "type": "object",
+            "properties": {
+                "card": {
+                    "description": "Foreign key relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Card"
+                        }
+                    ]
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "card_price_sold": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "seller": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "seller_id": {
+                    "type": "integer"
+                },
+                "station": {
+                    "$ref": "#/definitions/models.Station"
+                },
+                "station_id": {
+                    "description": "trạm nơi bán thẻ",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ServiceAlert": {
+            "type": "object",
+            "properties": {
+                "active_from": {
+                    "type": "string"
+                },
+                "active_until": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "description_en": {
+                    "type": "string"
+                },
+                "description_vi": {
+                    "type": "string"
+                },
+                "effect": {
+                    "$ref": "#/definitions/consts.AlertEffect"
+                },
+                "header_en": {
+                    "type": "string"
+                },
+                "header_vi": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Line"
+                    }
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "type": "integer"
+                },
+                "severity": {
+                    "$ref": "#/definitions/consts.AlertSeverity"
+                },
+                "stations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Station"
+                    }
+                },
+                "trips": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Trip"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
         },
-        "consts.CardType": {
-            "type": "integer",
-            "enum": [
-                1,
-                2,
-                3
-            ],
-            "x-enum-varnames": [
-                "StudentCard",
-                "NormalCard",
-                "VipCard"
-            ]
+        "models.ServiceException": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pattern_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.ServiceExceptionType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
         },
-        "consts.Role": {
-            "type": "integer",
-            "enum": [
-                1,
-                2,
-                3
-            ],
-            "x-enum-varnames": [
-                "AdminRole",
-                "StaffRole",
-                "UserRole"
-            ]
+        "models.ServicePattern": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "direction": {
+                    "$ref": "#/definitions/consts.Direction"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "exceptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ServiceException"
+                    }
+                },
+                "first_departure": {
+                    "description": "\"HH:MM\"",
+                    "type": "string"
+                },
+                "friday": {
+                    "type": "boolean"
+                },
+                "headway_minutes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_departure": {
+                    "description": "\"HH:MM\"",
+                    "type": "string"
+                },
+                "line": {
+                    "description": "Foreign key relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Line"
+                        }
+                    ]
+                },
+                "line_id": {
+                    "type": "integer"
+                },
+                "monday": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "saturday": {
+                    "type": "boolean"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "stops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PatternStop"
+                    }
+                },
+                "sunday": {
+                    "type": "boolean"
+                },
+                "thursday": {
+                    "type": "boolean"
+                },
+                "train": {
+                    "$ref": "#/definitions/models.Train"
+                },
+                "train_id": {
+                    "type": "integer"
+                },
+                "tuesday": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "wednesday": {
+                    "type": "boolean"
+                }
+            }
         },
-        "consts.Status": {
-            "type": "string",
-            "enum": [
-                "active",
-                "inactive",
-                "blocked"
-            ],
-            "x-enum-varnames": [
-                "ActiveStatus",
-                "InactiveStatus",
-                "BlockedStatus"
-            ]
+        "models.Station": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "closed": {
+                    "description": "trạm đóng cửa theo status hoặc thông báo đóng ga",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "xóa mềm; lịch sử vẫn tham chiếu được",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
         },
-        "consts.UserAction": {
-            "type": "integer",
-            "enum": [
-                1,
-                2
-            ],
-            "x-enum-varnames": [
-                "UserActionCheckin",
-                "UserActionCheckout"
-            ]
+        "models.StationHistory": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "\"checkin\" hoặc \"checkout\"",
+                    "type": "string"
+                },
+                "card": {
+                    "description": "Foreign key relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Card"
+                        }
+                    ]
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device": {
+                    "$ref": "#/definitions/models.Device"
+                },
+                "device_id": {
+                    "description": "thiết bị ghi nhận lượt quẹt",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offline": {
+                    "description": "lượt quẹt cổng lưu khi mất kết nối và gửi bù",
+                    "type": "boolean"
+                },
+                "station": {
+                    "$ref": "#/definitions/models.Station"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "used_balance": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.StopPrediction": {
+            "type": "object",
+            "properties": {
+                "late": {
+                    "type": "boolean"
+                },
+                "passed": {
+                    "type": "boolean"
+                },
+                "predicted_arrival": {
+                    "type": "string"
+                },
+                "predicted_departure": {
+                    "type": "string"
+                },
+                "scheduled_arrival": {
+                    "type": "string"
+                },
+                "scheduled_departure": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "station_name": {
+                    "type": "string"
+                },
+                "stop_sequence": {
+                    "type": "integer"
+                }
+            }
         },
-        "handlers.CardReq": {
+        "models.Train": {
             "type": "object",
-            "required": [
-                "type",
-                "user_id"
-            ],
             "properties": {
+                "company": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "xóa mềm; chuyến tàu cũ vẫn tham chiếu được",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
                 "type": {
                     "type": "string"
                 },
-                "user_id": {
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Trip": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pattern": {
+                    "$ref": "#/definitions/models.ServicePattern"
+                },
+                "pattern_id": {
+                    "description": "nil với chuyến tạo thủ công",
+                    "type": "integer"
+                },
+                "progress": {
+                    "$ref": "#/definitions/models.TripProgress"
+                },
+                "service_date": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "train": {
+                    "description": "Foreign key relationships",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Train"
+                        }
+                    ]
+                },
+                "train_id": {
                     "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.ChangePasswordReq": {
+        "models.TripProgress": {
             "type": "object",
-            "required": [
-                "new_password",
-                "old_password"
-            ],
             "properties": {
-                "new_password": {
-                    "type": "string",
-                    "minLength": 6
+                "created_at": {
+                    "type": "string"
                 },
-                "old_password": {
+                "current_station": {
+                    "$ref": "#/definitions/models.Station"
+                },
+                "current_station_id": {
+                    "type": "integer"
+                },
+                "current_stop_sequence": {
+                    "type": "integer"
+                },
+                "delay_seconds": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "late": {
+                    "type": "boolean"
+                },
+                "reported_at": {
+                    "type": "string"
+                },
+                "reported_by": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.TripStatus"
+                },
+                "trip_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "handlers.CheckInRequest": {
+        "models.TripProgressDetail": {
             "type": "object",
-            "required": [
-                "card_id"
-            ],
             "properties": {
-                "card_id": {
+                "progress": {
+                    "$ref": "#/definitions/models.TripProgress"
+                },
+                "stops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StopPrediction"
+                    }
+                },
+                "trip": {
+                    "$ref": "#/definitions/models.Trip"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "xóa mềm; lịch sử vẫn tham chiếu được",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified_at": {
+                    "description": "nil: chưa xác minh email, bị giới hạn quyền",
+                    "type": "string"
+                },
+                "full_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "\"ADMIN\", \"STAFF\", \"USER\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.Role"
+                        }
+                    ]
+                },
+                "role_granted_at": {
+                    "description": "lần admin đổi role gần nhất; nil: role mặc định khi đăng ký",
+                    "type": "string"
+                },
+                "stations": {
+                    "description": "Các trạm nhân viên được phân công; admin không cần phân công (toàn mạng lưới)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Station"
+                    }
+                },
+                "status": {
+                    "description": "\"active\" hoặc \"inactive\"",
+                    "type": "string"
+                },
+                "two_factor_enabled_at": {
+                    "description": "nil: chưa bật xác thực hai bước (TOTP)",
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "handlers.CheckOutRequest": {
+        "models.WalletTransaction": {
             "type": "object",
-            "required": [
-                "card_id"
-            ],
             "properties": {
+                "actor_id": {
+                    "description": "người thực hiện",
+                    "type": "integer"
+                },
+                "amount": {
+                    "description": "dương: tiền vào ví, âm: tiền ra khỏi ví",
+                    "type": "number"
+                },
+                "balance_after": {
+                    "type": "number"
+                },
                 "card_id": {
+                    "description": "thẻ được nạp (chỉ với topup)",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
                     "type": "string"
+                },
+                "organization_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.WalletEntryType"
                 }
             }
         },
-        "handlers.LoginReq": {
+        "models.WebhookDelivery": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/consts.DomainEventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/consts.WebhookDeliveryStatus"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.RegisterReq": {
+        "models.WebhookSubscription": {
             "type": "object",
-            "required": [
-                "email",
-                "full_name",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "full_name": {
+                "created_by": {
+                    "type": "integer"
+                },
+                "description": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "event_types": {
+                    "description": "\"*\": mọi loại sự kiện",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/consts.DomainEventType"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "description": "SigningSecret chỉ có giá trị trong response tạo/đổi secret",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.StationReq": {
+        "utils.AuditChainReport": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "ip_address": {
-                    "type": "string"
+                "broken_at": {
+                    "description": "ID bản ghi đầu tiên không khớp",
+                    "type": "integer"
                 },
-                "name": {
+                "checked": {
+                    "type": "integer"
+                },
+                "reason": {
                     "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
                 }
             }
         },
-        "handlers.UpdateCardReq": {
+        "utils.BoardSnapshot": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "number"
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ServiceAlert"
+                    }
                 },
-                "status": {
-                    "type": "string"
+                "departures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Departure"
+                    }
                 },
-                "type": {
-                    "type": "integer"
+                "generated_at": {
+                    "type": "string"
                 },
-                "user_id": {
+                "station_id": {
                     "type": "integer"
                 }
             }
         },
-        "models.Card": {
+        "utils.FleetDashboard": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "number"
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Device"
+                    }
                 },
-                "created_at": {
+                "generated_at": {
                     "type": "string"
                 },
-                "id": {
+                "offline": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "number"
-                },
-                "rf_id": {
-                    "type": "string"
+                "offline_timeout_seconds": {
+                    "type": "integer"
                 },
-                "status": {
-                    "$ref": "#/definitions/consts.Status"
+                "online": {
+                    "type": "integer"
                 },
-                "type": {
-                    "$ref": "#/definitions/consts.CardType"
+                "silent_stations": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "updated_at": {
-                    "type": "string"
+                "stations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.StationHealth"
+                    }
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "total": {
+                    "type": "integer"
                 },
-                "user_id": {
+                "unknown": {
                     "type": "integer"
                 }
             }
         },
-        "models.History": {
+        "utils.GTFSImportReport": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "number"
+                "created": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 },
-                "card_action": {
-                    "$ref": "#/definitions/consts.CardAction"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "card_id": {
-                    "type": "string"
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.GTFSRejection"
+                    }
                 },
-                "created_at": {
+                "updated": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "utils.GTFSRejection": {
+            "type": "object",
+            "properties": {
+                "file": {
                     "type": "string"
                 },
                 "id": {
-                    "type": "integer"
-                },
-                "time": {
-                    "type": "string"
-                },
-                "updated_at": {
                     "type": "string"
                 },
-                "user_action": {
-                    "$ref": "#/definitions/consts.UserAction"
+                "line": {
+                    "type": "integer"
                 },
-                "user_id": {
+                "reason": {
                     "type": "string"
                 }
             }
         },
-        "models.SellHistory": {
+        "utils.HotlistKey": {
             "type": "object",
             "properties": {
-                "card": {
-                    "description": "Foreign key relationships",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.Card"
-                        }
-                    ]
-                },
-                "card_id": {
-                    "type": "string"
-                },
-                "card_price_sold": {
-                    "type": "number"
-                },
-                "created_at": {
+                "algorithm": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "seller": {
-                    "$ref": "#/definitions/models.User"
-                },
-                "seller_id": {
-                    "type": "integer"
-                },
-                "time": {
+                "key_id": {
                     "type": "string"
                 },
-                "updated_at": {
+                "public_key": {
+                    "description": "base64",
                     "type": "string"
                 }
             }
         },
-        "models.Station": {
+        "utils.Journey": {
             "type": "object",
             "properties": {
-                "address": {
+                "card_id": {
                     "type": "string"
                 },
-                "capacity": {
-                    "type": "integer"
+                "check_in": {
+                    "$ref": "#/definitions/models.StationHistory"
                 },
-                "created_at": {
-                    "type": "string"
+                "check_out": {
+                    "$ref": "#/definitions/models.StationHistory"
                 },
-                "description": {
+                "fare": {
+                    "type": "number"
+                }
+            }
+        },
+        "utils.OrgCardDebit": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "card_id": {
                     "type": "string"
                 },
-                "id": {
+                "station_id": {
+                    "description": "ga ra (với fare)",
                     "type": "integer"
                 },
-                "image_url": {
-                    "type": "string"
-                },
-                "ip_address": {
+                "time": {
                     "type": "string"
                 },
-                "name": {
+                "to_card_id": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "string"
+                "transfer_id": {
+                    "description": "lần chuyển (với transfer)",
+                    "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
+                "type": {
+                    "$ref": "#/definitions/consts.CardDebitType"
                 }
             }
         },
-        "models.StationHistory": {
+        "utils.OrgStatement": {
             "type": "object",
             "properties": {
-                "action": {
-                    "description": "\"checkin\" hoặc \"checkout\"",
-                    "type": "string"
+                "card_debits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.OrgCardDebit"
+                    }
                 },
-                "card": {
-                    "description": "Foreign key relationships",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.Card"
-                        }
-                    ]
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.OrgStatementCard"
+                    }
                 },
-                "card_id": {
-                    "type": "string"
+                "closing_balance": {
+                    "type": "number"
                 },
-                "created_at": {
+                "deposits": {
+                    "type": "number"
+                },
+                "fares": {
+                    "description": "tiền vé các thẻ đã trả",
+                    "type": "number"
+                },
+                "from": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "month": {
+                    "description": "YYYY-MM",
+                    "type": "string"
                 },
-                "station": {
-                    "$ref": "#/definitions/models.Station"
+                "opening_balance": {
+                    "type": "number"
                 },
-                "station_id": {
+                "organization_id": {
                     "type": "integer"
                 },
-                "time": {
+                "to": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "topups": {
+                    "type": "number"
                 },
-                "used_balance": {
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WalletTransaction"
+                    }
+                },
+                "transfers_out": {
+                    "description": "số dư các thẻ đã chuyển đi",
                     "type": "number"
                 }
             }
         },
-        "models.Train": {
+        "utils.OrgStatementCard": {
             "type": "object",
             "properties": {
-                "company": {
+                "card_id": {
                     "type": "string"
                 },
-                "created_at": {
-                    "type": "string"
+                "fares": {
+                    "type": "number"
                 },
-                "id": {
-                    "type": "integer"
+                "monthly_funding_limit": {
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string"
+                "topups": {
+                    "type": "number"
                 },
-                "type": {
-                    "type": "string"
+                "transfers_out": {
+                    "type": "number"
                 },
-                "updated_at": {
-                    "type": "string"
+                "user_id": {
+                    "description": "người giữ thẻ",
+                    "type": "integer"
                 }
             }
         },
-        "models.Trip": {
+        "utils.Response": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "data": {},
+                "error": {
                     "type": "string"
                 },
-                "direction": {
+                "message": {
                     "type": "string"
                 },
-                "end_time": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "utils.SignedHotlist": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "key_id": {
+                    "type": "string"
                 },
-                "start_time": {
+                "payload": {
                     "type": "string"
                 },
-                "train": {
-                    "description": "Foreign key relationships",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.Train"
-                        }
-                    ]
+                "signature": {
+                    "type": "string"
                 },
-                "train_id": {
+                "version": {
                     "type": "integer"
-                },
-                "updated_at": {
-                    "type": "string"
                 }
             }
         },
-        "models.User": {
+        "utils.StationHealth": {
             "type": "object",
             "properties": {
-                "avatar": {
-                    "type": "string"
+                "devices": {
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
+                "gates": {
+                    "type": "integer"
                 },
-                "email": {
-                    "type": "string"
+                "gates_online": {
+                    "type": "integer"
                 },
-                "full_name": {
+                "last_seen_at": {
                     "type": "string"
                 },
-                "id": {
+                "offline": {
                     "type": "integer"
                 },
-                "phone": {
-                    "type": "string"
+                "online": {
+                    "type": "integer"
                 },
-                "role": {
-                    "description": "\"ADMIN\", \"STAFF\", \"USER\"",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/consts.Role"
-                        }
-                    ]
+                "silent": {
+                    "description": "trạm có cổng soát vé nhưng không cổng nào đang online",
+                    "type": "boolean"
                 },
-                "status": {
-                    "description": "\"active\" hoặc \"inactive\"",
-                    "type": "string"
+                "station_id": {
+                    "type": "integer"
                 },
-                "updated_at": {
+                "station_name": {
                     "type": "string"
+                },
+                "unknown": {
+                    "type": "integer"
                 }
             }
         },
-        "utils.Response": {
+        "utils.TokenPair": {
             "type": "object",
             "properties": {
-                "data": {},
-                "error": {
+                "access_token": {
                     "type": "string"
                 },
-                "message": {
+                "expires_in": {
+                    "description": "giây",
+                    "type": "integer"
+                },
+                "refresh_expires_at": {
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
                 }
             }
         }
@@ -3014,6 +13211,18 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "DeviceID": {
+            "description": "Key ID of a registered station device.",
+            "type": "apiKey",
+            "name": "X-Device-ID",
+            "in": "header"
+        },
+        "DeviceKey": {
+            "description": "Device secret. Devices may instead sign requests with X-Device-Timestamp, X-Device-Nonce and X-Device-Signature (HMAC-SHA256).",
+            "type": "apiKey",
+            "name": "X-Device-Key",
+            "in": "header"
         }
     }
 }`
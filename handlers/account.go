@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VerifyEmailReq struct for confirming an email address
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailReq struct for requests identified only by email
+type EmailReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordReq struct for setting a new password with a reset token
+type ResetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // độ mạnh kiểm tra theo PasswordPolicy
+}
+
+// userTokenError trả lỗi của token gửi qua email
+func userTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidUserToken), errors.Is(err, utils.ErrUserTokenExpired):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "failed to process token")
+	}
+}
+
+// VerifyEmail handles POST /auth/verify-email
+// @Summary Verify email address
+// @Description Confirm the email address with the single-use token sent after registration. Refresh the access token afterwards to lift the unverified restriction.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailReq true "Verification token"
+// @Success 200 {object} utils.Response "Email verified"
+// @Failure 400 {object} utils.Response "Invalid, used or expired token"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var request VerifyEmailReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := utils.ConsumeUserToken(tx, request.Token, models.TokenEmailVerification)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		userTokenError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "email verified", nil)
+}
+
+// ResendVerification handles POST /auth/resend-verification
+// @Summary Resend verification email
+// @Description Send a new verification link. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body EmailReq true "Account email"
+// @Success 200 {object} utils.Response "Verification email sent if the account needs it"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Router /auth/resend-verification [post]
+func ResendVerification(c *gin.Context) {
+	var request EmailReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", request.Email).First(&user).Error; err == nil && !user.EmailVerified() {
+		if err := utils.SendVerificationEmail(user); err != nil && !errors.Is(err, utils.ErrUserTokenThrottle) {
+			log.Println("⚠️ Failed to send verification email:", err)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "if the account exists and is not verified, a verification email has been sent", nil)
+}
+
+// ForgotPassword handles POST /auth/password/forgot
+// @Summary Request password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body EmailReq true "Account email"
+// @Success 200 {object} utils.Response "Reset email sent if the account exists"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Router /auth/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var request EmailReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", request.Email).First(&user).Error; err == nil {
+		if err := utils.SendPasswordResetEmail(user); err != nil && !errors.Is(err, utils.ErrUserTokenThrottle) {
+			log.Println("⚠️ Failed to send password reset email:", err)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "if the account exists, a password reset email has been sent", nil)
+}
+
+// ResetPassword handles POST /auth/password/reset
+// @Summary Reset password
+// @Description Set a new password with a reset token. The token works once; all sessions are signed out. Resetting also verifies the email address.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordReq true "Reset token and new password"
+// @Success 200 {object} utils.Response "Password reset"
+// @Failure 400 {object} utils.Response "Invalid token or weak password"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /auth/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var request ResetPasswordReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := utils.LoadPasswordPolicy().Validate(request.NewPassword); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(request.NewPassword)
+	if err != nil {
+		utils.InternalServerError(c, "failed to reset password")
+		return
+	}
+
+	var userID uint
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := utils.ConsumeUserToken(tx, request.Token, models.TokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		// Nhận được email đặt lại mật khẩu cũng chứng minh quyền sở hữu email
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password":          hashedPassword,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+		}).Error
+	})
+	if err != nil {
+		userTokenError(c, err)
+		return
+	}
+
+	// Đăng xuất mọi phiên đang mở bằng mật khẩu cũ
+	if err := utils.RevokeUserSessions(config.DB, userID); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after password reset:", err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "password reset successfully", nil)
+}
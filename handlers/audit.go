@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLogs handles GET /admin/audit
+// @Summary List audit log
+// @Description Administrative changes with actor, target and before/after diff, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query int false "Actor user ID"
+// @Param action query string false "Action (user.update, user.delete, card.update, card.delete, station.update, train.delete)"
+// @Param target_type query string false "Target type (user, card, station, train)"
+// @Param target_id query string false "Target ID"
+// @Param from query string false "Only entries at or after this time (RFC3339)"
+// @Param to query string false "Only entries before this time (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.AuditLog} "Audit log retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filter"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/audit [get]
+func GetAuditLogs(c *gin.Context) {
+	query := config.DB.Model(&models.AuditLog{})
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := strconv.ParseUint(actorID, 10, 64)
+		if err != nil {
+			utils.BadRequest(c, "invalid actor_id")
+			return
+		}
+		query = query.Where("actor_id = ?", id)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.BadRequest(c, "from must be RFC3339")
+			return
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			utils.BadRequest(c, "to must be RFC3339")
+			return
+		}
+		query = query.Where("created_at < ?", toTime)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	entries := []models.AuditLog{}
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch audit log")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "audit log retrieved successfully", entries)
+}
+
+// VerifyAuditLog handles GET /admin/audit/verify
+// @Summary Verify audit log integrity
+// @Description Recompute the hash chain of the whole audit log and report the first entry that was modified, removed or reordered
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=utils.AuditChainReport} "Verification result"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/audit/verify [get]
+func VerifyAuditLog(c *gin.Context) {
+	report, err := utils.VerifyAuditChain()
+	if err != nil {
+		utils.InternalServerError(c, "failed to verify audit log")
+		return
+	}
+
+	message := "audit log is intact"
+	if !report.Valid {
+		message = "audit log has been tampered with"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, report)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseRetryMillis là thời gian chờ trước khi client tự kết nối lại
+const sseRetryMillis = 3000
+
+func writeSSEEvent(w io.Writer, id uint64, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
+
+// StreamStationBoard handles GET /station/:id/board/stream
+// @Summary Live departure board stream
+// @Description Server-Sent Events stream for a station screen. Sends a "departures" snapshot on connect and whenever departures or delays change, and a "heartbeat" event every 15 seconds. Clients reconnect automatically (retry: 3s) and always receive a fresh snapshot.
+// @Tags station
+// @Produce text/event-stream
+// @Param id path int true "Station ID"
+// @Success 200 {object} utils.BoardSnapshot "Event stream"
+// @Failure 404 {object} utils.Response "Station not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /station/{id}/board/stream [get]
+func StreamStationBoard(c *gin.Context) {
+	id := c.Param("id")
+	var station models.Station
+
+	if err := config.DB.First(&station, id).Error; err != nil {
+		utils.NotFound(c, "station not found")
+		return
+	}
+
+	snapshot, err := utils.BuildBoardSnapshot(station.ID)
+	if err != nil {
+		utils.InternalServerError(c, "failed to build departure board")
+		return
+	}
+
+	subscriber, unsubscribe := utils.Boards.Subscribe(station.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis)
+	if err := writeSSEEvent(c.Writer, utils.Boards.NextEventID(), "departures", snapshot); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(utils.BoardHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-subscriber.Events():
+			if !ok {
+				return false
+			}
+			return writeSSEEvent(w, event.ID, event.Event, event.Data) == nil
+		case now := <-heartbeat.C:
+			return writeSSEEvent(w, utils.Boards.NextEventID(), "heartbeat", gin.H{"time": now}) == nil
+		}
+	})
+}
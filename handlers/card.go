@@ -1,8 +1,10 @@
 package handlers
 
 import (
+  "errors"
   "fmt"
   "math/rand"
+  "net/http"
   "time"
 
   "go-metro/config"
@@ -11,19 +13,28 @@ import (
   "go-metro/utils"
 
   "github.com/gin-gonic/gin"
+  "gorm.io/gorm"
+  "gorm.io/gorm/clause"
 )
 
 // CardRequest struct for creating card (without rf_id as it's auto-generated)
 type CardReq struct {
-  UserID uint   `json:"user_id" binding:"required"`
-  Type   string `json:"type"  binding:"required"`
+  UserID    *uint      `json:"user_id"` // bỏ trống để bán thẻ vô danh, khách nhận thẻ sau bằng mã xác minh
+  Type      string     `json:"type"  binding:"required"`
+  StationID uint       `json:"station_id"` // trạm bán thẻ; bỏ trống nếu nhân viên chỉ được phân công một trạm
+  ExpiresAt *time.Time `json:"expires_at"` // hạn dùng (thẻ học sinh, vé tháng); bỏ trống nếu thẻ không hết hạn
+}
+
+// CardExpiryReq struct for changing the expiry date of a card
+type CardExpiryReq struct {
+  ExpiresAt *time.Time `json:"expires_at"` // null: thẻ không hết hạn
 }
 
 type UpdateCardReq struct {
-  UserID  uint    `json:"user_id"`
-  Balance float64 `json:"balance" gorm:"default:0"`
-  Status  string  `json:"status"`
-  Type    int
+  UserID  uint     `json:"user_id"`
+  Balance *float64 `json:"balance" binding:"omitempty,gte=0"` // bỏ trống nếu không đổi số dư
+  Status  string   `json:"status" binding:"omitempty,oneof=active inactive blocked expired"`
+  Type    int      `json:"type" binding:"omitempty,oneof=1 2 3"`
 }
 
 func generateCardID() string {
@@ -32,9 +43,10 @@ func generateCardID() string {
   return fmt.Sprintf("GM%010d", randomNumber)
 }
 
+// isCardIDUnique kiểm tra cả thẻ đã xóa mềm: RFID không bao giờ được cấp lại
 func isCardIDUnique(cardID string) bool {
   var count int64
-  config.DB.Model(&models.Card{}).Where("rf_id = ?", cardID).Count(&count)
+  config.DB.Unscoped().Model(&models.Card{}).Where("rf_id = ?", cardID).Count(&count)
   return count == 0
 }
 
@@ -49,13 +61,43 @@ func generateUniqueCardID() string {
   return cardID
 }
 
+// saleStation xác định trạm bán thẻ trong phạm vi của nhân viên (nil: admin bán ngoài trạm)
+func saleStation(c *gin.Context, requested uint) (*uint, bool) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "Lỗi tạo thẻ")
+    return nil, false
+  }
+  if requested != 0 {
+    if !scope.Allows(requested) {
+      utils.ErrorResponse(c, http.StatusForbidden, "Bạn không được phân công tại trạm này")
+      return nil, false
+    }
+    var station models.Station
+    if err := config.DB.First(&station, requested).Error; err != nil {
+      utils.NotFound(c, "Trạm không tồn tại")
+      return nil, false
+    }
+    return &station.ID, true
+  }
+  if !scope.All {
+    if len(scope.StationIDs) != 1 {
+      utils.BadRequest(c, "Vui lòng chọn station_id nơi bán thẻ")
+      return nil, false
+    }
+    return &scope.StationIDs[0], true
+  }
+  return nil, true
+}
+
 // OK
 // CreateCard handles POST /card
 // @Summary Create a new card
-// @Description Create a new metro card with auto-generated card ID and user ID
+// @Description Create a new metro card with auto-generated card ID. Omit user_id to sell an anonymous card; the response then includes a one-time verification_code to print on the card so the rider can claim it. The sale is recorded at station_id, which must be one of the staff member's assigned stations (admins may sell at any station).
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param card body CardReq true "Card information"
 // @Router /card [post]
 func CreateCard(c *gin.Context) {
@@ -66,24 +108,34 @@ func CreateCard(c *gin.Context) {
     return
   }
 
-  cardID := generateUniqueCardID()
-  var user models.User
-  if err := config.DB.First(&user, cardRequest.UserID).Error; err != nil {
-    utils.NotFound(c, "Người dùng không tồn tại")
+  stationID, ok := saleStation(c, cardRequest.StationID)
+  if !ok {
     return
   }
 
+  cardID := generateUniqueCardID()
   card := models.Card{
-    RFID:   cardID,
-    UserID: cardRequest.UserID,
+    RFID:      cardID,
+    UserID:    cardRequest.UserID,
+    Status:    consts.ActiveStatus,
+    ExpiresAt: cardRequest.ExpiresAt,
   }
 
-  // Search OwnerID trong database trước
-
-  if card.UserID != 0 {
-   card.Status = consts.ActiveStatus
+  if card.Registered() {
+    var user models.User
+    if err := config.DB.First(&user, *cardRequest.UserID).Error; err != nil {
+      utils.NotFound(c, "Người dùng không tồn tại")
+      return
+    }
   } else {
-   card.Status = consts.InactiveStatus
+    // Thẻ vô danh: mã xác minh in lên thẻ để khách nhận thẻ vào tài khoản sau
+    code, hash, err := utils.NewCardVerificationCode()
+    if err != nil {
+      utils.InternalServerError(c, "Lỗi tạo thẻ")
+      return
+    }
+    card.VerificationCode = code
+    card.VerificationCodeHash = hash
   }
 
   if cardRequest.Type == consts.StudentCard.ToText() {
@@ -100,25 +152,45 @@ func CreateCard(c *gin.Context) {
     card.Type = consts.VipCard
   }
 
-  // Bắt đầu transaction
-  tx := config.DB.Begin()
+  // Tạo SellHistory log với người bán là nhân viên đang đăng nhập
+  sellerID, _ := c.Get("user_id")
+  sellerUserID, _ := sellerID.(uint)
 
-  // Tạo card
-  if err := tx.Create(&card).Error; err != nil {
-    tx.Rollback()
-    utils.InternalServerError(c, "Lỗi tạo thẻ")
-    return
-  }
-
-  // Tạo SellHistory log
-  if err := utils.CreateSellHistoryLog(cardID, cardRequest.UserID, card.Price); err != nil {
-    tx.Rollback()
-    utils.InternalServerError(c, "Lỗi tạo lịch sử bán thẻ")
+  // Tạo card, sự kiện bán thẻ (outbox) và SellHistory trong cùng transaction;
+  // sự kiện chỉ được công bố khi commit thành công
+  message := "Lỗi tạo thẻ"
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    if err := tx.Create(&card).Error; err != nil {
+      return err
+    }
+    if err := utils.RecordEvent(tx, consts.DomainCardSold, card.RFID, map[string]interface{}{
+      "user_id":    card.UserID,
+      "type":       card.Type.ToText(),
+      "price":      card.Price,
+      "balance":    card.Balance,
+      "expires_at": card.ExpiresAt,
+      "station_id": stationID,
+      "seller_id":  sellerUserID,
+    }); err != nil {
+      return err
+    }
+    if err := utils.CreateSellHistoryLog(tx, cardID, sellerUserID, stationID, card.Price); err != nil {
+      message = "Lỗi tạo lịch sử bán thẻ"
+      return err
+    }
+    return nil
+  })
+  if err != nil {
+    utils.InternalServerError(c, message)
     return
   }
 
-  // Commit transaction
-  tx.Commit()
+  // Mã xác minh chỉ in lên thẻ, không ghi vào audit
+  audited := card
+  audited.VerificationCode = ""
+  utils.AuditTarget(c, card.RFID)
+  utils.AuditAfter(c, audited)
+  utils.AfterCommit(c, utils.Outbox.Wake)
 
   utils.SuccessResponse(c, 201, "Tạo thẻ thành công", card)
 }
@@ -130,6 +202,7 @@ func CreateCard(c *gin.Context) {
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Router /card [get]
 func GetCards(c *gin.Context) {
   var cards []models.Card
@@ -149,6 +222,7 @@ func GetCards(c *gin.Context) {
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Card ID"
 // @Success 200 {object} utils.Response{data=models.Card} "Card retrieved successfully"
 // @Failure 404 {object} utils.Response "Thẻ không tồn tại"
@@ -172,6 +246,7 @@ func GetCardByID(c *gin.Context) {
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param rf_id path string true "Card ID (physical card number)"
 // @Router /card/cardid/{rf_id} [get]
 func GetCardByCardID(c *gin.Context) {
@@ -186,57 +261,63 @@ func GetCardByCardID(c *gin.Context) {
   utils.SuccessResponse(c, 200, "", card)
 }
 
-// TODO
 // UpdateCard handles PUT /card/:rf_id
 // @Summary Update card
-// @Description Update a specific card's information
+// @Description Update a card's balance, status or type (the price follows the type). Omitted fields are left unchanged; blocking or expiring the card adds it to the gate hotlist.
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param rf_id path string true "Card ID"
 // @Param card body UpdateCardReq true "Updated card information"
 // @Router /card/{rf_id} [put]
 func UpdateCard(c *gin.Context) {
   rf_id := c.Param("rf_id")
-  var card models.Card
-
-  // Check if card exists
-  if err := config.DB.Where("rf_id = ?", rf_id).First(&card).Error; err != nil {
-    utils.NotFound(c, "Thẻ không tồn tại")
-    return
-  }
 
   // Bind update data
   var updateData UpdateCardReq
-  updateDataCard := models.Card{
-    //Username: updateData.OwnerID,
-    Balance: updateData.Balance,
-    Status:  consts.Status(updateData.Status),
-    Type:    consts.CardType(updateData.Type),
+  if err := c.ShouldBindJSON(&updateData); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
   }
 
-  switch updateDataCard.Type {
-  case consts.StudentCard:
-    updateDataCard.Price = consts.StudentCard.ToPrice()
-  case consts.NormalCard:
-    updateDataCard.Price = consts.NormalCard.ToPrice()
-  case consts.VipCard:
-    updateDataCard.Price = consts.VipCard.ToPrice()
-  }
+  var card models.Card
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    // Khóa thẻ như lượt quẹt và nạp tiền để không ghi đè số dư vừa thay đổi
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", rf_id).First(&card).Error; err != nil {
+      return err
+    }
+    utils.AuditBefore(c, card)
 
-  if err := c.ShouldBindJSON(&updateDataCard); err != nil {
-    utils.BadRequest(c, err.Error())
+    if updateData.Balance != nil {
+      card.Balance = *updateData.Balance
+    }
+    if updateData.Status != "" {
+      card.Status = consts.Status(updateData.Status)
+    }
+    if updateData.Type != 0 {
+      card.Type = consts.CardType(updateData.Type)
+      card.Price = card.Type.ToPrice()
+    }
+
+    // Update card
+    if err := tx.Model(&card).Select("balance", "status", "type", "price").Updates(&card).Error; err != nil {
+      return err
+    }
+    // Đổi trạng thái khóa/hết hạn thì cập nhật hotlist trong cùng transaction
+    return utils.SyncCardHotlist(tx, card)
+  })
+  if errors.Is(err, gorm.ErrRecordNotFound) {
+    utils.NotFound(c, "Thẻ không tồn tại")
     return
   }
-
-  // Update card
-  if err := config.DB.Model(&card).Updates(updateData).Error; err != nil {
+  if err != nil {
     utils.InternalServerError(c, "Lỗi cập nhật thẻ")
     return
   }
 
-  // Get updated card
-  config.DB.First(&card, rf_id)
+  utils.AuditAfter(c, card)
+  utils.AfterCommit(c, utils.Hotlists.Wake)
   utils.SuccessResponse(c, 200, "Cập nhật thành công", card)
 }
 
@@ -246,31 +327,347 @@ func UpdateCard(c *gin.Context) {
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Card ID"
 // @Router /card/{id} [delete]
 func DeleteCard(c *gin.Context) {
   id := c.Param("id")
+  db := utils.RequestDB(c)
   var card models.Card
 
-  if err := config.DB.First(&card, id).Error; err != nil {
+  if err := db.First(&card, id).Error; err != nil {
     utils.NotFound(c, "Thẻ không tồn tại")
     return
   }
 
-  if err := config.DB.Delete(&card).Error; err != nil {
+  utils.AuditBefore(c, card)
+
+  if err := db.Delete(&card).Error; err != nil {
     utils.InternalServerError(c, "failed to delete card")
     return
   }
 
+  // Thẻ đã xóa được gỡ khỏi hotlist ở lần đối chiếu kế tiếp
+  utils.AfterCommit(c, utils.Hotlists.Wake)
+
   utils.SuccessResponse(c, 200, "card deleted successfully", nil)
 }
 
+// setCardStatus đổi trạng thái thẻ và cập nhật hotlist trong cùng transaction
+func setCardStatus(c *gin.Context, card *models.Card, status consts.Status) error {
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    if err := tx.Model(card).Update("status", status).Error; err != nil {
+      return err
+    }
+    card.Status = status
+    if err := utils.SyncCardHotlist(tx, *card); err != nil {
+      return err
+    }
+    if status == consts.BlockedStatus {
+      utils.NotifyCardBlocked(tx, *card)
+    }
+    return nil
+  })
+  if err != nil {
+    return err
+  }
+
+  // Đẩy phiên bản hotlist mới xuống các cổng đang kết nối
+  utils.AfterCommit(c, func() {
+    utils.Hotlists.Wake()
+    utils.Notifications.Wake()
+  })
+  return nil
+}
+
+// SetCardExpiry handles PUT /card/:rf_id/expiry
+// @Summary Set card expiry date
+// @Description Set or clear the expiry date of a card (student cards, monthly passes). Holders are notified CARD_EXPIRY_NOTICE_DAYS before the date; an hourly job then expires the card and adds it to the gate hotlist.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Param request body CardExpiryReq true "Expiry date"
+// @Success 200 {object} utils.Response{data=models.Card} "Card expiry updated"
+// @Failure 400 {object} utils.Response "Invalid request"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/{rf_id}/expiry [put]
+func SetCardExpiry(c *gin.Context) {
+  var request CardExpiryReq
+  if err := c.ShouldBindJSON(&request); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
+  db := utils.RequestDB(c)
+  var card models.Card
+  if err := db.Where("rf_id = ?", c.Param("rf_id")).First(&card).Error; err != nil {
+    utils.NotFound(c, "Thẻ không tồn tại")
+    return
+  }
+
+  utils.AuditBefore(c, card)
+
+  card.ExpiresAt = request.ExpiresAt
+  if err := db.Model(&card).Select("expires_at").Updates(&card).Error; err != nil {
+    utils.InternalServerError(c, "failed to update card expiry")
+    return
+  }
+
+  utils.AuditAfter(c, card)
+  utils.SuccessResponse(c, http.StatusOK, "card expiry updated", card)
+}
+
+// BlockCard handles POST /card/:rf_id/block
+// @Summary Block card
+// @Description Block a card (lost, stolen, fraud). The card is added to the gate hotlist within seconds.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Success 200 {object} utils.Response{data=models.Card} "Card blocked"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/{rf_id}/block [post]
+func BlockCard(c *gin.Context) {
+  var card models.Card
+  if err := utils.RequestDB(c).Where("rf_id = ?", c.Param("rf_id")).First(&card).Error; err != nil {
+    utils.NotFound(c, "Thẻ không tồn tại")
+    return
+  }
+  utils.AuditBefore(c, card)
+
+  if err := setCardStatus(c, &card, consts.BlockedStatus); err != nil {
+    utils.InternalServerError(c, "failed to block card")
+    return
+  }
+  utils.AuditAfter(c, card)
+
+  utils.SuccessResponse(c, http.StatusOK, "card blocked", card)
+}
+
+// UnblockCard handles POST /card/:rf_id/unblock
+// @Summary Unblock card
+// @Description Reactivate a blocked card and remove it from the gate hotlist. Expired cards cannot be unblocked.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Success 200 {object} utils.Response{data=models.Card} "Card unblocked"
+// @Failure 400 {object} utils.Response "Card is not blocked"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/{rf_id}/unblock [post]
+func UnblockCard(c *gin.Context) {
+  var card models.Card
+  if err := utils.RequestDB(c).Where("rf_id = ?", c.Param("rf_id")).First(&card).Error; err != nil {
+    utils.NotFound(c, "Thẻ không tồn tại")
+    return
+  }
+
+  if card.Status != consts.BlockedStatus {
+    utils.BadRequest(c, "card is not blocked")
+    return
+  }
+  utils.AuditBefore(c, card)
+
+  if err := setCardStatus(c, &card, consts.ActiveStatus); err != nil {
+    utils.InternalServerError(c, "failed to unblock card")
+    return
+  }
+  utils.AuditAfter(c, card)
+
+  utils.SuccessResponse(c, http.StatusOK, "card unblocked", card)
+}
+
+// CardRefundRes struct for the result of refunding a card
+type CardRefundRes struct {
+  Card     models.Card `json:"card"`
+  Refunded float64     `json:"refunded"` // số dư đã hoàn cho khách
+}
+
+// ReplaceCardReq struct for issuing a replacement card
+type ReplaceCardReq struct {
+  StationID uint `json:"station_id"` // trạm cấp thẻ mới; bỏ trống nếu nhân viên chỉ được phân công một trạm
+}
+
+// RefundCard handles POST /card/:rf_id/refund
+// @Summary Refund card
+// @Description Refund the remaining balance of a registered card and retire it (status expired, added to the gate hotlist). Anonymous cards must be claimed first.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Success 200 {object} utils.Response{data=CardRefundRes} "Card refunded"
+// @Failure 400 {object} utils.Response "Card is anonymous, already retired or has no balance"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/{rf_id}/refund [post]
+func RefundCard(c *gin.Context) {
+  var res CardRefundRes
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    card, err := lockRetirableCard(tx, c.Param("rf_id"))
+    if err != nil {
+      return err
+    }
+    if card.Balance <= 0 {
+      return errCardNoBalance
+    }
+    utils.AuditBefore(c, card)
+
+    res.Refunded = card.Balance
+    card.Balance = 0
+    card.Status = consts.ExpiredStatus
+    if err := tx.Model(&card).Select("balance", "status").Updates(&card).Error; err != nil {
+      return err
+    }
+    if err := tx.Create(&models.History{
+      CardID:     card.RFID,
+      Time:       time.Now(),
+      UserID:     fmt.Sprint(*card.UserID),
+      Balance:    card.Balance,
+      CardAction: consts.CardActionRefund,
+    }).Error; err != nil {
+      return err
+    }
+    res.Card = card
+    return utils.SyncCardHotlist(tx, card)
+  })
+  if err != nil {
+    retireCardError(c, err, "failed to refund card")
+    return
+  }
+
+  utils.AuditAfter(c, res.Card)
+  utils.AfterCommit(c, utils.Hotlists.Wake)
+  utils.SuccessResponse(c, http.StatusOK, "card refunded", res)
+}
+
+// ReplaceCard handles POST /card/:rf_id/replace
+// @Summary Replace card
+// @Description Issue a new card to the owner of a registered card (lost or damaged). The balance moves to the new card and the old card is retired (status expired, added to the gate hotlist). Anonymous cards must be claimed first.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID of the card being replaced"
+// @Param request body ReplaceCardReq false "Station issuing the new card"
+// @Success 201 {object} utils.Response{data=models.Card} "Replacement card issued"
+// @Failure 400 {object} utils.Response "Card is anonymous or already retired"
+// @Failure 403 {object} utils.Response "Staff is not assigned to this station"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/{rf_id}/replace [post]
+func ReplaceCard(c *gin.Context) {
+  var request ReplaceCardReq
+  if c.Request.ContentLength > 0 {
+    if err := c.ShouldBindJSON(&request); err != nil {
+      utils.BadRequest(c, err.Error())
+      return
+    }
+  }
+
+  stationID, ok := saleStation(c, request.StationID)
+  if !ok {
+    return
+  }
+  sellerID, _ := c.Get("user_id")
+  sellerUserID, _ := sellerID.(uint)
+
+  var replacement models.Card
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    card, err := lockRetirableCard(tx, c.Param("rf_id"))
+    if err != nil {
+      return err
+    }
+    utils.AuditBefore(c, card)
+
+    replacement = models.Card{
+      RFID:      generateUniqueCardID(),
+      UserID:    card.UserID,
+      Balance:   card.Balance,
+      Status:    consts.ActiveStatus,
+      Price:     card.Price,
+      Type:      card.Type,
+      ExpiresAt: card.ExpiresAt, // thẻ thay thế giữ hạn dùng của thẻ cũ
+    }
+    if err := tx.Omit("User").Create(&replacement).Error; err != nil {
+      return err
+    }
+
+    card.Balance = 0
+    card.Status = consts.ExpiredStatus
+    if err := tx.Model(&card).Select("balance", "status").Updates(&card).Error; err != nil {
+      return err
+    }
+    if err := utils.SyncCardHotlist(tx, card); err != nil {
+      return err
+    }
+    utils.AuditAfter(c, card)
+
+    // Thẻ thay thế được ghi nhận là một lượt bán miễn phí tại trạm cấp thẻ
+    return tx.Omit("Card", "Seller", "Station").Create(&models.SellHistory{
+      CardID:    replacement.RFID,
+      SellerID:  sellerUserID,
+      StationID: stationID,
+      Time:      time.Now(),
+    }).Error
+  })
+  if err != nil {
+    retireCardError(c, err, "failed to replace card")
+    return
+  }
+
+  utils.AfterCommit(c, utils.Hotlists.Wake)
+  utils.SuccessResponse(c, http.StatusCreated, "replacement card issued", replacement)
+}
+
+var (
+  errCardRetired   = errors.New("card is already refunded or replaced")
+  errCardNoBalance = errors.New("card has no balance to refund")
+)
+
+// lockRetirableCard khóa thẻ để hoàn tiền hoặc cấp lại; chỉ thẻ đã đăng ký và chưa bị thu hồi
+func lockRetirableCard(tx *gorm.DB, rfID string) (models.Card, error) {
+  var card models.Card
+  if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", rfID).First(&card).Error; err != nil {
+    return card, err
+  }
+  if !card.Registered() {
+    return card, utils.ErrCardNotRegistered
+  }
+  if card.Status == consts.ExpiredStatus {
+    return card, errCardRetired
+  }
+  return card, nil
+}
+
+// retireCardError trả lỗi của thao tác hoàn tiền/cấp lại thẻ
+func retireCardError(c *gin.Context, err error, message string) {
+  switch {
+  case errors.Is(err, gorm.ErrRecordNotFound):
+    utils.NotFound(c, "Thẻ không tồn tại")
+  case errors.Is(err, utils.ErrCardNotRegistered):
+    utils.BadRequest(c, "anonymous cards must be claimed by a registered account before they can be refunded or replaced")
+  case errors.Is(err, errCardRetired), errors.Is(err, errCardNoBalance):
+    utils.BadRequest(c, err.Error())
+  default:
+    utils.InternalServerError(c, message)
+  }
+}
+
 // TopUpCard handles POST /card/:rf_id/topup
 // @Summary Top up card balance
 // @Description Add money to a card's balance
 // @Tags card
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param rf_id path string true "Card ID"
 // @Param request body object true "Top-up amount"
 // @Router /card/{rf_id}/topup [post]
@@ -278,12 +675,6 @@ func TopUpCard(c *gin.Context) {
   id := c.Param("rf_id")
   var card models.Card
 
-  // Check if card exists
-  if err := config.DB.Where("rf_id = ?", id).First(&card).Error; err != nil {
-    utils.NotFound(c, "Thẻ không tồn tại")
-    return
-  }
-
   // Parse amount from request
   var request struct {
     Amount float64 `json:"amount" binding:"required,gt=0"`
@@ -294,15 +685,38 @@ func TopUpCard(c *gin.Context) {
     return
   }
 
-  // Bắt đầu transaction
-  tx := config.DB.Begin()
+  // Khóa thẻ như lượt quẹt và chuyển số dư để không ghi đè số dư vừa thay đổi; sự kiện nạp tiền
+  // được công bố qua outbox trong cùng transaction nên chỉ được gửi khi commit thành công
+  err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", id).First(&card).Error; err != nil {
+      return err
+    }
+    utils.AuditBefore(c, card)
+
+    // Update balance
+    card.Balance += request.Amount
+    if err := tx.Model(&card).Update("balance", card.Balance).Error; err != nil {
+      return err
+    }
+
+    if err := utils.RecordEvent(tx, consts.DomainCardToppedUp, card.RFID, map[string]interface{}{
+      "amount":   request.Amount,
+      "balance":  card.Balance,
+      "source":   "counter",
+      "actor_id": currentUserID(c),
+    }); err != nil {
+      return err
+    }
 
-  // Update balance
-  oldBalance := card.Balance
-  newBalance := oldBalance + request.Amount
-  card.Balance = newBalance
-  if err := tx.Save(&card).Error; err != nil {
-    tx.Rollback()
+    // Xác nhận nạp tiền cho chủ thẻ
+    utils.NotifyTopUp(tx, card, request.Amount, time.Now())
+    return nil
+  })
+  if errors.Is(err, gorm.ErrRecordNotFound) {
+    utils.NotFound(c, "Thẻ không tồn tại")
+    return
+  }
+  if err != nil {
     utils.InternalServerError(c, "Nạp tiền thất bại")
     return
   }
@@ -314,22 +728,26 @@ func TopUpCard(c *gin.Context) {
   //  return
   //}
 
-  // Commit transaction
-  tx.Commit()
+  utils.AuditAfter(c, card)
+  utils.AfterCommit(c, func() {
+    utils.Notifications.Wake()
+    utils.Outbox.Wake()
+  })
 
   utils.SuccessResponse(c, 200, "Nạp tiền thành công", card)
 }
 
-// GetCardsByUser handles GET /card/user/:user_id
+// GetCardsByUser handles GET /card/user/:owner_id
 // @Summary Get cards by user ID
-// @Description Retrieve all cards belonging to a specific user
+// @Description Retrieve all cards belonging to a specific user (staff only; riders use /me/cards)
 // @Tags card
 // @Accept json
 // @Produce json
-// @Param user_id path int true "User ID"
-// @Router /card/user/{user_id} [get]
+// @Security BearerAuth
+// @Param owner_id path int true "User ID"
+// @Router /card/user/{owner_id} [get]
 func GetCardsByUser(c *gin.Context) {
-  userID := c.Param("user_id")
+  userID := c.Param("owner_id")
   var cards []models.Card
 
   if err := config.DB.Where("user_id = ?", userID).Find(&cards).Error; err != nil {
@@ -342,11 +760,12 @@ func GetCardsByUser(c *gin.Context) {
 
 // GetCardsByStatus handles GET /card/status/:status
 // @Summary Get cards by status
-// @Description Retrieve all cards with a specific status (active, inactive, blocked)
+// @Description Retrieve all cards with a specific status (active, inactive, blocked, expired)
 // @Tags card
 // @Accept json
 // @Produce json
-// @Param status path string true "Card status" Enums(active, inactive, blocked)
+// @Security BearerAuth
+// @Param status path string true "Card status" Enums(active, inactive, blocked, expired)
 // @Router /card/status/{status} [get]
 func GetCardsByStatus(c *gin.Context) {
   status := c.Param("status")
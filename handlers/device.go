@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceReq struct for registering and updating a station device
+type DeviceReq struct {
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type" binding:"required,oneof=gate ticket_machine"`
+	StationID uint   `json:"station_id" binding:"required"`
+	IPAddress string `json:"ip_address" binding:"omitempty,ip"`
+	Status    string `json:"status" binding:"omitempty,oneof=active inactive blocked"`
+}
+
+// DeviceCredentialsRes trả về thông tin xác thực; secret chỉ hiển thị một lần
+type DeviceCredentialsRes struct {
+	Device models.Device `json:"device"`
+	KeyID  string        `json:"key_id"`
+	Secret string        `json:"secret"`
+}
+
+func buildDevice(request DeviceReq, device *models.Device) error {
+	var station models.Station
+	if err := config.DB.First(&station, request.StationID).Error; err != nil {
+		return err
+	}
+
+	device.Name = request.Name
+	device.Type = consts.DeviceType(request.Type)
+	device.StationID = station.ID
+	device.Station = station
+	device.IPAddress = request.IPAddress
+	if request.Status != "" {
+		device.Status = consts.Status(request.Status)
+	} else if device.Status == "" {
+		device.Status = consts.ActiveStatus
+	}
+	return nil
+}
+
+// CreateDevice handles POST /device
+// @Summary Register a station device
+// @Description Register a gate or ticket machine at a station. The returned secret is shown only once.
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param device body DeviceReq true "Device information"
+// @Success 201 {object} utils.Response{data=DeviceCredentialsRes} "Device registered successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device [post]
+func CreateDevice(c *gin.Context) {
+	var request DeviceReq
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var device models.Device
+	if err := buildDevice(request, &device); err != nil {
+		utils.BadRequest(c, "station not found")
+		return
+	}
+
+	keyID, secret, err := utils.NewDeviceCredentials()
+	if err != nil {
+		utils.InternalServerError(c, "failed to generate device credentials")
+		return
+	}
+	device.KeyID = keyID
+	device.Secret = secret
+
+	if err := utils.RequestDB(c).Omit("Station").Create(&device).Error; err != nil {
+		utils.InternalServerError(c, "failed to register device")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(device.ID), 10))
+	utils.AuditAfter(c, device)
+
+	utils.SuccessResponse(c, http.StatusCreated, "device registered successfully", DeviceCredentialsRes{
+		Device: device,
+		KeyID:  keyID,
+		Secret: secret,
+	})
+}
+
+// GetDevices handles GET /device
+// @Summary Get registered devices
+// @Description Retrieve registered devices with optional station, type and status filters
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param station_id query int false "Filter by station ID"
+// @Param type query string false "Filter by device type" Enums(gate, ticket_machine)
+// @Param status query string false "Filter by status" Enums(active, inactive, blocked)
+// @Success 200 {object} utils.Response{data=[]models.Device} "Devices retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device [get]
+func GetDevices(c *gin.Context) {
+	devices := []models.Device{}
+	query := config.DB.Preload("Station")
+
+	if stationID := c.Query("station_id"); stationID != "" {
+		query = query.Where("station_id = ?", stationID)
+	}
+	if deviceType := c.Query("type"); deviceType != "" {
+		query = query.Where("type = ?", deviceType)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("station_id ASC, id ASC").Find(&devices).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch devices")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "devices retrieved successfully", devices)
+}
+
+// GetDeviceByID handles GET /device/:id
+// @Summary Get device by ID
+// @Description Retrieve a specific registered device by its ID
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Success 200 {object} utils.Response{data=models.Device} "Device retrieved successfully"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Router /device/{id} [get]
+func GetDeviceByID(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	if err := config.DB.Preload("Station").First(&device, id).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "device retrieved successfully", device)
+}
+
+// UpdateDevice handles PUT /device/:id
+// @Summary Update device
+// @Description Update a registered device, e.g. move it to another station or deactivate it
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Param device body DeviceReq true "Updated device information"
+// @Success 200 {object} utils.Response{data=models.Device} "Device updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/{id} [put]
+func UpdateDevice(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var device models.Device
+
+	if err := db.Preload("Station").First(&device, id).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	var request DeviceReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.AuditBefore(c, device)
+	if err := buildDevice(request, &device); err != nil {
+		utils.BadRequest(c, "station not found")
+		return
+	}
+
+	if err := db.Omit("Station").Save(&device).Error; err != nil {
+		utils.InternalServerError(c, "failed to update device")
+		return
+	}
+	utils.AuditAfter(c, device)
+
+	utils.SuccessResponse(c, http.StatusOK, "device updated successfully", device)
+}
+
+// RotateDeviceKey handles POST /device/:id/rotate-key
+// @Summary Rotate device secret
+// @Description Issue new credentials for a device; the old secret stops working immediately
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Success 200 {object} utils.Response{data=DeviceCredentialsRes} "Device key rotated successfully"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/{id}/rotate-key [post]
+func RotateDeviceKey(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var device models.Device
+
+	if err := db.Preload("Station").First(&device, id).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	utils.AuditBefore(c, device)
+
+	keyID, secret, err := utils.NewDeviceCredentials()
+	if err != nil {
+		utils.InternalServerError(c, "failed to generate device credentials")
+		return
+	}
+
+	if err := db.Model(&device).Updates(map[string]interface{}{
+		"key_id": keyID,
+		"secret": secret,
+	}).Error; err != nil {
+		utils.InternalServerError(c, "failed to rotate device key")
+		return
+	}
+	device.KeyID = keyID
+	utils.AuditAfter(c, device)
+
+	utils.SuccessResponse(c, http.StatusOK, "device key rotated successfully", DeviceCredentialsRes{
+		Device: device,
+		KeyID:  keyID,
+		Secret: secret,
+	})
+}
+
+// DeleteDevice handles DELETE /device/:id
+// @Summary Delete device
+// @Description Remove a device from the registry
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Success 200 {object} utils.Response "Device deleted successfully"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/{id} [delete]
+func DeleteDevice(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var device models.Device
+
+	if err := db.First(&device, id).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	utils.AuditBefore(c, device)
+
+	if err := db.Delete(&device).Error; err != nil {
+		utils.InternalServerError(c, "failed to delete device")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "device deleted successfully", nil)
+}
+
+// GetDeviceTaps handles GET /device/:id/taps
+// @Summary Get offline taps uploaded by a device
+// @Description Retrieve offline taps a gate uploaded and how each was resolved, newest sequence first
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Param result query string false "Filter by result" Enums(accepted, accepted_with_debt, rejected)
+// @Param card_id query string false "Filter by card RFID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.DeviceTap} "Device taps retrieved successfully"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/{id}/taps [get]
+func GetDeviceTaps(c *gin.Context) {
+	var device models.Device
+	if err := config.DB.First(&device, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	query := config.DB.Where("device_id = ?", device.ID)
+	if result := c.Query("result"); result != "" {
+		query = query.Where("result = ?", result)
+	}
+	if cardID := c.Query("card_id"); cardID != "" {
+		query = query.Where("card_id = ?", cardID)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	taps := []models.DeviceTap{}
+	if err := query.Order("sequence DESC").Offset(offset).Limit(limit).Find(&taps).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch device taps")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "device taps retrieved successfully", taps)
+}
+
+// HeartbeatReq là tình trạng thiết bị gửi định kỳ
+type HeartbeatReq struct {
+	FirmwareVersion string           `json:"firmware_version" binding:"max=64"`
+	DeviceTime      time.Time        `json:"device_time" binding:"required"`
+	QueueSize       int              `json:"queue_size" binding:"min=0"`
+	Errors          map[string]int64 `json:"errors"`
+	UptimeSeconds   int64            `json:"uptime_seconds" binding:"min=0"`
+}
+
+// HeartbeatRes trả về thời gian máy chủ để thiết bị tự chỉnh đồng hồ
+type HeartbeatRes struct {
+	DeviceID     uint      `json:"device_id"`
+	ServerTime   time.Time `json:"server_time"`
+	ClockDriftMs int64     `json:"clock_drift_ms"`
+}
+
+// PostHeartbeat handles POST /device/heartbeat
+// @Summary Device heartbeat
+// @Description Report firmware version, clock, offline queue size and error counters. Devices that stay silent longer than DEVICE_OFFLINE_TIMEOUT_SECONDS are marked offline.
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security DeviceID && DeviceKey
+// @Param heartbeat body HeartbeatReq true "Device status"
+// @Success 200 {object} utils.Response{data=HeartbeatRes} "Heartbeat recorded"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/heartbeat [post]
+func PostHeartbeat(c *gin.Context) {
+	value, _ := c.Get("device")
+	device, ok := value.(models.Device)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "device authentication required")
+		return
+	}
+
+	var request HeartbeatReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	record, err := utils.RecordHeartbeat(&device, utils.Heartbeat{
+		FirmwareVersion: request.FirmwareVersion,
+		DeviceTime:      request.DeviceTime,
+		QueueSize:       request.QueueSize,
+		Errors:          request.Errors,
+		UptimeSeconds:   request.UptimeSeconds,
+	})
+	if err != nil {
+		utils.InternalServerError(c, "failed to record heartbeat")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "heartbeat recorded", HeartbeatRes{
+		DeviceID:     device.ID,
+		ServerTime:   record.ReceivedAt,
+		ClockDriftMs: record.ClockDriftMs,
+	})
+}
+
+// GetFleetStatus handles GET /device/fleet
+// @Summary Fleet health dashboard
+// @Description Connectivity of all active devices, per-station summary and stations whose gates have all gone silent
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param station_id query int false "Limit to one station"
+// @Param connectivity query string false "Only list devices with this connectivity" Enums(online, offline, unknown)
+// @Success 200 {object} utils.Response{data=utils.FleetDashboard} "Fleet status retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/fleet [get]
+func GetFleetStatus(c *gin.Context) {
+	stationID, _ := strconv.ParseUint(c.Query("station_id"), 10, 64)
+
+	dashboard, err := utils.BuildFleetDashboard(uint(stationID))
+	if err != nil {
+		utils.InternalServerError(c, "failed to build fleet status")
+		return
+	}
+
+	if connectivity := c.Query("connectivity"); connectivity != "" {
+		devices := []models.Device{}
+		for _, device := range dashboard.Devices {
+			if string(device.Connectivity) == connectivity {
+				devices = append(devices, device)
+			}
+		}
+		dashboard.Devices = devices
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "fleet status retrieved successfully", dashboard)
+}
+
+// GetDeviceHeartbeats handles GET /device/:id/heartbeats
+// @Summary Get device heartbeats
+// @Description Retrieve recent heartbeats of a device, newest first
+// @Tags device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Param from query string false "Only heartbeats received at or after this time (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.DeviceHeartbeat} "Device heartbeats retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid time"
+// @Failure 404 {object} utils.Response "Device not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /device/{id}/heartbeats [get]
+func GetDeviceHeartbeats(c *gin.Context) {
+	var device models.Device
+	if err := config.DB.First(&device, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "device not found")
+		return
+	}
+
+	query := config.DB.Where("device_id = ?", device.ID)
+	if from := c.Query("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.BadRequest(c, "from must be RFC3339")
+			return
+		}
+		query = query.Where("received_at >= ?", fromTime)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	heartbeats := []models.DeviceHeartbeat{}
+	if err := query.Order("received_at DESC").Offset(offset).Limit(limit).Find(&heartbeats).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch device heartbeats")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "device heartbeats retrieved successfully", heartbeats)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OfflineTapReq là một lượt quẹt cổng lưu khi mất kết nối; Sequence tăng dần theo thiết bị, bắt đầu từ 1
+type OfflineTapReq struct {
+	Sequence uint64    `json:"sequence" binding:"required"`
+	Action   string    `json:"action" binding:"required,oneof=checkin checkout"`
+	CardID   string    `json:"card_id" binding:"required"`
+	TappedAt time.Time `json:"tapped_at" binding:"required"`
+}
+
+// OfflineTapBatchReq struct for uploading taps stored offline by a gate (tối đa 500 lượt mỗi lần)
+type OfflineTapBatchReq struct {
+	Taps []OfflineTapReq `json:"taps" binding:"required,min=1,max=500,dive"`
+}
+
+// OfflineTapResult là kết quả xử lý từng lượt quẹt.
+// Status: accepted, accepted_with_debt, rejected, duplicate hoặc error (thiết bị nên gửi lại).
+type OfflineTapResult struct {
+	Sequence         uint64  `json:"sequence"`
+	Status           string  `json:"status"`
+	Result           string  `json:"result,omitempty"` // kết quả đã lưu khi status = duplicate
+	Reason           string  `json:"reason,omitempty"`
+	StationHistoryID *uint   `json:"station_history_id,omitempty"`
+	Balance          float64 `json:"balance"`
+}
+
+// OfflineTapBatchRes tổng hợp kết quả của một lần gửi bù
+type OfflineTapBatchRes struct {
+	DeviceID  uint               `json:"device_id"`
+	StationID uint               `json:"station_id"`
+	Accepted  int                `json:"accepted"`
+	Rejected  int                `json:"rejected"`
+	Duplicate int                `json:"duplicate"`
+	Failed    int                `json:"failed"`
+	Results   []OfflineTapResult `json:"results"`
+}
+
+// UploadOfflineTaps handles POST /station/:id/gate/batch
+// @Summary Upload offline gate taps
+// @Description Replay taps a gate stored while offline, in device sequence order. Taps already uploaded are reported as duplicate with their stored result. Insufficient balance and blocked cards are resolved by the offline policy (OFFLINE_BALANCE_POLICY, OFFLINE_BLOCKED_CARD_POLICY).
+// @Tags station
+// @Accept json
+// @Produce json
+// @Security DeviceID && DeviceKey
+// @Param id path int true "Station ID"
+// @Param request body OfflineTapBatchReq true "Offline taps"
+// @Success 200 {object} utils.Response{data=OfflineTapBatchRes} "Offline taps processed"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 403 {object} utils.Response "Device inactive or not a gate of this station"
+// @Failure 404 {object} utils.Response "Station not found"
+// @Router /station/{id}/gate/batch [post]
+func UploadOfflineTaps(c *gin.Context) {
+	var request OfflineTapBatchReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var station models.Station
+	if err := config.DB.First(&station, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "station not found")
+		return
+	}
+
+	device, ok := gateDevice(c, station.ID)
+	if !ok {
+		return
+	}
+
+	// Phát lại theo đúng thứ tự thiết bị đã ghi nhận
+	taps := request.Taps
+	sort.SliceStable(taps, func(i, j int) bool { return taps[i].Sequence < taps[j].Sequence })
+
+	policy := utils.OfflineTapPolicy()
+	response := OfflineTapBatchRes{
+		DeviceID:  device.ID,
+		StationID: station.ID,
+		Results:   make([]OfflineTapResult, 0, len(taps)),
+	}
+
+	for _, tap := range taps {
+		record, duplicate, err := utils.ReplayOfflineTap(device, utils.OfflineTap{
+			Sequence: tap.Sequence,
+			Action:   tap.Action,
+			CardID:   tap.CardID,
+			TappedAt: tap.TappedAt,
+		}, policy)
+
+		result := OfflineTapResult{Sequence: tap.Sequence}
+		switch {
+		case err != nil:
+			log.Printf("⚠️ Failed to replay offline tap %d of device %d: %v", tap.Sequence, device.ID, err)
+			result.Status = "error"
+			result.Reason = "failed to process tap"
+			response.Failed++
+		case duplicate:
+			result.Status = "duplicate"
+			result.Result = record.Result
+			result.Reason = record.Reason
+			result.StationHistoryID = record.StationHistoryID
+			result.Balance = record.Balance
+			response.Duplicate++
+		default:
+			result.Status = record.Result
+			result.Reason = record.Reason
+			result.StationHistoryID = record.StationHistoryID
+			result.Balance = record.Balance
+			if record.Result == models.TapRejected {
+				response.Rejected++
+			} else {
+				response.Accepted++
+			}
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "offline taps processed", response)
+}
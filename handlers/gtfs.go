@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"go-metro/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGTFSUploadSize giới hạn kích thước file GTFS được upload (50 MB)
+const maxGTFSUploadSize = 50 << 20
+
+// ExportGTFS handles GET /gtfs/export
+// @Summary Export GTFS static feed
+// @Description Download stations, lines and service patterns as a GTFS static zip (agency, stops, routes, trips, stop_times, calendar, calendar_dates)
+// @Tags gtfs
+// @Produce application/zip
+// @Success 200 {file} file "GTFS zip archive"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /gtfs/export [get]
+func ExportGTFS(c *gin.Context) {
+	var buffer bytes.Buffer
+
+	if err := utils.WriteGTFSFeed(&buffer); err != nil {
+		utils.InternalServerError(c, "failed to export GTFS feed")
+		return
+	}
+
+	filename := "gometro-gtfs-" + time.Now().Format("20060102") + ".zip"
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/zip", buffer.Bytes())
+}
+
+// ImportGTFS handles POST /gtfs/import
+// @Summary Import GTFS static feed
+// @Description Validate and upsert stations, lines and service patterns from a GTFS static zip in one transaction. Invalid rows are skipped and listed in the report.
+// @Tags gtfs
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "GTFS zip archive"
+// @Param default_train_id formData int false "Train used for trips whose block_id is not a known train ID"
+// @Param dry_run formData bool false "Validate only, roll back all changes"
+// @Success 200 {object} utils.Response{data=utils.GTFSImportReport} "GTFS feed imported"
+// @Failure 400 {object} utils.Response "Bad request - invalid feed"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /gtfs/import [post]
+func ImportGTFS(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequest(c, "file is required")
+		return
+	}
+	if fileHeader.Size > maxGTFSUploadSize {
+		utils.BadRequest(c, "file is too large")
+		return
+	}
+
+	var defaultTrainID uint64
+	if value := c.PostForm("default_train_id"); value != "" {
+		if defaultTrainID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			utils.BadRequest(c, "invalid default_train_id")
+			return
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.BadRequest(c, "cannot read file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.BadRequest(c, "cannot read file")
+		return
+	}
+
+	report, err := utils.ImportGTFSFeed(utils.RequestDB(c), data, uint(defaultTrainID), dryRun)
+	if errors.Is(err, utils.ErrGTFSInvalidFeed) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalServerError(c, "failed to import GTFS feed")
+		return
+	}
+
+	utils.AuditAfter(c, report)
+	if !dryRun {
+		utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	}
+
+	message := "GTFS feed imported"
+	if dryRun {
+		message = "GTFS feed validated (dry run, no changes saved)"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, report)
+}
@@ -14,6 +14,7 @@ import (
 // @Tags history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {object} utils.Response{data=[]models.History} "Histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /history [get]
@@ -34,6 +35,7 @@ func GetHistories(c *gin.Context) {
 // @Tags history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "History ID"
 // @Success 200 {object} utils.Response{data=models.History} "History retrieved successfully"
 // @Failure 404 {object} utils.Response "History not found"
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"go-metro/config"
+	"go-metro/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHotlist handles GET /hotlist
+// @Summary Download card hotlist
+// @Description Signed list of blocked and expired cards for gates. Pass since=<version> to receive only the changes after that version; omit it for the full list. The payload is base64 JSON signed with Ed25519 (see GET /hotlist/key).
+// @Tags hotlist
+// @Produce json
+// @Security DeviceID && DeviceKey
+// @Param since query int false "Version the device already holds"
+// @Success 200 {object} utils.Response{data=utils.SignedHotlist} "Hotlist retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid version"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /hotlist [get]
+func GetHotlist(c *gin.Context) {
+	var since uint64
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.BadRequest(c, "since must be a version number")
+			return
+		}
+		since = parsed
+	}
+
+	hotlist, err := utils.BuildHotlist(since)
+	if err != nil {
+		utils.InternalServerError(c, "failed to build hotlist")
+		return
+	}
+
+	signed, err := utils.SignHotlist(hotlist)
+	if err != nil {
+		utils.InternalServerError(c, "failed to sign hotlist")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "hotlist retrieved successfully", signed)
+}
+
+// GetHotlistKey handles GET /hotlist/key
+// @Summary Hotlist signing key
+// @Description Public key devices use to verify hotlist signatures
+// @Tags hotlist
+// @Produce json
+// @Success 200 {object} utils.Response{data=utils.HotlistKey} "Hotlist key retrieved successfully"
+// @Router /hotlist/key [get]
+func GetHotlistKey(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "hotlist key retrieved successfully", utils.GetHotlistKey())
+}
+
+// StreamHotlist handles GET /hotlist/stream
+// @Summary Hotlist change stream
+// @Description Server-Sent Events stream for gates. Sends a "version" event with the current hotlist version on connect and whenever a card is added or removed, and a "heartbeat" event every 15 seconds. Devices download the delta with GET /hotlist?since=<version>.
+// @Tags hotlist
+// @Produce text/event-stream
+// @Security DeviceID && DeviceKey
+// @Success 200 {object} object "Event stream"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /hotlist/stream [get]
+func StreamHotlist(c *gin.Context) {
+	versions, unsubscribe := utils.Hotlists.Subscribe()
+	defer unsubscribe()
+
+	version, err := utils.CurrentHotlistVersion(config.DB)
+	if err != nil {
+		utils.InternalServerError(c, "failed to read hotlist version")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis)
+	if err := writeSSEEvent(c.Writer, version, "version", gin.H{"version": version}); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(utils.BoardHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case version = <-versions:
+			return writeSSEEvent(w, version, "version", gin.H{"version": version}) == nil
+		case now := <-heartbeat.C:
+			return writeSSEEvent(w, version, "heartbeat", gin.H{"time": now}) == nil
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LineReq struct for creating and updating a line
+type LineReq struct {
+	Code        string `json:"code" binding:"required,max=20"`
+	Name        string `json:"name" binding:"required"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// CreateLine handles POST /line
+// @Summary Create a new metro line
+// @Description Create a new metro line (route)
+// @Tags line
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param line body LineReq true "Line information"
+// @Success 201 {object} utils.Response{data=models.Line} "Line created successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /line [post]
+func CreateLine(c *gin.Context) {
+	var request LineReq
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	line := models.Line{
+		Code:        request.Code,
+		Name:        request.Name,
+		Color:       request.Color,
+		Description: request.Description,
+	}
+
+	if err := utils.RequestDB(c).Create(&line).Error; err != nil {
+		utils.InternalServerError(c, "failed to create line")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(line.ID), 10))
+	utils.AuditAfter(c, line)
+
+	utils.SuccessResponse(c, http.StatusCreated, "line created successfully", line)
+}
+
+// GetLines handles GET /line
+// @Summary Get all metro lines
+// @Description Retrieve all metro lines
+// @Tags line
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.Line} "Lines retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /line [get]
+func GetLines(c *gin.Context) {
+	var lines []models.Line
+
+	if err := config.DB.Order("code ASC").Find(&lines).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch lines")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "lines retrieved successfully", lines)
+}
+
+// GetLineByID handles GET /line/:id
+// @Summary Get line by ID
+// @Description Retrieve a specific metro line by its ID
+// @Tags line
+// @Accept json
+// @Produce json
+// @Param id path int true "Line ID"
+// @Success 200 {object} utils.Response{data=models.Line} "Line retrieved successfully"
+// @Failure 404 {object} utils.Response "Line not found"
+// @Router /line/{id} [get]
+func GetLineByID(c *gin.Context) {
+	id := c.Param("id")
+	var line models.Line
+
+	if err := config.DB.First(&line, id).Error; err != nil {
+		utils.NotFound(c, "line not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "line retrieved successfully", line)
+}
+
+// UpdateLine handles PUT /line/:id
+// @Summary Update line
+// @Description Update an existing metro line
+// @Tags line
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Line ID"
+// @Param line body LineReq true "Updated line information"
+// @Success 200 {object} utils.Response{data=models.Line} "Line updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Line not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /line/{id} [put]
+func UpdateLine(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var line models.Line
+
+	// Check if record exists
+	if err := db.First(&line, id).Error; err != nil {
+		utils.NotFound(c, "line not found")
+		return
+	}
+
+	utils.AuditBefore(c, line)
+
+	var request LineReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	line.Code = request.Code
+	line.Name = request.Name
+	line.Color = request.Color
+	line.Description = request.Description
+
+	if err := db.Save(&line).Error; err != nil {
+		utils.InternalServerError(c, "failed to update line")
+		return
+	}
+	utils.AuditAfter(c, line)
+
+	utils.SuccessResponse(c, http.StatusOK, "line updated successfully", line)
+}
+
+// DeleteLine handles DELETE /line/:id
+// @Summary Delete line
+// @Description Delete a metro line together with its service patterns
+// @Tags line
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Line ID"
+// @Success 200 {object} utils.Response "Line deleted successfully"
+// @Failure 404 {object} utils.Response "Line not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /line/{id} [delete]
+func DeleteLine(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var line models.Line
+
+	// Check if record exists
+	if err := db.First(&line, id).Error; err != nil {
+		utils.NotFound(c, "line not found")
+		return
+	}
+
+	utils.AuditBefore(c, line)
+
+	if err := db.Delete(&line).Error; err != nil {
+		utils.InternalServerError(c, "failed to delete line")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "line deleted successfully", nil)
+}
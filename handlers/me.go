@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ClaimCardReq struct for claiming an anonymous card
+type ClaimCardReq struct {
+	RFID             string `json:"rf_id" binding:"required"`
+	VerificationCode string `json:"verification_code" binding:"required"` // mã in trên thẻ, dạng xxxxx-xxxxx
+}
+
+// currentUserID là ID của người dùng trong JWT
+func currentUserID(c *gin.Context) uint {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uint)
+	return id
+}
+
+// myCard tìm thẻ thuộc về người dùng hiện tại; thẻ của người khác được báo là không tồn tại
+func myCard(c *gin.Context) (models.Card, bool) {
+	var card models.Card
+	if err := config.DB.Where("rf_id = ? AND user_id = ?", c.Param("rf_id"), currentUserID(c)).First(&card).Error; err != nil {
+		utils.NotFound(c, "Thẻ không tồn tại")
+		return card, false
+	}
+	return card, true
+}
+
+// filterTapTime áp dụng bộ lọc from/to (RFC3339) lên thời gian quẹt thẻ
+func filterTapTime(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	if from := c.Query("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.BadRequest(c, "from must be RFC3339")
+			return query, false
+		}
+		query = query.Where("time >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			utils.BadRequest(c, "to must be RFC3339")
+			return query, false
+		}
+		query = query.Where("time < ?", toTime)
+	}
+	return query, true
+}
+
+// GetMyCards handles GET /me/cards
+// @Summary List my cards
+// @Description Cards owned by the authenticated user
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Card} "Cards retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/cards [get]
+func GetMyCards(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	cards := []models.Card{}
+	if err := config.DB.Where("user_id = ?", currentUserID(c)).
+		Order("created_at DESC").Offset(offset).Limit(limit).Find(&cards).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch cards")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "cards retrieved successfully", cards)
+}
+
+// GetMyCardHistory handles GET /me/cards/:rf_id/history
+// @Summary List taps of my card
+// @Description Check-ins and check-outs of a card owned by the authenticated user, newest first
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Param from query string false "Only taps at or after this time (RFC3339)"
+// @Param to query string false "Only taps before this time (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.StationHistory} "Card history retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filter"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/cards/{rf_id}/history [get]
+func GetMyCardHistory(c *gin.Context) {
+	card, ok := myCard(c)
+	if !ok {
+		return
+	}
+
+	query, ok := filterTapTime(c, config.DB.Where("card_id = ?", card.RFID))
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	histories := []models.StationHistory{}
+	if err := query.Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).
+		Order("time DESC").Offset(offset).Limit(limit).Find(&histories).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch card history")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "card history retrieved successfully", histories)
+}
+
+// GetMyJourneys handles GET /me/journeys
+// @Summary List my journeys
+// @Description Journeys of the authenticated user's cards, pairing each check-in with the following check-out, newest first
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param card_id query string false "Only journeys of this card"
+// @Param from query string false "Only taps at or after this time (RFC3339)"
+// @Param to query string false "Only taps before this time (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]utils.Journey} "Journeys retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filter"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/journeys [get]
+func GetMyJourneys(c *gin.Context) {
+	myCards := config.DB.Model(&models.Card{}).Select("rf_id").Where("user_id = ?", currentUserID(c))
+	if cardID := c.Query("card_id"); cardID != "" {
+		myCards = myCards.Where("rf_id = ?", cardID)
+	}
+
+	query, ok := filterTapTime(c, config.DB.Where("card_id IN (?)", myCards))
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	journeys, err := utils.PageJourneys(query, offset, limit)
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch journeys")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "journeys retrieved successfully", journeys)
+}
+
+// BlockMyCard handles POST /me/cards/:rf_id/block
+// @Summary Block my card
+// @Description Block a lost or stolen card owned by the authenticated user. The card is added to the gate hotlist within seconds; only staff can unblock it.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Success 200 {object} utils.Response{data=models.Card} "Card blocked"
+// @Failure 400 {object} utils.Response "Card is already blocked or expired"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/cards/{rf_id}/block [post]
+func BlockMyCard(c *gin.Context) {
+	card, ok := myCard(c)
+	if !ok {
+		return
+	}
+
+	if card.Status.IsHotlisted() {
+		utils.BadRequest(c, "card is already blocked or expired")
+		return
+	}
+
+	if err := setCardStatus(c, &card, consts.BlockedStatus); err != nil {
+		utils.InternalServerError(c, "failed to block card")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "card blocked", card)
+}
+
+// ClaimMyCard handles POST /me/cards/claim
+// @Summary Claim an anonymous card
+// @Description Link an anonymous card bought at a kiosk to the authenticated user with its RFID and the verification code printed on the card. Repeated wrong codes are throttled like logins.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ClaimCardReq true "Card ID and verification code"
+// @Success 200 {object} utils.Response{data=models.Card} "Card claimed"
+// @Failure 400 {object} utils.Response "Invalid card or verification code"
+// @Failure 429 {object} utils.Response "Too many failed attempts"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/cards/claim [post]
+func ClaimMyCard(c *gin.Context) {
+	var request ClaimCardReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	card, err := utils.ClaimCard(currentUserID(c), strings.TrimSpace(request.RFID), request.VerificationCode, clientInfo(c))
+	var throttled *utils.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(throttled.RetryAfter()))
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "too many failed claim attempts, try again later")
+		return
+	case errors.Is(err, utils.ErrInvalidCardClaim):
+		utils.BadRequest(c, err.Error())
+		return
+	case err != nil:
+		utils.InternalServerError(c, "failed to claim card")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "card claimed", card)
+}
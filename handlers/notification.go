@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationPreferenceReq struct for updating notification preferences; omitted fields keep their value
+type NotificationPreferenceReq struct {
+	LowBalance          *bool    `json:"low_balance"`
+	LowBalanceThreshold *float64 `json:"low_balance_threshold" binding:"omitempty,gte=0"`
+	TopUp               *bool    `json:"topup"`
+	CardBlocked         *bool    `json:"card_blocked"`
+	CardExpiring        *bool    `json:"card_expiring"`
+	Email               *bool    `json:"email"`
+	SMS                 *bool    `json:"sms"`  // gửi tới số điện thoại trong hồ sơ
+	Push                *bool    `json:"push"` // cần push_token
+	PushToken           *string  `json:"push_token"`
+}
+
+// GetMyNotificationPreferences handles GET /me/notifications/preferences
+// @Summary Get my notification preferences
+// @Description Which account events the authenticated user is notified about and on which channels. Users who never saved preferences get the defaults (every event by email).
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=models.NotificationPreference} "Preferences retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/notifications/preferences [get]
+func GetMyNotificationPreferences(c *gin.Context) {
+	preference, err := utils.LoadNotificationPreference(config.DB, currentUserID(c))
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch notification preferences")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "notification preferences retrieved successfully", preference)
+}
+
+// UpdateMyNotificationPreferences handles PUT /me/notifications/preferences
+// @Summary Update my notification preferences
+// @Description Turn notifications for low balance, top-ups, blocked cards and expiring cards on or off, set the low balance threshold and choose email, SMS or push
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body NotificationPreferenceReq true "Preferences to change"
+// @Success 200 {object} utils.Response{data=models.NotificationPreference} "Preferences updated"
+// @Failure 400 {object} utils.Response "Invalid preferences"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/notifications/preferences [put]
+func UpdateMyNotificationPreferences(c *gin.Context) {
+	var request NotificationPreferenceReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	preference, err := utils.LoadNotificationPreference(config.DB, currentUserID(c))
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch notification preferences")
+		return
+	}
+
+	if request.LowBalance != nil {
+		preference.LowBalance = *request.LowBalance
+	}
+	if request.TopUp != nil {
+		preference.TopUp = *request.TopUp
+	}
+	if request.CardBlocked != nil {
+		preference.CardBlocked = *request.CardBlocked
+	}
+	if request.CardExpiring != nil {
+		preference.CardExpiring = *request.CardExpiring
+	}
+	if request.Email != nil {
+		preference.Email = *request.Email
+	}
+	if request.SMS != nil {
+		preference.SMS = *request.SMS
+	}
+	if request.Push != nil {
+		preference.Push = *request.Push
+	}
+	if request.LowBalanceThreshold != nil {
+		preference.LowBalanceThreshold = *request.LowBalanceThreshold
+	}
+	if request.PushToken != nil {
+		preference.PushToken = strings.TrimSpace(*request.PushToken)
+	}
+	if preference.Push && preference.PushToken == "" {
+		utils.BadRequest(c, "push_token is required to receive push notifications")
+		return
+	}
+
+	if err := utils.SaveNotificationPreference(&preference); err != nil {
+		utils.InternalServerError(c, "failed to update notification preferences")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "notification preferences updated", preference)
+}
+
+// GetMyNotifications handles GET /me/notifications
+// @Summary List my notifications
+// @Description Notifications sent or queued for the authenticated user, newest first
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param event query string false "Filter by event (low_balance, topup, card_blocked, card_expiring)"
+// @Param status query string false "Filter by delivery status (pending, sent, failed)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Notification} "Notifications retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/notifications [get]
+func GetMyNotifications(c *gin.Context) {
+	query := config.DB.Where("user_id = ?", currentUserID(c))
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	notifications := []models.Notification{}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch notifications")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "notifications retrieved successfully", notifications)
+}
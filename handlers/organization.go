@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateOrganizationReq struct for creating a family or corporate account
+type CreateOrganizationReq struct {
+	Name    string         `json:"name" binding:"required"`
+	Type    consts.OrgType `json:"type" binding:"required"` // family hoặc corporate
+	OwnerID uint           `json:"owner_id" binding:"required"`
+}
+
+// OrganizationMemberReq struct for adding a member or changing their role
+type OrganizationMemberReq struct {
+	UserID uint           `json:"user_id" binding:"required"`
+	Role   consts.OrgRole `json:"role"` // owner, manager hoặc member (mặc định member)
+}
+
+// OrganizationCardReq struct for attaching a card to an organization
+type OrganizationCardReq struct {
+	RFID                string  `json:"rf_id" binding:"required"`
+	UserID              *uint   `json:"user_id"`                                         // thành viên được giao thẻ
+	MonthlyFundingLimit float64 `json:"monthly_funding_limit" binding:"omitempty,gte=0"` // 0: không giới hạn
+}
+
+// CardLimitReq struct for changing the monthly funding limit of an organization card
+type CardLimitReq struct {
+	MonthlyFundingLimit float64 `json:"monthly_funding_limit" binding:"gte=0"` // 0: không giới hạn
+}
+
+// WalletDepositReq struct for adding money to the shared wallet
+type WalletDepositReq struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Note   string  `json:"note"`
+}
+
+// WalletTopupReq struct for funding a card top-up from the shared wallet
+type WalletTopupReq struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// OrganizationDetail là tổ chức cùng thành viên và thẻ
+type OrganizationDetail struct {
+	models.Organization
+	Members []models.OrganizationMember `json:"members"`
+	Cards   []OrganizationCardDetail    `json:"cards"`
+}
+
+// OrganizationCardDetail là thẻ của tổ chức kèm hạn mức nạp và số tiền ví đã nạp trong tháng
+type OrganizationCardDetail struct {
+	models.Card
+	MonthlyFundingLimit float64 `json:"monthly_funding_limit"`
+	MonthlyFunded       float64 `json:"monthly_funded"`
+}
+
+// organizationDetail tải tổ chức; holderID khác nil chỉ lấy thành viên và thẻ của người đó
+func organizationDetail(orgID uint, holderID *uint) (OrganizationDetail, error) {
+	detail := OrganizationDetail{Members: []models.OrganizationMember{}, Cards: []OrganizationCardDetail{}}
+	if err := config.DB.First(&detail.Organization, orgID).Error; err != nil {
+		return detail, err
+	}
+
+	members := config.DB.Preload("User").Where("organization_id = ?", orgID)
+	if holderID != nil {
+		members = members.Where("user_id = ?", *holderID)
+	}
+	if err := members.Order("id").Find(&detail.Members).Error; err != nil {
+		return detail, err
+	}
+
+	var links []models.OrganizationCard
+	if err := config.DB.Where("organization_id = ?", orgID).Order("id").Find(&links).Error; err != nil {
+		return detail, err
+	}
+	now := time.Now()
+	for _, link := range links {
+		var card models.Card
+		if err := config.DB.Where("rf_id = ?", link.CardID).First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return detail, err
+		}
+		if holderID != nil && (card.UserID == nil || *card.UserID != *holderID) {
+			continue
+		}
+		funded, err := utils.CardMonthlyFunding(config.DB, orgID, link.CardID, now)
+		if err != nil {
+			return detail, err
+		}
+		detail.Cards = append(detail.Cards, OrganizationCardDetail{Card: card, MonthlyFundingLimit: link.MonthlyFundingLimit, MonthlyFunded: funded})
+	}
+	return detail, nil
+}
+
+// organizationError trả lỗi của các thao tác trên tổ chức
+func organizationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFound(c, "organization or card not found")
+	case errors.Is(err, utils.ErrNotOrgMember), errors.Is(err, utils.ErrCardNotInOrg):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, utils.ErrUserInOtherOrg), errors.Is(err, utils.ErrCardInOtherOrg):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, utils.ErrHolderNotInOrg), errors.Is(err, utils.ErrLastOrgOwner),
+		errors.Is(err, utils.ErrWalletInsufficient), errors.Is(err, utils.ErrCardFundingLimitExceeded):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, message)
+	}
+}
+
+// orgIDParam đọc ID tổ chức trên URL
+func orgIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequest(c, "invalid organization id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// myMembership là tư cách thành viên tổ chức của người dùng hiện tại
+func myMembership(c *gin.Context) (models.OrganizationMember, bool) {
+	member, err := utils.OrgMembership(currentUserID(c))
+	if err != nil {
+		organizationError(c, err, "failed to fetch organization")
+		return member, false
+	}
+	return member, true
+}
+
+// requireOrgManager chỉ cho chủ tài khoản và quản lý của tổ chức
+func requireOrgManager(c *gin.Context, member models.OrganizationMember) bool {
+	if !member.Role.CanManage() {
+		utils.ErrorResponse(c, http.StatusForbidden, "only organization owners and managers can do this")
+		return false
+	}
+	return true
+}
+
+// CreateOrganization handles POST /admin/orgs
+// @Summary Create organization
+// @Description Create a family or corporate account with a shared wallet. The owner must not belong to another organization.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateOrganizationReq true "Organization"
+// @Success 201 {object} utils.Response{data=models.Organization} "Organization created"
+// @Failure 400 {object} utils.Response "Bad request"
+// @Failure 404 {object} utils.Response "Owner not found"
+// @Failure 409 {object} utils.Response "Owner already belongs to an organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs [post]
+func CreateOrganization(c *gin.Context) {
+	var request CreateOrganizationReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if !request.Type.IsValid() {
+		utils.BadRequest(c, "type must be family or corporate")
+		return
+	}
+
+	var owner models.User
+	if err := config.DB.First(&owner, request.OwnerID).Error; err != nil {
+		utils.NotFound(c, "Người dùng không tồn tại")
+		return
+	}
+
+	org, err := utils.CreateOrganization(utils.RequestDB(c), strings.TrimSpace(request.Name), request.Type, owner.ID)
+	if err != nil {
+		organizationError(c, err, "failed to create organization")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(org.ID), 10))
+	utils.AuditAfter(c, org)
+
+	utils.SuccessResponse(c, http.StatusCreated, "organization created", org)
+}
+
+// GetOrganizations handles GET /admin/orgs
+// @Summary List organizations
+// @Description Family and corporate accounts with their wallet balance
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param type query string false "Filter by type (family, corporate)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Organization} "Organizations retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs [get]
+func GetOrganizations(c *gin.Context) {
+	query := config.DB.Model(&models.Organization{})
+	if orgType := c.Query("type"); orgType != "" {
+		query = query.Where("type = ?", orgType)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	orgs := []models.Organization{}
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&orgs).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch organizations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "organizations retrieved successfully", orgs)
+}
+
+// GetOrganizationByID handles GET /admin/orgs/:id
+// @Summary Get organization
+// @Description Organization with its members and cards, including each card's monthly funding limit and what the wallet has funded this month
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {object} utils.Response{data=OrganizationDetail} "Organization retrieved successfully"
+// @Failure 404 {object} utils.Response "Organization not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id} [get]
+func GetOrganizationByID(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := organizationDetail(orgID, nil)
+	if err != nil {
+		organizationError(c, err, "failed to fetch organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "organization retrieved successfully", detail)
+}
+
+// SetOrganizationMember handles POST /admin/orgs/:id/members
+// @Summary Add organization member
+// @Description Add a user to the organization or change their role. A user belongs to at most one organization, and an organization always keeps one owner.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body OrganizationMemberReq true "Member"
+// @Success 200 {object} utils.Response{data=models.OrganizationMember} "Member saved"
+// @Failure 400 {object} utils.Response "Bad request"
+// @Failure 404 {object} utils.Response "Organization or user not found"
+// @Failure 409 {object} utils.Response "User already belongs to another organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/members [post]
+func SetOrganizationMember(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request OrganizationMemberReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if request.Role == "" {
+		request.Role = consts.OrgMember
+	}
+	if !request.Role.IsValid() {
+		utils.BadRequest(c, "role must be owner, manager or member")
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, request.UserID).Error; err != nil {
+		utils.NotFound(c, "Người dùng không tồn tại")
+		return
+	}
+
+	member, err := utils.SetOrgMember(utils.RequestDB(c), orgID, user.ID, request.Role)
+	if err != nil {
+		organizationError(c, err, "failed to save member")
+		return
+	}
+	utils.AuditAfter(c, member)
+
+	utils.SuccessResponse(c, http.StatusOK, "member saved", member)
+}
+
+// RemoveOrganizationMember handles DELETE /admin/orgs/:id/members/:user_id
+// @Summary Remove organization member
+// @Description Remove a user from the organization. Cards they hold stay with the organization.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} utils.Response "Member removed"
+// @Failure 400 {object} utils.Response "Last owner cannot be removed"
+// @Failure 404 {object} utils.Response "Member not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/members/{user_id} [delete]
+func RemoveOrganizationMember(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		utils.BadRequest(c, "invalid user_id")
+		return
+	}
+
+	if err := utils.RemoveOrgMember(utils.RequestDB(c), orgID, uint(userID)); err != nil {
+		organizationError(c, err, "failed to remove member")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "member removed", nil)
+}
+
+// AddOrganizationCard handles POST /admin/orgs/:id/cards
+// @Summary Add card to organization
+// @Description Put a card under the organization so the shared wallet can fund its top-ups. user_id hands the card to a member; a card that already has an owner can only join that owner's organization.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body OrganizationCardReq true "Card"
+// @Success 201 {object} utils.Response{data=models.OrganizationCard} "Card added"
+// @Failure 400 {object} utils.Response "Card holder is not a member"
+// @Failure 404 {object} utils.Response "Organization or card not found"
+// @Failure 409 {object} utils.Response "Card already belongs to an organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/cards [post]
+func AddOrganizationCard(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request OrganizationCardReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	link, err := utils.AttachOrgCard(utils.RequestDB(c), orgID, strings.TrimSpace(request.RFID), request.UserID, request.MonthlyFundingLimit)
+	if err != nil {
+		organizationError(c, err, "failed to add card")
+		return
+	}
+	utils.AuditAfter(c, link)
+
+	utils.SuccessResponse(c, http.StatusCreated, "card added", link)
+}
+
+// RemoveOrganizationCard handles DELETE /admin/orgs/:id/cards/:rf_id
+// @Summary Remove card from organization
+// @Description Stop funding a card from the shared wallet. The card stays with its holder.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param rf_id path string true "Card ID"
+// @Success 200 {object} utils.Response "Card removed"
+// @Failure 404 {object} utils.Response "Card does not belong to this organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/cards/{rf_id} [delete]
+func RemoveOrganizationCard(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := utils.DetachOrgCard(utils.RequestDB(c), orgID, c.Param("rf_id")); err != nil {
+		organizationError(c, err, "failed to remove card")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "card removed", nil)
+}
+
+// DepositOrganizationWallet handles POST /admin/orgs/:id/wallet/deposit
+// @Summary Deposit into shared wallet
+// @Description Record money received from the organization into its shared wallet
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body WalletDepositReq true "Deposit"
+// @Success 200 {object} utils.Response{data=models.WalletTransaction} "Deposit recorded"
+// @Failure 400 {object} utils.Response "Invalid amount"
+// @Failure 404 {object} utils.Response "Organization not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/wallet/deposit [post]
+func DepositOrganizationWallet(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request WalletDepositReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, "số tiền không hợp lệ")
+		return
+	}
+
+	entry, err := utils.DepositToWallet(utils.RequestDB(c), orgID, request.Amount, currentUserID(c), request.Note)
+	if err != nil {
+		organizationError(c, err, "failed to deposit")
+		return
+	}
+	utils.AuditAfter(c, entry)
+
+	utils.SuccessResponse(c, http.StatusOK, "deposit recorded", entry)
+}
+
+// GetOrganizationStatement handles GET /admin/orgs/:id/statements/:month
+// @Summary Get monthly statement
+// @Description Shared wallet statement of an organization for a month: opening and closing balance, deposits, top-ups per card, every wallet transaction, and the fares and balance transfers paid by the organization's cards
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param month path string true "Month (YYYY-MM)"
+// @Success 200 {object} utils.Response{data=utils.OrgStatement} "Statement retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid month"
+// @Failure 404 {object} utils.Response "Organization not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orgs/{id}/statements/{month} [get]
+func GetOrganizationStatement(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+	if err := config.DB.First(&models.Organization{}, orgID).Error; err != nil {
+		organizationError(c, err, "failed to fetch statement")
+		return
+	}
+
+	organizationStatement(c, orgID)
+}
+
+// organizationStatement trả sao kê tháng trên URL
+func organizationStatement(c *gin.Context, orgID uint) {
+	statement, err := utils.BuildOrgStatement(orgID, c.Param("month"))
+	if err != nil {
+		var parseErr *time.ParseError
+		if errors.As(err, &parseErr) {
+			utils.BadRequest(c, "month must be YYYY-MM")
+			return
+		}
+		utils.InternalServerError(c, "failed to fetch statement")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "statement retrieved successfully", statement)
+}
+
+// GetMyOrganization handles GET /org
+// @Summary Get my organization
+// @Description Organization of the authenticated user. Owners and managers see every member and card; members only see themselves and their own cards.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=OrganizationDetail} "Organization retrieved successfully"
+// @Failure 404 {object} utils.Response "Not a member of an organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /org [get]
+func GetMyOrganization(c *gin.Context) {
+	member, ok := myMembership(c)
+	if !ok {
+		return
+	}
+
+	var holderID *uint
+	if !member.Role.CanManage() {
+		holderID = &member.UserID
+	}
+	detail, err := organizationDetail(member.OrganizationID, holderID)
+	if err != nil {
+		organizationError(c, err, "failed to fetch organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "organization retrieved successfully", detail)
+}
+
+// SetMyOrganizationCardLimit handles PUT /org/cards/:rf_id/limit
+// @Summary Set card funding limit
+// @Description Change how much the shared wallet may top up a member card each month (0 = unlimited). The limit does not restrict what the card spends. Owners and managers only.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Param request body CardLimitReq true "Monthly funding limit"
+// @Success 200 {object} utils.Response{data=models.OrganizationCard} "Limit updated"
+// @Failure 400 {object} utils.Response "Invalid limit"
+// @Failure 403 {object} utils.Response "Not an owner or manager"
+// @Failure 404 {object} utils.Response "Card does not belong to the organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /org/cards/{rf_id}/limit [put]
+func SetMyOrganizationCardLimit(c *gin.Context) {
+	member, ok := myMembership(c)
+	if !ok || !requireOrgManager(c, member) {
+		return
+	}
+
+	var request CardLimitReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	link, err := utils.SetOrgCardFundingLimit(member.OrganizationID, c.Param("rf_id"), request.MonthlyFundingLimit)
+	if err != nil {
+		organizationError(c, err, "failed to update limit")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "limit updated", link)
+}
+
+// TopUpMyOrganizationCard handles POST /org/cards/:rf_id/topup
+// @Summary Top up card from shared wallet
+// @Description Move money from the shared wallet onto an organization card, within the card's monthly funding limit. Owners and managers can fund any card; members only the cards they hold.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rf_id path string true "Card ID"
+// @Param request body WalletTopupReq true "Amount"
+// @Success 200 {object} utils.Response{data=models.Card} "Card topped up"
+// @Failure 400 {object} utils.Response "Insufficient wallet balance or monthly funding limit exceeded"
+// @Failure 404 {object} utils.Response "Card does not belong to the organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /org/cards/{rf_id}/topup [post]
+func TopUpMyOrganizationCard(c *gin.Context) {
+	member, ok := myMembership(c)
+	if !ok {
+		return
+	}
+
+	var request WalletTopupReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, "số tiền không hợp lệ")
+		return
+	}
+
+	rfID := c.Param("rf_id")
+	if !member.Role.CanManage() {
+		// Thành viên chỉ được nạp cho thẻ mình giữ; thẻ khác được báo như không thuộc tổ chức
+		var count int64
+		if err := config.DB.Model(&models.Card{}).Where("rf_id = ? AND user_id = ?", rfID, member.UserID).Count(&count).Error; err != nil {
+			utils.InternalServerError(c, "failed to top up card")
+			return
+		}
+		if count == 0 {
+			organizationError(c, utils.ErrCardNotInOrg, "")
+			return
+		}
+	}
+
+	card, _, err := utils.FundCardTopUp(member.OrganizationID, rfID, request.Amount, member.UserID)
+	if err != nil {
+		organizationError(c, err, "failed to top up card")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Nạp tiền thành công", card)
+}
+
+// GetMyOrganizationStatement handles GET /org/statements/:month
+// @Summary Get my organization's monthly statement
+// @Description Shared wallet statement for a month (YYYY-MM), including the fares and balance transfers paid by the organization's cards. Owners and managers only.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param month path string true "Month (YYYY-MM)"
+// @Success 200 {object} utils.Response{data=utils.OrgStatement} "Statement retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid month"
+// @Failure 403 {object} utils.Response "Not an owner or manager"
+// @Failure 404 {object} utils.Response "Not a member of an organization"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /org/statements/{month} [get]
+func GetMyOrganizationStatement(c *gin.Context) {
+	member, ok := myMembership(c)
+	if !ok || !requireOrgManager(c, member) {
+		return
+	}
+
+	organizationStatement(c, member.OrganizationID)
+}
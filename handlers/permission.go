@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"go-metro/consts"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RolePermissionsReq struct for replacing the permissions of a role
+type RolePermissionsReq struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// RolePermissionsRes là danh sách quyền của một role
+type RolePermissionsRes struct {
+	Role        consts.Role         `json:"role"`
+	RoleName    string              `json:"role_name"`
+	Permissions []consts.Permission `json:"permissions"`
+}
+
+var allRoles = []consts.Role{consts.AdminRole, consts.StaffRole, consts.UserRole}
+
+// parseRole đọc role từ path (1, 2, 3 hoặc admin, staff, user)
+func parseRole(value string) (consts.Role, bool) {
+	for _, role := range allRoles {
+		if value == role.ToText() || value == strconv.Itoa(int(role)) {
+			return role, true
+		}
+	}
+	return 0, false
+}
+
+func rolePermissions(role consts.Role) (RolePermissionsRes, error) {
+	granted, err := utils.GetRolePermissions(role)
+	return RolePermissionsRes{Role: role, RoleName: role.ToText(), Permissions: granted}, err
+}
+
+// GetPermissions handles GET /admin/permissions
+// @Summary Get the permission matrix (Admin only)
+// @Description List every permission the system knows and the permissions granted to each role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=object{permissions=[]string,roles=[]RolePermissionsRes}} "Permissions retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/permissions [get]
+func GetPermissions(c *gin.Context) {
+	roles := make([]RolePermissionsRes, 0, len(allRoles))
+	for _, role := range allRoles {
+		res, err := rolePermissions(role)
+		if err != nil {
+			utils.InternalServerError(c, "failed to fetch permissions")
+			return
+		}
+		roles = append(roles, res)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "permissions retrieved successfully", gin.H{
+		"permissions": consts.AllPermissions,
+		"roles":       roles,
+	})
+}
+
+// GetRolePermissions handles GET /admin/roles/:role/permissions
+// @Summary Get permissions of a role (Admin only)
+// @Description Retrieve the permissions granted to a role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role (1/admin, 2/staff, 3/user)"
+// @Success 200 {object} utils.Response{data=RolePermissionsRes} "Permissions retrieved successfully"
+// @Failure 404 {object} utils.Response "Role not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/roles/{role}/permissions [get]
+func GetRolePermissions(c *gin.Context) {
+	role, ok := parseRole(c.Param("role"))
+	if !ok {
+		utils.NotFound(c, "role not found")
+		return
+	}
+
+	res, err := rolePermissions(role)
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch permissions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "permissions retrieved successfully", res)
+}
+
+// UpdateRolePermissions handles PUT /admin/roles/:role/permissions
+// @Summary Replace permissions of a role (Admin only)
+// @Description Replace the full list of permissions granted to a role. Admin always keeps role:manage.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role (1/admin, 2/staff, 3/user)"
+// @Param request body RolePermissionsReq true "Permissions to grant"
+// @Success 200 {object} utils.Response{data=RolePermissionsRes} "Permissions updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - unknown permission"
+// @Failure 404 {object} utils.Response "Role not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/roles/{role}/permissions [put]
+func UpdateRolePermissions(c *gin.Context) {
+	role, ok := parseRole(c.Param("role"))
+	if !ok {
+		utils.NotFound(c, "role not found")
+		return
+	}
+
+	var request RolePermissionsReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	granted := make([]consts.Permission, 0, len(request.Permissions))
+	for _, value := range request.Permissions {
+		permission := consts.Permission(value)
+		if !permission.IsValid() {
+			utils.BadRequest(c, "unknown permission: "+value)
+			return
+		}
+		granted = append(granted, permission)
+	}
+
+	before, err := rolePermissions(role)
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch permissions")
+		return
+	}
+	utils.AuditTarget(c, role.ToText())
+	utils.AuditBefore(c, before)
+
+	permissionList, err := utils.SetRolePermissions(utils.RequestDB(c), role, granted)
+	if err != nil {
+		utils.InternalServerError(c, "failed to update permissions")
+		return
+	}
+	utils.AfterCommit(c, utils.InvalidatePermissions)
+
+	res := RolePermissionsRes{Role: role, RoleName: role.ToText(), Permissions: permissionList}
+	utils.AuditAfter(c, res)
+
+	utils.SuccessResponse(c, http.StatusOK, "permissions updated successfully", res)
+}
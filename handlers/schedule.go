@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// PatternStopReq struct for one stop of a service pattern
+type PatternStopReq struct {
+	StationID       uint `json:"station_id" binding:"required"`
+	ArrivalOffset   int  `json:"arrival_offset" binding:"min=0"`
+	DepartureOffset int  `json:"departure_offset" binding:"min=0"`
+}
+
+// ServicePatternReq struct for creating and updating a service pattern
+type ServicePatternReq struct {
+	Name           string           `json:"name" binding:"required"`
+	LineID         uint             `json:"line_id" binding:"required"`
+	TrainID        uint             `json:"train_id" binding:"required"`
+	Direction      consts.Direction `json:"direction" binding:"oneof=0 1"`
+	Monday         bool             `json:"monday"`
+	Tuesday        bool             `json:"tuesday"`
+	Wednesday      bool             `json:"wednesday"`
+	Thursday       bool             `json:"thursday"`
+	Friday         bool             `json:"friday"`
+	Saturday       bool             `json:"saturday"`
+	Sunday         bool             `json:"sunday"`
+	StartDate      string           `json:"start_date" binding:"omitempty,datetime=2006-01-02" example:"2025-01-01"`
+	EndDate        string           `json:"end_date" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`
+	FirstDeparture string           `json:"first_departure" binding:"required" example:"05:30"`
+	LastDeparture  string           `json:"last_departure" binding:"required" example:"22:00"`
+	HeadwayMinutes int              `json:"headway_minutes" binding:"min=0" example:"10"`
+	Stops          []PatternStopReq `json:"stops" binding:"required,min=2,dive"`
+}
+
+// ServiceExceptionReq struct for adding or removing a service day
+type ServiceExceptionReq struct {
+	Date        string `json:"date" binding:"required,datetime=2006-01-02" example:"2025-04-30"`
+	Type        string `json:"type" binding:"required,oneof=added removed"`
+	Description string `json:"description"`
+}
+
+// GenerateTripsReq struct for generating trips from service patterns
+type GenerateTripsReq struct {
+	From string `json:"from" binding:"omitempty,datetime=2006-01-02" example:"2025-01-01"`
+	To   string `json:"to" binding:"omitempty,datetime=2006-01-02" example:"2025-01-07"`
+}
+
+// buildServicePattern validates the request and converts it into a ServicePattern
+func buildServicePattern(request ServicePatternReq) (models.ServicePattern, error) {
+	pattern := models.ServicePattern{
+		Name:           request.Name,
+		LineID:         request.LineID,
+		TrainID:        request.TrainID,
+		Direction:      request.Direction,
+		Monday:         request.Monday,
+		Tuesday:        request.Tuesday,
+		Wednesday:      request.Wednesday,
+		Thursday:       request.Thursday,
+		Friday:         request.Friday,
+		Saturday:       request.Saturday,
+		Sunday:         request.Sunday,
+		FirstDeparture: request.FirstDeparture,
+		LastDeparture:  request.LastDeparture,
+		HeadwayMinutes: request.HeadwayMinutes,
+	}
+
+	first, err := models.ParseClock(request.FirstDeparture)
+	if err != nil {
+		return pattern, err
+	}
+	last, err := models.ParseClock(request.LastDeparture)
+	if err != nil {
+		return pattern, err
+	}
+	if last < first {
+		return pattern, fmt.Errorf("last_departure must not be before first_departure")
+	}
+
+	if request.StartDate != "" {
+		pattern.StartDate, _ = time.ParseInLocation(dateLayout, request.StartDate, time.Local)
+	}
+	if request.EndDate != "" {
+		pattern.EndDate, _ = time.ParseInLocation(dateLayout, request.EndDate, time.Local)
+	}
+	if !pattern.StartDate.IsZero() && !pattern.EndDate.IsZero() && pattern.EndDate.Before(pattern.StartDate) {
+		return pattern, fmt.Errorf("end_date must not be before start_date")
+	}
+
+	var line models.Line
+	if err := config.DB.First(&line, request.LineID).Error; err != nil {
+		return pattern, fmt.Errorf("line not found")
+	}
+	var train models.Train
+	if err := config.DB.First(&train, request.TrainID).Error; err != nil {
+		return pattern, fmt.Errorf("train not found")
+	}
+
+	previous := -1
+	for i, stop := range request.Stops {
+		if stop.DepartureOffset < stop.ArrivalOffset {
+			return pattern, fmt.Errorf("stop %d departs before it arrives", i+1)
+		}
+		if stop.ArrivalOffset < previous {
+			return pattern, fmt.Errorf("stop %d arrives before the previous stop departs", i+1)
+		}
+		previous = stop.DepartureOffset
+
+		var station models.Station
+		if err := config.DB.First(&station, stop.StationID).Error; err != nil {
+			return pattern, fmt.Errorf("station %d not found", stop.StationID)
+		}
+
+		pattern.Stops = append(pattern.Stops, models.PatternStop{
+			StopSequence:    i + 1,
+			StationID:       stop.StationID,
+			ArrivalOffset:   stop.ArrivalOffset,
+			DepartureOffset: stop.DepartureOffset,
+		})
+	}
+
+	return pattern, nil
+}
+
+// CreateServicePattern handles POST /schedule/patterns
+// @Summary Create a service pattern
+// @Description Create a service pattern with its stops, days of operation and departure window
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pattern body ServicePatternReq true "Service pattern information"
+// @Success 201 {object} utils.Response{data=models.ServicePattern} "Service pattern created successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/patterns [post]
+func CreateServicePattern(c *gin.Context) {
+	var request ServicePatternReq
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	pattern, err := buildServicePattern(request)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := utils.RequestDB(c).Create(&pattern).Error; err != nil {
+		utils.InternalServerError(c, "failed to create service pattern")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(pattern.ID), 10))
+	utils.AuditAfter(c, pattern)
+
+	utils.SuccessResponse(c, http.StatusCreated, "service pattern created successfully", pattern)
+}
+
+// GetServicePatterns handles GET /schedule/patterns
+// @Summary Get all service patterns
+// @Description Retrieve all service patterns with their stops and exceptions
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param line_id query int false "Filter by line ID"
+// @Success 200 {object} utils.Response{data=[]models.ServicePattern} "Service patterns retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/patterns [get]
+func GetServicePatterns(c *gin.Context) {
+	var patterns []models.ServicePattern
+	query := config.DB.Preload("Line").Preload("Train").Preload("Exceptions").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB { return db.Order("stop_sequence ASC") })
+
+	if lineID := c.Query("line_id"); lineID != "" {
+		query = query.Where("line_id = ?", lineID)
+	}
+
+	if err := query.Order("id ASC").Find(&patterns).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch service patterns")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "service patterns retrieved successfully", patterns)
+}
+
+// GetServicePatternByID handles GET /schedule/patterns/:id
+// @Summary Get service pattern by ID
+// @Description Retrieve a specific service pattern with its stops and exceptions
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param id path int true "Service pattern ID"
+// @Success 200 {object} utils.Response{data=models.ServicePattern} "Service pattern retrieved successfully"
+// @Failure 404 {object} utils.Response "Service pattern not found"
+// @Router /schedule/patterns/{id} [get]
+func GetServicePatternByID(c *gin.Context) {
+	id := c.Param("id")
+	var pattern models.ServicePattern
+
+	if err := config.DB.Preload("Line").Preload("Train").Preload("Exceptions").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB { return db.Order("stop_sequence ASC") }).
+		Preload("Stops.Station").
+		First(&pattern, id).Error; err != nil {
+		utils.NotFound(c, "service pattern not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "service pattern retrieved successfully", pattern)
+}
+
+// UpdateServicePattern handles PUT /schedule/patterns/:id
+// @Summary Update service pattern
+// @Description Replace a service pattern and its stops. Generated trips that have not started yet are removed and must be generated again.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service pattern ID"
+// @Param pattern body ServicePatternReq true "Updated service pattern information"
+// @Success 200 {object} utils.Response{data=models.ServicePattern} "Service pattern updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Service pattern not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/patterns/{id} [put]
+func UpdateServicePattern(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var existing models.ServicePattern
+
+	// Check if record exists
+	if err := db.First(&existing, id).Error; err != nil {
+		utils.NotFound(c, "service pattern not found")
+		return
+	}
+
+	var request ServicePatternReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	pattern, err := buildServicePattern(request)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	pattern.ID = existing.ID
+	pattern.CreatedAt = existing.CreatedAt
+	utils.AuditBefore(c, existing)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pattern_id = ?", pattern.ID).Delete(&models.PatternStop{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("pattern_id = ? AND start_time > ?", pattern.ID, time.Now()).Delete(&models.Trip{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&pattern).Error
+	})
+	if err != nil {
+		utils.InternalServerError(c, "failed to update service pattern")
+		return
+	}
+	utils.AuditAfter(c, pattern)
+
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	utils.SuccessResponse(c, http.StatusOK, "service pattern updated successfully", pattern)
+}
+
+// DeleteServicePattern handles DELETE /schedule/patterns/:id
+// @Summary Delete service pattern
+// @Description Delete a service pattern together with its stops, exceptions and generated trips
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service pattern ID"
+// @Success 200 {object} utils.Response "Service pattern deleted successfully"
+// @Failure 404 {object} utils.Response "Service pattern not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/patterns/{id} [delete]
+func DeleteServicePattern(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var pattern models.ServicePattern
+
+	// Check if record exists
+	if err := db.First(&pattern, id).Error; err != nil {
+		utils.NotFound(c, "service pattern not found")
+		return
+	}
+
+	utils.AuditBefore(c, pattern)
+
+	if err := db.Delete(&pattern).Error; err != nil {
+		utils.InternalServerError(c, "failed to delete service pattern")
+		return
+	}
+
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	utils.SuccessResponse(c, http.StatusOK, "service pattern deleted successfully", nil)
+}
+
+// errServiceExceptionExists báo ngày đã có ngoại lệ; mỗi lịch chạy chỉ có một ngoại lệ mỗi ngày
+var errServiceExceptionExists = errors.New("service pattern already has an exception for this date")
+
+// AddServiceException handles POST /schedule/patterns/:id/exceptions
+// @Summary Add a service exception
+// @Description Add or remove a single service day (e.g. a public holiday) for a service pattern
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service pattern ID"
+// @Param exception body ServiceExceptionReq true "Service exception information"
+// @Success 201 {object} utils.Response{data=models.ServiceException} "Service exception created successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Service pattern not found"
+// @Failure 409 {object} utils.Response "The pattern already has an exception for this date"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/patterns/{id}/exceptions [post]
+func AddServiceException(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var pattern models.ServicePattern
+
+	if err := db.First(&pattern, id).Error; err != nil {
+		utils.NotFound(c, "service pattern not found")
+		return
+	}
+
+	var request ServiceExceptionReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	date, _ := time.ParseInLocation(dateLayout, request.Date, time.Local)
+	exception := models.ServiceException{
+		PatternID:   pattern.ID,
+		Date:        date,
+		Type:        consts.ServiceAdded,
+		Description: request.Description,
+	}
+	if request.Type == "removed" {
+		exception.Type = consts.ServiceRemoved
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ServiceException{}).
+			Where("pattern_id = ? AND date = ?", pattern.ID, request.Date).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errServiceExceptionExists
+		}
+		if err := tx.Create(&exception).Error; err != nil {
+			return err
+		}
+		// Ngày bị bỏ thì xóa luôn các chuyến đã sinh cho ngày đó
+		if exception.Type == consts.ServiceRemoved {
+			return tx.Where("pattern_id = ? AND service_date = ?", pattern.ID, request.Date).Delete(&models.Trip{}).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errServiceExceptionExists) {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalServerError(c, "failed to create service exception")
+		return
+	}
+	utils.AuditAfter(c, exception)
+
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	utils.SuccessResponse(c, http.StatusCreated, "service exception created successfully", exception)
+}
+
+// DeleteServiceException handles DELETE /schedule/exceptions/:id
+// @Summary Delete a service exception
+// @Description Delete a service exception
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service exception ID"
+// @Success 200 {object} utils.Response "Service exception deleted successfully"
+// @Failure 404 {object} utils.Response "Service exception not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/exceptions/{id} [delete]
+func DeleteServiceException(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var exception models.ServiceException
+
+	if err := db.First(&exception, id).Error; err != nil {
+		utils.NotFound(c, "service exception not found")
+		return
+	}
+
+	utils.AuditBefore(c, exception)
+
+	if err := db.Delete(&exception).Error; err != nil {
+		utils.InternalServerError(c, "failed to delete service exception")
+		return
+	}
+
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	utils.SuccessResponse(c, http.StatusOK, "service exception deleted successfully", nil)
+}
+
+// GenerateScheduledTrips handles POST /schedule/generate
+// @Summary Generate trips from service patterns
+// @Description Generate concrete trips from all service patterns for a date range (default: the next 7 days). Existing trips are kept.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GenerateTripsReq false "Date range"
+// @Success 200 {object} utils.Response "Trips generated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /schedule/generate [post]
+func GenerateScheduledTrips(c *gin.Context) {
+	var request GenerateTripsReq
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 6)
+	if request.From != "" {
+		from, _ = time.ParseInLocation(dateLayout, request.From, time.Local)
+	}
+	if request.To != "" {
+		to, _ = time.ParseInLocation(dateLayout, request.To, time.Local)
+	}
+
+	if to.Before(from) {
+		utils.BadRequest(c, "to must not be before from")
+		return
+	}
+	if to.Sub(from) >= utils.MaxGenerateDays*24*time.Hour {
+		utils.BadRequest(c, fmt.Sprintf("date range must not exceed %d days", utils.MaxGenerateDays))
+		return
+	}
+
+	created, err := utils.GenerateTrips(utils.RequestDB(c), from, to)
+	if err != nil {
+		utils.InternalServerError(c, "failed to generate trips")
+		return
+	}
+
+	result := gin.H{
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"created": created,
+	}
+	utils.AuditAfter(c, result)
+
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
+	utils.SuccessResponse(c, http.StatusOK, "trips generated successfully", result)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockIPReq struct for clearing the failed-login counter of an IP
+type UnlockIPReq struct {
+	IP string `json:"ip" binding:"required,ip"`
+}
+
+// unlockError trả lỗi khi mở khóa đăng nhập
+func unlockError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrNoLockout) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.InternalServerError(c, "failed to unlock")
+}
+
+// UnlockUserLogin handles POST /admin/users/:id/unlock
+// @Summary Unlock user login
+// @Description Clear the failed-login counter, backoff and temporary lock of a user account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response "Account unlocked"
+// @Failure 404 {object} utils.Response "User not found or not locked"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/users/{id}/unlock [post]
+func UnlockUserLogin(c *gin.Context) {
+	var user models.User
+	db := utils.RequestDB(c)
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "user not found")
+		return
+	}
+
+	actorID, _ := c.Get("user_id")
+	actor, _ := actorID.(uint)
+	if err := utils.UnlockLogin(db, models.ThrottleAccount, user.Email, actor, &user.ID); err != nil {
+		unlockError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "account unlocked", nil)
+}
+
+// UnlockIP handles POST /admin/security/unlock-ip
+// @Summary Unlock IP address
+// @Description Clear the failed-login counter, backoff and temporary lock of an IP address
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UnlockIPReq true "IP address"
+// @Success 200 {object} utils.Response "IP unlocked"
+// @Failure 400 {object} utils.Response "Invalid IP"
+// @Failure 404 {object} utils.Response "IP not locked"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/security/unlock-ip [post]
+func UnlockIP(c *gin.Context) {
+	var request UnlockIPReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	actorID, _ := c.Get("user_id")
+	actor, _ := actorID.(uint)
+	if err := utils.UnlockLogin(utils.RequestDB(c), models.ThrottleIP, request.IP, actor, nil); err != nil {
+		unlockError(c, err)
+		return
+	}
+	utils.AuditTarget(c, request.IP)
+
+	utils.SuccessResponse(c, http.StatusOK, "ip unlocked", nil)
+}
+
+// GetLoginLockouts handles GET /admin/security/lockouts
+// @Summary List login lockouts
+// @Description List accounts and IPs that are currently in backoff or temporarily locked
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param scope query string false "account or ip"
+// @Success 200 {object} utils.Response{data=[]models.LoginThrottle} "Lockouts retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/security/lockouts [get]
+func GetLoginLockouts(c *gin.Context) {
+	now := time.Now()
+	query := config.DB.Where("locked_until > ? OR blocked_until > ?", now, now)
+	if scope := c.Query("scope"); scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+
+	lockouts := []models.LoginThrottle{}
+	if err := query.Order("updated_at DESC").Find(&lockouts).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch lockouts")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "lockouts retrieved successfully", lockouts)
+}
+
+// GetSecurityEvents handles GET /admin/security/events
+// @Summary List security events
+// @Description Audit trail of logins, failed attempts, lockouts and unlocks, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param event query string false "Event type (login_succeeded, login_failed, login_throttled, account_locked, ip_locked, account_unlocked, ip_unlocked)"
+// @Param user_id query int false "User ID"
+// @Param email query string false "Email"
+// @Param ip query string false "IP address"
+// @Param from query string false "Only events at or after this time (RFC3339)"
+// @Param to query string false "Only events before this time (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.SecurityEvent} "Security events retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filter"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/security/events [get]
+func GetSecurityEvents(c *gin.Context) {
+	query := config.DB.Model(&models.SecurityEvent{})
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			utils.BadRequest(c, "invalid user_id")
+			return
+		}
+		query = query.Where("user_id = ?", id)
+	}
+	if email := c.Query("email"); email != "" {
+		query = query.Where("email = ?", email)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		query = query.Where("ip_address = ?", ip)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.BadRequest(c, "from must be RFC3339")
+			return
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			utils.BadRequest(c, "to must be RFC3339")
+			return
+		}
+		query = query.Where("created_at < ?", toTime)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	events := []models.SecurityEvent{}
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch security events")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "security events retrieved successfully", events)
+}
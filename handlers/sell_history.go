@@ -13,20 +13,28 @@ import (
 
 // GetSellHistories handles GET /sell-history
 // @Summary Get all sell history records
-// @Description Retrieve all sell history records with optional filtering
+// @Description Retrieve all sell history records with optional filtering. Staff only see sales at their assigned stations.
 // @Tags sell-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param page query int false "Page number"
 // @Param limit query int false "Number of records per page"
 // @Param card_id query string false "Filter by card ID"
 // @Param seller_id query string false "Filter by seller ID"
+// @Param station_id query int false "Filter by station where the card was sold"
 // @Success 200 {object} utils.Response{data=[]models.SellHistory} "Sell histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /sell-history [get]
 func GetSellHistories(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch sell histories")
+    return
+  }
+
   var sellHistories []models.SellHistory
-  query := config.DB.Preload("Card").Preload("Seller")
+  query := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Seller", models.WithDeleted).Preload("Station", models.WithDeleted)
 
   // Apply filters
   if cardID := c.Query("card_id"); cardID != "" {
@@ -35,6 +43,9 @@ func GetSellHistories(c *gin.Context) {
   if sellerID := c.Query("seller_id"); sellerID != "" {
     query = query.Where("seller_id = ?", sellerID)
   }
+  if stationID := c.Query("station_id"); stationID != "" {
+    query = query.Where("station_id = ?", stationID)
+  }
 
   // Pagination
   page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -55,15 +66,22 @@ func GetSellHistories(c *gin.Context) {
 // @Tags sell-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Sell History ID"
 // @Success 200 {object} utils.Response{data=models.SellHistory} "Sell history retrieved successfully"
 // @Failure 404 {object} utils.Response "Sell history not found"
 // @Router /sell-history/{id} [get]
 func GetSellHistoryByID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch sell histories")
+    return
+  }
+
   id := c.Param("id")
   var sellHistory models.SellHistory
 
-  if err := config.DB.Preload("Card").Preload("Seller").First(&sellHistory, id).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Seller", models.WithDeleted).Preload("Station", models.WithDeleted).First(&sellHistory, id).Error; err != nil {
     utils.NotFound(c, "sell history not found")
     return
   }
@@ -77,15 +95,22 @@ func GetSellHistoryByID(c *gin.Context) {
 // @Tags sell-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param card_id path string true "Card ID"
 // @Success 200 {object} utils.Response{data=[]models.SellHistory} "Sell histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /sell-history/card/{card_id} [get]
 func GetSellHistoriesByCardID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch sell histories")
+    return
+  }
+
   cardID := c.Param("card_id")
   var sellHistories []models.SellHistory
 
-  if err := config.DB.Preload("Card").Preload("Seller").Where("card_id = ?", cardID).Find(&sellHistories).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Seller", models.WithDeleted).Preload("Station", models.WithDeleted).Where("card_id = ?", cardID).Find(&sellHistories).Error; err != nil {
     utils.InternalServerError(c, "failed to fetch sell histories")
     return
   }
@@ -99,15 +124,22 @@ func GetSellHistoriesByCardID(c *gin.Context) {
 // @Tags sell-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param seller_id path string true "Seller ID"
 // @Success 200 {object} utils.Response{data=[]models.SellHistory} "Sell histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /sell-history/seller/{seller_id} [get]
 func GetSellHistoriesBySellerID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch sell histories")
+    return
+  }
+
   sellerID := c.Param("seller_id")
   var sellHistories []models.SellHistory
 
-  if err := config.DB.Preload("Card").Preload("Seller").Where("seller_id = ?", sellerID).Find(&sellHistories).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Seller", models.WithDeleted).Preload("Station", models.WithDeleted).Where("seller_id = ?", sellerID).Find(&sellHistories).Error; err != nil {
     utils.InternalServerError(c, "failed to fetch sell histories")
     return
   }
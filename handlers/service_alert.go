@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ServiceAlertReq struct for creating and updating a service alert
+type ServiceAlertReq struct {
+	Severity      string     `json:"severity" binding:"required,oneof=info warning severe"`
+	Effect        string     `json:"effect" binding:"required,oneof=delay station_closure line_closure station_outage other"`
+	HeaderVi      string     `json:"header_vi" binding:"required"`
+	HeaderEn      string     `json:"header_en"`
+	DescriptionVi string     `json:"description_vi"`
+	DescriptionEn string     `json:"description_en"`
+	ActiveFrom    *time.Time `json:"active_from"` // mặc định là thời điểm tạo
+	ActiveUntil   *time.Time `json:"active_until"`
+	LineIDs       []uint     `json:"line_ids"`
+	StationIDs    []uint     `json:"station_ids"`
+	TripIDs       []uint     `json:"trip_ids"`
+}
+
+// buildServiceAlert validates the request and fills the alert with the affected entities
+func buildServiceAlert(request ServiceAlertReq, alert *models.ServiceAlert) error {
+	if len(request.LineIDs) == 0 && len(request.StationIDs) == 0 && len(request.TripIDs) == 0 {
+		return fmt.Errorf("at least one of line_ids, station_ids or trip_ids is required")
+	}
+
+	alert.Severity = consts.AlertSeverity(request.Severity)
+	alert.Effect = consts.AlertEffect(request.Effect)
+	alert.HeaderVi = request.HeaderVi
+	alert.HeaderEn = request.HeaderEn
+	alert.DescriptionVi = request.DescriptionVi
+	alert.DescriptionEn = request.DescriptionEn
+	alert.ActiveUntil = request.ActiveUntil
+	if request.ActiveFrom != nil {
+		alert.ActiveFrom = *request.ActiveFrom
+	} else if alert.ActiveFrom.IsZero() {
+		alert.ActiveFrom = time.Now()
+	}
+	if alert.ActiveUntil != nil && !alert.ActiveUntil.After(alert.ActiveFrom) {
+		return fmt.Errorf("active_until must be after active_from")
+	}
+
+	alert.Lines, alert.Stations, alert.Trips = nil, nil, nil
+	if len(request.LineIDs) > 0 {
+		if err := config.DB.Find(&alert.Lines, request.LineIDs).Error; err != nil || len(alert.Lines) != len(request.LineIDs) {
+			return fmt.Errorf("one or more lines not found")
+		}
+	}
+	if len(request.StationIDs) > 0 {
+		if err := config.DB.Find(&alert.Stations, request.StationIDs).Error; err != nil || len(alert.Stations) != len(request.StationIDs) {
+			return fmt.Errorf("one or more stations not found")
+		}
+	}
+	if len(request.TripIDs) > 0 {
+		if err := config.DB.Find(&alert.Trips, request.TripIDs).Error; err != nil || len(alert.Trips) != len(request.TripIDs) {
+			return fmt.Errorf("one or more trips not found")
+		}
+	}
+
+	return nil
+}
+
+// CreateServiceAlert handles POST /admin/alerts
+// @Summary Create a service alert (Admin only)
+// @Description Announce a closure, delay or station outage affecting lines, stations or trips
+// @Tags alert
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param alert body ServiceAlertReq true "Service alert information"
+// @Success 201 {object} utils.Response{data=models.ServiceAlert} "Service alert created successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/alerts [post]
+func CreateServiceAlert(c *gin.Context) {
+	var request ServiceAlertReq
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var alert models.ServiceAlert
+	if err := buildServiceAlert(request, &alert); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	alert.CreatedBy, _ = userID.(uint)
+
+	if err := utils.RequestDB(c).Omit("Lines.*", "Stations.*", "Trips.*").Create(&alert).Error; err != nil {
+		utils.InternalServerError(c, "failed to create service alert")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(alert.ID), 10))
+	utils.AuditAfter(c, alert)
+
+	utils.AfterCommit(c, func() { utils.BroadcastAlert(alert) })
+	utils.SuccessResponse(c, http.StatusCreated, "service alert created successfully", alert)
+}
+
+// UpdateServiceAlert handles PUT /admin/alerts/:id
+// @Summary Update a service alert (Admin only)
+// @Description Update an unresolved service alert and its affected lines, stations and trips
+// @Tags alert
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service alert ID"
+// @Param alert body ServiceAlertReq true "Updated service alert information"
+// @Success 200 {object} utils.Response{data=models.ServiceAlert} "Service alert updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Service alert not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/alerts/{id} [put]
+func UpdateServiceAlert(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var alert models.ServiceAlert
+
+	if err := db.Preload("Lines").Preload("Stations").Preload("Trips").First(&alert, id).Error; err != nil {
+		utils.NotFound(c, "service alert not found")
+		return
+	}
+	if alert.ResolvedAt != nil {
+		utils.BadRequest(c, "service alert is already resolved")
+		return
+	}
+
+	var request ServiceAlertReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	// Các trạm bị ảnh hưởng trước khi cập nhật cũng cần làm mới bảng giờ tàu
+	previousStations, _ := utils.AlertStationIDs(alert)
+	utils.AuditBefore(c, alert)
+
+	if err := buildServiceAlert(request, &alert); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines", "Stations", "Trips").Save(&alert).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&alert).Omit("Lines.*").Association("Lines").Replace(alert.Lines); err != nil {
+			return err
+		}
+		if err := tx.Model(&alert).Omit("Stations.*").Association("Stations").Replace(alert.Stations); err != nil {
+			return err
+		}
+		return tx.Model(&alert).Omit("Trips.*").Association("Trips").Replace(alert.Trips)
+	})
+	if err != nil {
+		utils.InternalServerError(c, "failed to update service alert")
+		return
+	}
+	utils.AuditAfter(c, alert)
+
+	utils.AfterCommit(c, func() {
+		utils.Boards.MarkDirty(previousStations...)
+		utils.BroadcastAlert(alert)
+	})
+	utils.SuccessResponse(c, http.StatusOK, "service alert updated successfully", alert)
+}
+
+// ResolveServiceAlert handles POST /admin/alerts/:id/resolve
+// @Summary Resolve a service alert (Admin only)
+// @Description Mark a service alert as resolved; it stops being shown and closed stations reopen
+// @Tags alert
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service alert ID"
+// @Success 200 {object} utils.Response{data=models.ServiceAlert} "Service alert resolved successfully"
+// @Failure 400 {object} utils.Response "Service alert already resolved"
+// @Failure 404 {object} utils.Response "Service alert not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/alerts/{id}/resolve [post]
+func ResolveServiceAlert(c *gin.Context) {
+	id := c.Param("id")
+	db := utils.RequestDB(c)
+	var alert models.ServiceAlert
+
+	if err := db.Preload("Lines").Preload("Stations").Preload("Trips").First(&alert, id).Error; err != nil {
+		utils.NotFound(c, "service alert not found")
+		return
+	}
+	if alert.ResolvedAt != nil {
+		utils.BadRequest(c, "service alert is already resolved")
+		return
+	}
+	utils.AuditBefore(c, alert)
+
+	now := time.Now()
+	userID, _ := c.Get("user_id")
+	resolvedBy, _ := userID.(uint)
+	alert.ResolvedAt = &now
+	alert.ResolvedBy = &resolvedBy
+
+	if err := db.Model(&alert).Select("ResolvedAt", "ResolvedBy").Updates(&alert).Error; err != nil {
+		utils.InternalServerError(c, "failed to resolve service alert")
+		return
+	}
+	utils.AuditAfter(c, alert)
+
+	utils.AfterCommit(c, func() { utils.BroadcastAlert(alert) })
+	utils.SuccessResponse(c, http.StatusOK, "service alert resolved successfully", alert)
+}
+
+// GetServiceAlerts handles GET /alerts
+// @Summary Get service alerts
+// @Description Retrieve service alerts, by default only those active now
+// @Tags alert
+// @Accept json
+// @Produce json
+// @Param status query string false "active (default), resolved or all" Enums(active, resolved, all)
+// @Param station_id query int false "Only alerts affecting this station (directly or via its lines and trips)"
+// @Param line_id query int false "Only alerts affecting this line"
+// @Param trip_id query int false "Only alerts affecting this trip"
+// @Param severity query string false "Filter by severity" Enums(info, warning, severe)
+// @Success 200 {object} utils.Response{data=[]models.ServiceAlert} "Service alerts retrieved successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /alerts [get]
+func GetServiceAlerts(c *gin.Context) {
+	alerts := []models.ServiceAlert{}
+	query := config.DB.Preload("Lines").Preload("Stations").Preload("Trips")
+
+	switch c.DefaultQuery("status", "active") {
+	case "active":
+		query = query.Scopes(utils.ActiveAlerts(time.Now()))
+	case "resolved":
+		query = query.Where("resolved_at IS NOT NULL")
+	case "all":
+	default:
+		utils.BadRequest(c, "status must be active, resolved or all")
+		return
+	}
+
+	if stationID := c.Query("station_id"); stationID != "" {
+		id, err := strconv.ParseUint(stationID, 10, 64)
+		if err != nil {
+			utils.BadRequest(c, "invalid station_id")
+			return
+		}
+		query = query.Scopes(utils.AlertsForStation(uint(id)))
+	}
+	if lineID := c.Query("line_id"); lineID != "" {
+		query = query.Where("id IN (SELECT service_alert_id FROM service_alert_lines WHERE line_id = ?)", lineID)
+	}
+	if tripID := c.Query("trip_id"); tripID != "" {
+		query = query.Where("id IN (SELECT service_alert_id FROM service_alert_trips WHERE trip_id = ?)", tripID)
+	}
+	if severity := c.Query("severity"); severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+
+	if err := query.Order("active_from DESC").Find(&alerts).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch service alerts")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "service alerts retrieved successfully", alerts)
+}
+
+// GetServiceAlertByID handles GET /alerts/:id
+// @Summary Get service alert by ID
+// @Description Retrieve a specific service alert by its ID
+// @Tags alert
+// @Accept json
+// @Produce json
+// @Param id path int true "Service alert ID"
+// @Success 200 {object} utils.Response{data=models.ServiceAlert} "Service alert retrieved successfully"
+// @Failure 404 {object} utils.Response "Service alert not found"
+// @Router /alerts/{id} [get]
+func GetServiceAlertByID(c *gin.Context) {
+	id := c.Param("id")
+	var alert models.ServiceAlert
+
+	if err := config.DB.Preload("Lines").Preload("Stations").Preload("Trips").First(&alert, id).Error; err != nil {
+		utils.NotFound(c, "service alert not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "service alert retrieved successfully", alert)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errRestoreConflict báo bản ghi không khôi phục được vì khóa duy nhất đã bị bản ghi khác chiếm
+var errRestoreConflict = errors.New("restore conflict")
+
+// listDeleted trả về các bản ghi đã xóa mềm của một bảng, mới xóa nhất trước
+func listDeleted(c *gin.Context, records interface{}, message string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Offset(offset).Limit(limit).Find(records).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch deleted records")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, records)
+}
+
+// restoreDeleted khôi phục một bản ghi đã xóa mềm. conflict (có thể nil) kiểm tra trong transaction
+// rằng khóa duy nhất của bản ghi chưa bị bản ghi đang hoạt động khác dùng.
+func restoreDeleted(c *gin.Context, record interface{}, conflict func(tx *gorm.DB) error) bool {
+	err := utils.RequestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(record, c.Param("id")).Error; err != nil {
+			return err
+		}
+		utils.AuditBefore(c, record)
+
+		if conflict != nil {
+			if err := conflict(tx); err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(record).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.First(record, c.Param("id")).Error
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFound(c, "deleted record not found")
+		return false
+	case errors.Is(err, errRestoreConflict):
+		utils.ErrorResponse(c, http.StatusConflict, "an active record with the same unique key already exists")
+		return false
+	case err != nil:
+		utils.InternalServerError(c, "failed to restore record")
+		return false
+	}
+
+	utils.AuditAfter(c, record)
+	return true
+}
+
+// GetDeletedUsers handles GET /admin/deleted/users
+// @Summary List deleted users
+// @Description Soft-deleted users that can still be restored, most recently deleted first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.User} "Deleted users retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/users [get]
+func GetDeletedUsers(c *gin.Context) {
+	listDeleted(c, &[]models.User{}, "deleted users retrieved successfully")
+}
+
+// RestoreUser handles POST /admin/deleted/users/:id/restore
+// @Summary Restore deleted user
+// @Description Restore a soft-deleted user. Fails if another active user has taken the same email.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response{data=models.User} "User restored"
+// @Failure 404 {object} utils.Response "Deleted user not found"
+// @Failure 409 {object} utils.Response "Email already in use"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/users/{id}/restore [post]
+func RestoreUser(c *gin.Context) {
+	var user models.User
+	restored := restoreDeleted(c, &user, func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errRestoreConflict
+		}
+		return nil
+	})
+	if !restored {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "user restored successfully", user)
+}
+
+// GetDeletedCards handles GET /admin/deleted/cards
+// @Summary List deleted cards
+// @Description Soft-deleted cards that can still be restored, most recently deleted first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Card} "Deleted cards retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/cards [get]
+func GetDeletedCards(c *gin.Context) {
+	listDeleted(c, &[]models.Card{}, "deleted cards retrieved successfully")
+}
+
+// RestoreCard handles POST /admin/deleted/cards/:id/restore
+// @Summary Restore deleted card
+// @Description Restore a soft-deleted card. A blocked card goes back on the gate hotlist.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Card ID"
+// @Success 200 {object} utils.Response{data=models.Card} "Card restored"
+// @Failure 404 {object} utils.Response "Deleted card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/cards/{id}/restore [post]
+func RestoreCard(c *gin.Context) {
+	var card models.Card
+	// RFID duy nhất kể cả giữa thẻ đã xóa nên khôi phục không thể trùng với thẻ khác
+	if !restoreDeleted(c, &card, nil) {
+		return
+	}
+
+	// Thẻ bị khóa được đưa lại vào hotlist ở lần đối chiếu kế tiếp
+	utils.AfterCommit(c, utils.Hotlists.Wake)
+
+	utils.SuccessResponse(c, http.StatusOK, "card restored successfully", card)
+}
+
+// GetDeletedStations handles GET /admin/deleted/stations
+// @Summary List deleted stations
+// @Description Soft-deleted stations that can still be restored, most recently deleted first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Station} "Deleted stations retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/stations [get]
+func GetDeletedStations(c *gin.Context) {
+	listDeleted(c, &[]models.Station{}, "deleted stations retrieved successfully")
+}
+
+// RestoreStation handles POST /admin/deleted/stations/:id/restore
+// @Summary Restore deleted station
+// @Description Restore a soft-deleted station
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Station ID"
+// @Success 200 {object} utils.Response{data=models.Station} "Station restored"
+// @Failure 404 {object} utils.Response "Deleted station not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/stations/{id}/restore [post]
+func RestoreStation(c *gin.Context) {
+	var station models.Station
+	if !restoreDeleted(c, &station, nil) {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "station restored successfully", station)
+}
+
+// GetDeletedTrains handles GET /admin/deleted/trains
+// @Summary List deleted trains
+// @Description Soft-deleted trains that can still be restored, most recently deleted first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.Train} "Deleted trains retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/trains [get]
+func GetDeletedTrains(c *gin.Context) {
+	listDeleted(c, &[]models.Train{}, "deleted trains retrieved successfully")
+}
+
+// RestoreTrain handles POST /admin/deleted/trains/:id/restore
+// @Summary Restore deleted train
+// @Description Restore a soft-deleted train
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Train ID"
+// @Success 200 {object} utils.Response{data=models.Train} "Train restored"
+// @Failure 404 {object} utils.Response "Deleted train not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/deleted/trains/{id}/restore [post]
+func RestoreTrain(c *gin.Context) {
+	var train models.Train
+	if !restoreDeleted(c, &train, nil) {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "train restored successfully", train)
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+  "errors"
   "net/http"
+  "strconv"
   "strings"
+  "time"
 
   "go-metro/config"
+  "go-metro/consts"
   "go-metro/models"
   "go-metro/utils"
 
@@ -13,8 +17,11 @@ import (
 
 // StationReq struct for creating station
 type StationReq struct {
-  Name      string `json:"name" binding:"required"`
-  IPAddress string `json:"ip_address"`
+  Name      string  `json:"name" binding:"required"`
+  IPAddress string  `json:"ip_address"`
+  Latitude  float64 `json:"latitude" binding:"omitempty,latitude"`
+  Longitude float64 `json:"longitude" binding:"omitempty,longitude"`
+  Status    string  `json:"status" binding:"omitempty,oneof=active inactive maintenance closed"`
 }
 
 // CreateStation handles POST /station
@@ -23,6 +30,7 @@ type StationReq struct {
 // @Tags station
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param station body StationReq true "Station information"
 // @Success 200 {object} utils.Response{data=models.Station} "Station created successfully"
 // @Failure 400 {object} utils.Response "Bad request - validation error"
@@ -39,19 +47,27 @@ func CreateStation(c *gin.Context) {
   station := models.Station{
     Name:      stationRequest.Name,
     IPAddress: stationRequest.IPAddress,
+    Latitude:  stationRequest.Latitude,
+    Longitude: stationRequest.Longitude,
+    Status:    string(consts.StationActive),
+  }
+  if stationRequest.Status != "" {
+    station.Status = stationRequest.Status
   }
 
-  if err := config.DB.Create(&station).Error; err != nil {
+  if err := utils.RequestDB(c).Create(&station).Error; err != nil {
     utils.InternalServerError(c, "failed to create station")
     return
   }
+  utils.AuditTarget(c, strconv.FormatUint(uint64(station.ID), 10))
+  utils.AuditAfter(c, station)
 
   utils.SuccessResponse(c, http.StatusCreated, "station created successfully", station)
 }
 
 // StationQueryParams defines query parameters for station filtering
 type StationQueryParams struct {
-  Status string `form:"status" binding:"omitempty,oneof=active inactive maintenance" json:"status"`
+  Status string `form:"status" binding:"omitempty,oneof=active inactive maintenance closed" json:"status"`
   Name   string `form:"name" binding:"omitempty,max=100" json:"name"`
 }
 
@@ -61,7 +77,7 @@ type StationQueryParams struct {
 // @Tags station
 // @Accept json
 // @Produce json
-// @Param status query string false "Filter by status" Enums(active, inactive, maintenance)
+// @Param status query string false "Filter by status" Enums(active, inactive, maintenance, closed)
 // @Param name query string false "Search by station name (partial match, case-insensitive)"
 // @Router /station [get]
 func GetStations(c *gin.Context) {
@@ -96,6 +112,12 @@ func GetStations(c *gin.Context) {
     return
   }
 
+  // Đánh dấu các trạm đang đóng cửa (theo trạng thái hoặc thông báo đóng ga)
+  if err := utils.FlagClosedStations(stations); err != nil {
+    utils.InternalServerError(c, "Lỗi khi lấy danh sách trạm")
+    return
+  }
+
   utils.SuccessResponse(c, http.StatusOK, "Lấy danh sách trạm thành công", stations)
 }
 
@@ -118,6 +140,11 @@ func GetStationByID(c *gin.Context) {
     return
   }
 
+  if _, err := utils.IsStationClosed(&station); err != nil {
+    utils.InternalServerError(c, "failed to fetch station")
+    return
+  }
+
   utils.SuccessResponse(c, http.StatusOK, "station retrieved successfully", station)
 }
 
@@ -127,6 +154,7 @@ func GetStationByID(c *gin.Context) {
 // @Tags station
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Station ID"
 // @Param station body StationReq true "Updated station information"
 // @Success 200 {object} utils.Response{data=models.Station} "Station updated successfully"
@@ -136,10 +164,11 @@ func GetStationByID(c *gin.Context) {
 // @Router /station/{id} [put]
 func UpdateStation(c *gin.Context) {
   id := c.Param("id")
+  db := utils.RequestDB(c)
   var station models.Station
 
   // Check if station exists
-  if err := config.DB.First(&station, id).Error; err != nil {
+  if err := db.First(&station, id).Error; err != nil {
     utils.NotFound(c, "station not found")
     return
   }
@@ -151,13 +180,21 @@ func UpdateStation(c *gin.Context) {
     return
   }
 
+  utils.AuditBefore(c, station)
+
   station.Name = updateData.Name
   station.IPAddress = updateData.IPAddress
+  station.Latitude = updateData.Latitude
+  station.Longitude = updateData.Longitude
+  if updateData.Status != "" {
+    station.Status = updateData.Status
+  }
 
-  if err := config.DB.Save(&station).Error; err != nil {
+  if err := db.Save(&station).Error; err != nil {
     utils.InternalServerError(c, "failed to update station")
     return
   }
+  utils.AuditAfter(c, station)
 
   utils.SuccessResponse(c, http.StatusOK, "station updated successfully", station)
 }
@@ -168,6 +205,7 @@ func UpdateStation(c *gin.Context) {
 // @Tags station
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Station ID"
 // @Success 200 {object} utils.Response "Station deleted successfully"
 // @Failure 404 {object} utils.Response "Station not found"
@@ -175,15 +213,18 @@ func UpdateStation(c *gin.Context) {
 // @Router /station/{id} [delete]
 func DeleteStation(c *gin.Context) {
   id := c.Param("id")
+  db := utils.RequestDB(c)
   var station models.Station
 
   // Check if station exists
-  if err := config.DB.First(&station, id).Error; err != nil {
+  if err := db.First(&station, id).Error; err != nil {
     utils.NotFound(c, "station not found")
     return
   }
 
-  if err := config.DB.Delete(&station).Error; err != nil {
+  utils.AuditBefore(c, station)
+
+  if err := db.Delete(&station).Error; err != nil {
     utils.InternalServerError(c, "failed to delete station")
     return
   }
@@ -191,6 +232,84 @@ func DeleteStation(c *gin.Context) {
   utils.SuccessResponse(c, http.StatusOK, "station deleted successfully", nil)
 }
 
+// GetStationDepartures handles GET /station/:id/departures
+// @Summary Get next departures from a station
+// @Description List the next scheduled departures from a station, based on trips generated from service patterns
+// @Tags station
+// @Accept json
+// @Produce json
+// @Param id path int true "Station ID"
+// @Param limit query int false "Number of departures (default: 10, max: 50)"
+// @Param from query string false "Start time in RFC3339 format (default: now)"
+// @Success 200 {object} utils.Response{data=[]models.Departure} "Departures retrieved successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 404 {object} utils.Response "Station not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /station/{id}/departures [get]
+func GetStationDepartures(c *gin.Context) {
+  id := c.Param("id")
+  var station models.Station
+
+  if err := config.DB.First(&station, id).Error; err != nil {
+    utils.NotFound(c, "station not found")
+    return
+  }
+
+  limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+  if limit < 1 || limit > 50 {
+    limit = 10
+  }
+
+  from := time.Now()
+  if fromStr := c.Query("from"); fromStr != "" {
+    parsed, err := time.Parse(time.RFC3339, fromStr)
+    if err != nil {
+      utils.BadRequest(c, "from must be in RFC3339 format")
+      return
+    }
+    from = parsed
+  }
+
+  departures, err := utils.GetNextDepartures(station.ID, from, limit)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch departures")
+    return
+  }
+
+  utils.SuccessResponse(c, http.StatusOK, "departures retrieved successfully", departures)
+}
+
+// gateDevice lấy thiết bị đã xác thực và kiểm tra đó là cổng soát vé của trạm
+func gateDevice(c *gin.Context, stationID uint) (models.Device, bool) {
+  value, _ := c.Get("device")
+  device, ok := value.(models.Device)
+  if !ok {
+    utils.ErrorResponse(c, http.StatusUnauthorized, "device authentication required")
+    return device, false
+  }
+  if device.StationID != stationID || device.Type != consts.GateDevice {
+    utils.ErrorResponse(c, http.StatusForbidden, "device is not a gate of this station")
+    return device, false
+  }
+  return device, true
+}
+
+// tapErrorResponse trả lỗi của lượt quẹt trực tuyến; action là "check-in" hoặc "check-out"
+func tapErrorResponse(c *gin.Context, err error, action string) {
+  switch {
+  case errors.Is(err, utils.ErrCardNotFound):
+    utils.NotFound(c, "card not found")
+  case errors.Is(err, utils.ErrCardBlocked):
+    utils.ErrorResponse(c, http.StatusForbidden, "card is blocked")
+  case errors.Is(err, utils.ErrCardExpired):
+    utils.ErrorResponse(c, http.StatusForbidden, "card is expired")
+  case errors.Is(err, utils.ErrInsufficientBalance):
+    utils.BadRequest(c, "insufficient balance for "+action)
+  default:
+    utils.InternalServerError(c, "failed to record "+action)
+  }
+}
+
 // CheckInRequest struct for check-in
 type CheckInRequest struct {
   CardID string `json:"card_id" binding:"required"`
@@ -202,10 +321,13 @@ type CheckInRequest struct {
 // @Tags station
 // @Accept json
 // @Produce json
+// @Security DeviceID && DeviceKey
 // @Param id path int true "Station ID"
 // @Param request body CheckInRequest true "Check-in information"
 // @Success 200 {object} utils.Response{data=models.StationHistory} "Check-in successful"
 // @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 403 {object} utils.Response "Device inactive, not a gate of this station, or card blocked/expired"
 // @Failure 404 {object} utils.Response "Station or card not found"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station/{id}/checkin [post]
@@ -225,37 +347,40 @@ func CheckIn(c *gin.Context) {
     return
   }
 
-  // Check if card exists
-  var card models.Card
-  if err := config.DB.Where("rf_id = ?", request.CardID).First(&card).Error; err != nil {
-    utils.NotFound(c, "card not found")
+  // Chỉ cổng soát vé đã đăng ký tại trạm này mới được ghi nhận lượt quẹt
+  device, ok := gateDevice(c, station.ID)
+  if !ok {
     return
   }
 
-  // Check if card has sufficient balance (minimum 5000 VND for check-in)
-  if card.Balance < 5000 {
-    utils.BadRequest(c, "insufficient balance for check-in")
+  // Trạm đóng cửa không nhận khách vào, nhưng vẫn cho khách đang đi ra
+  closed, err := utils.IsStationClosed(&station)
+  if err != nil {
+    utils.InternalServerError(c, "failed to check station status")
     return
   }
-
-  // Bắt đầu transaction
-  tx := config.DB.Begin()
-
-  // Tạo StationHistory log cho check-in
-  if err := utils.CreateStationHistoryLog("checkin", request.CardID, station.ID, 0); err != nil {
-    tx.Rollback()
-    utils.InternalServerError(c, "failed to create check-in history")
+  if closed {
+    utils.BadRequest(c, "station is closed")
     return
   }
 
-  // Commit transaction
-  tx.Commit()
+  outcome, err := utils.RecordTap(utils.Tap{
+    Action:    utils.TapCheckIn,
+    CardID:    request.CardID,
+    StationID: station.ID,
+    DeviceID:  &device.ID,
+  })
+  if err != nil {
+    tapErrorResponse(c, err, "check-in")
+    return
+  }
 
   utils.SuccessResponse(c, http.StatusOK, "check-in successful", gin.H{
     "card_id":    request.CardID,
     "station_id": stationID,
+    "device_id":  device.ID,
     "action":     "checkin",
-    "balance":    card.Balance,
+    "balance":    outcome.Card.Balance,
   })
 }
 
@@ -270,10 +395,13 @@ type CheckOutRequest struct {
 // @Tags station
 // @Accept json
 // @Produce json
+// @Security DeviceID && DeviceKey
 // @Param id path int true "Station ID"
 // @Param request body CheckOutRequest true "Check-out information"
 // @Success 200 {object} utils.Response{data=models.StationHistory} "Check-out successful"
 // @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Device not registered or invalid credentials"
+// @Failure 403 {object} utils.Response "Device inactive, not a gate of this station, or card blocked/expired"
 // @Failure 404 {object} utils.Response "Station or card not found"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station/{id}/checkout [post]
@@ -293,57 +421,30 @@ func CheckOut(c *gin.Context) {
     return
   }
 
-  // Check if card exists
-  var card models.Card
-  if err := config.DB.Where("rf_id = ?", request.CardID).First(&card).Error; err != nil {
-    utils.NotFound(c, "card not found")
-    return
-  }
-
-  // Calculate fare (fixed fare for demo: 5000 VND)
-  fare := 5000.0
-
-  // Check if card has sufficient balance
-  if card.Balance < fare {
-    utils.BadRequest(c, "insufficient balance for check-out")
+  // Chỉ cổng soát vé đã đăng ký tại trạm này mới được ghi nhận lượt quẹt
+  device, ok := gateDevice(c, station.ID)
+  if !ok {
     return
   }
 
-  // Bắt đầu transaction
-  tx := config.DB.Begin()
-
-  // Deduct fare from card balance
-  oldBalance := card.Balance
-  card.Balance -= fare
-  if err := tx.Save(&card).Error; err != nil {
-    tx.Rollback()
-    utils.InternalServerError(c, "failed to deduct fare")
-    return
-  }
-
-  // Tạo StationHistory log cho check-out
-  if err := utils.CreateStationHistoryLog("checkout", request.CardID, station.ID, fare); err != nil {
-    tx.Rollback()
-    utils.InternalServerError(c, "failed to create check-out history")
+  outcome, err := utils.RecordTap(utils.Tap{
+    Action:    utils.TapCheckOut,
+    CardID:    request.CardID,
+    StationID: station.ID,
+    DeviceID:  &device.ID,
+  })
+  if err != nil {
+    tapErrorResponse(c, err, "check-out")
     return
   }
 
-  // Tạo History log cho payment
-  //if err := utils.CreateCardPaymentHistory(request.CardID, card.Username, fare, card.Balance); err != nil {
-  //  tx.Rollback()
-  //  utils.InternalServerError(c, "failed to create payment history")
-  //  return
-  //}
-
-  // Commit transaction
-  tx.Commit()
-
   utils.SuccessResponse(c, http.StatusOK, "check-out successful", gin.H{
     "card_id":     request.CardID,
     "station_id":  stationID,
+    "device_id":   device.ID,
     "action":      "checkout",
-    "fare":        fare,
-    "old_balance": oldBalance,
-    "new_balance": card.Balance,
+    "fare":        outcome.Fare,
+    "old_balance": outcome.OldBalance,
+    "new_balance": outcome.Card.Balance,
   })
 }
@@ -13,10 +13,11 @@ import (
 
 // GetStationHistories handles GET /station-history
 // @Summary Get all station history records
-// @Description Retrieve all station history records with optional filtering
+// @Description Retrieve all station history records with optional filtering. Staff only see records at their assigned stations.
 // @Tags station-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param page query int false "Page number"
 // @Param limit query int false "Number of records per page"
 // @Param card_id query string false "Filter by card ID"
@@ -26,8 +27,14 @@ import (
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station-history [get]
 func GetStationHistories(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch station histories")
+    return
+  }
+
   var stationHistories []models.StationHistory
-  query := config.DB.Preload("Card").Preload("Station")
+  query := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted)
 
   // Apply filters
   if cardID := c.Query("card_id"); cardID != "" {
@@ -59,15 +66,22 @@ func GetStationHistories(c *gin.Context) {
 // @Tags station-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Station History ID"
 // @Success 200 {object} utils.Response{data=models.StationHistory} "Station history retrieved successfully"
 // @Failure 404 {object} utils.Response "Station history not found"
 // @Router /station-history/{id} [get]
 func GetStationHistoryByID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch station histories")
+    return
+  }
+
   id := c.Param("id")
   var stationHistory models.StationHistory
 
-  if err := config.DB.Preload("Card").Preload("Station").First(&stationHistory, id).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).First(&stationHistory, id).Error; err != nil {
     utils.NotFound(c, "station history not found")
     return
   }
@@ -81,15 +95,22 @@ func GetStationHistoryByID(c *gin.Context) {
 // @Tags station-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param card_id path string true "Card ID"
 // @Success 200 {object} utils.Response{data=[]models.StationHistory} "Station histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station-history/card/{card_id} [get]
 func GetStationHistoriesByCardID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch station histories")
+    return
+  }
+
   cardID := c.Param("card_id")
   var stationHistories []models.StationHistory
 
-  if err := config.DB.Preload("Card").Preload("Station").Where("card_id = ?", cardID).Order("time DESC").Find(&stationHistories).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).Where("card_id = ?", cardID).Order("time DESC").Find(&stationHistories).Error; err != nil {
     utils.InternalServerError(c, "failed to fetch station histories")
     return
   }
@@ -103,15 +124,34 @@ func GetStationHistoriesByCardID(c *gin.Context) {
 // @Tags station-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param station_id path int true "Station ID"
 // @Success 200 {object} utils.Response{data=[]models.StationHistory} "Station histories retrieved successfully"
+// @Failure 403 {object} utils.Response "Staff is not assigned to this station"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station-history/station/{station_id} [get]
 func GetStationHistoriesByStationID(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch station histories")
+    return
+  }
+
   stationID := c.Param("station_id")
   var stationHistories []models.StationHistory
 
-  if err := config.DB.Preload("Card").Preload("Station").Where("station_id = ?", stationID).Order("time DESC").Find(&stationHistories).Error; err != nil {
+  // Nhân viên chỉ xem được lịch sử tại trạm mình được phân công
+  id, err := strconv.ParseUint(stationID, 10, 64)
+  if err != nil {
+    utils.BadRequest(c, "invalid station_id")
+    return
+  }
+  if !scope.Allows(uint(id)) {
+    utils.ErrorResponse(c, http.StatusForbidden, "you are not assigned to this station")
+    return
+  }
+
+  if err := config.DB.Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).Where("station_id = ?", stationID).Order("time DESC").Find(&stationHistories).Error; err != nil {
     utils.InternalServerError(c, "failed to fetch station histories")
     return
   }
@@ -125,15 +165,22 @@ func GetStationHistoriesByStationID(c *gin.Context) {
 // @Tags station-history
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param action path string true "Action (checkin/checkout)"
 // @Success 200 {object} utils.Response{data=[]models.StationHistory} "Station histories retrieved successfully"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /station-history/action/{action} [get]
 func GetStationHistoriesByAction(c *gin.Context) {
+  scope, err := utils.GetStationScope(c)
+  if err != nil {
+    utils.InternalServerError(c, "failed to fetch station histories")
+    return
+  }
+
   action := c.Param("action")
   var stationHistories []models.StationHistory
 
-  if err := config.DB.Preload("Card").Preload("Station").Where("action = ?", action).Order("time DESC").Find(&stationHistories).Error; err != nil {
+  if err := config.DB.Scopes(scope.Filter("station_id")).Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).Where("action = ?", action).Order("time DESC").Find(&stationHistories).Error; err != nil {
     utils.InternalServerError(c, "failed to fetch station histories")
     return
   }
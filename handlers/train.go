@@ -16,6 +16,7 @@ import (
 // @Tags train
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param train body models.Train true "Train information"
 // @Success 200 {object} utils.Response{data=models.Train} "Train created successfully"
 // @Failure 400 {object} utils.Response "Bad request - validation error"
@@ -29,10 +30,12 @@ func CreateTrain(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&train).Error; err != nil {
+	if err := utils.RequestDB(c).Create(&train).Error; err != nil {
 		utils.InternalServerError(c, "failed to create train")
 		return
 	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(train.ID), 10))
+	utils.AuditAfter(c, train)
 
 	utils.SuccessResponse(c, http.StatusCreated, "train created successfully", train)
 }
@@ -103,6 +106,7 @@ func GetTrainByID(c *gin.Context) {
 // @Tags train
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Train ID"
 // @Param train body models.Train true "Updated train information"
 // @Success 200 {object} utils.Response{data=models.Train} "Train updated successfully"
@@ -112,24 +116,28 @@ func GetTrainByID(c *gin.Context) {
 // @Router /train/{id} [put]
 func UpdateTrain(c *gin.Context) {
 	id := c.Param("id")
+	db := utils.RequestDB(c)
 	var train models.Train
 
 	// Check if record exists
-	if err := config.DB.First(&train, id).Error; err != nil {
+	if err := db.First(&train, id).Error; err != nil {
 		utils.NotFound(c, "train not found")
 		return
 	}
 
+	utils.AuditBefore(c, train)
+
 	// Bind update data
 	if err := c.ShouldBindJSON(&train); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
-	if err := config.DB.Save(&train).Error; err != nil {
+	if err := db.Save(&train).Error; err != nil {
 		utils.InternalServerError(c, "failed to update train")
 		return
 	}
+	utils.AuditAfter(c, train)
 
 	utils.SuccessResponse(c, http.StatusOK, "train updated successfully", train)
 }
@@ -140,6 +148,7 @@ func UpdateTrain(c *gin.Context) {
 // @Tags train
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Train ID"
 // @Success 200 {object} utils.Response "Train deleted successfully"
 // @Failure 404 {object} utils.Response "Train not found"
@@ -147,15 +156,18 @@ func UpdateTrain(c *gin.Context) {
 // @Router /train/{id} [delete]
 func DeleteTrain(c *gin.Context) {
 	id := c.Param("id")
+	db := utils.RequestDB(c)
 	var train models.Train
 
 	// Check if record exists
-	if err := config.DB.First(&train, id).Error; err != nil {
+	if err := db.First(&train, id).Error; err != nil {
 		utils.NotFound(c, "train not found")
 		return
 	}
 
-	if err := config.DB.Delete(&train).Error; err != nil {
+	utils.AuditBefore(c, train)
+
+	if err := db.Delete(&train).Error; err != nil {
 		utils.InternalServerError(c, "failed to delete train")
 		return
 	}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TransferReq struct for moving balance from one of my cards to another card
+type TransferReq struct {
+	FromCardID string  `json:"from_card_id" binding:"required"` // thẻ của tôi
+	ToCardID   string  `json:"to_card_id" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// StaffTransferReq struct for a balance transfer made at the counter
+type StaffTransferReq struct {
+	FromCardID string  `json:"from_card_id" binding:"required"`
+	ToCardID   string  `json:"to_card_id" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Override   bool    `json:"override"` // cho phép chuyển từ thẻ bị khóa hoặc hết hạn
+	Reason     string  `json:"reason"`   // bắt buộc khi override
+}
+
+// transferError trả lỗi của thao tác chuyển số dư
+func transferError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFound(c, "transfer not found")
+	case errors.Is(err, utils.ErrCardNotFound):
+		utils.NotFound(c, "Thẻ không tồn tại")
+	case errors.Is(err, utils.ErrTransferNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, utils.ErrTransferNeedsOverride):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, utils.ErrTransferSameCard), errors.Is(err, utils.ErrTransferTargetClosed),
+		errors.Is(err, utils.ErrTransferDailyLimit), errors.Is(err, utils.ErrInsufficientBalance),
+		errors.Is(err, utils.ErrCardNotRegistered), errors.Is(err, utils.ErrTransferOwnerMismatch):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, message)
+	}
+}
+
+// transferIDParam đọc ID lần chuyển trên URL
+func transferIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequest(c, "invalid transfer id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateMyTransfer handles POST /me/transfers
+// @Summary Transfer balance from my card
+// @Description Move balance from a card owned by the authenticated user to another card. Between two of my cards the transfer completes immediately; to someone else's card it stays pending until the recipient approves it. Blocked or expired source cards can only be transferred from by staff.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TransferReq true "Cards and amount"
+// @Success 200 {object} utils.Response{data=models.BalanceTransfer} "Transfer completed"
+// @Success 202 {object} utils.Response{data=models.BalanceTransfer} "Transfer waiting for the recipient's approval"
+// @Failure 400 {object} utils.Response "Invalid transfer, insufficient balance or daily limit exceeded"
+// @Failure 403 {object} utils.Response "Source card is blocked or expired"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/transfers [post]
+func CreateMyTransfer(c *gin.Context) {
+	var request TransferReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	transfer, err := utils.RequestTransfer(currentUserID(c),
+		strings.TrimSpace(request.FromCardID), strings.TrimSpace(request.ToCardID), request.Amount)
+	if err != nil {
+		transferError(c, err, "failed to transfer balance")
+		return
+	}
+
+	if transfer.Status == consts.TransferPending {
+		utils.SuccessResponse(c, http.StatusAccepted, "transfer waiting for the recipient's approval", transfer)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "balance transferred successfully", transfer)
+}
+
+// GetMyTransfers handles GET /me/transfers
+// @Summary List my transfers
+// @Description Transfers sent from or received by the authenticated user's cards, newest first
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (pending, completed, rejected, cancelled)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.BalanceTransfer} "Transfers retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/transfers [get]
+func GetMyTransfers(c *gin.Context) {
+	userID := currentUserID(c)
+	myCards := config.DB.Model(&models.Card{}).Select("rf_id").Where("user_id = ?", userID)
+	query := config.DB.Where("requested_by = ? OR from_card_id IN (?) OR to_card_id IN (?)", userID, myCards, myCards)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	transfers := []models.BalanceTransfer{}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&transfers).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch transfers")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "transfers retrieved successfully", transfers)
+}
+
+// ApproveMyTransfer handles POST /me/transfers/:id/approve
+// @Summary Approve an incoming transfer
+// @Description Accept a pending transfer to a card owned by the authenticated user. Balance, card status and the daily limit are checked again before the money moves.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} utils.Response{data=models.BalanceTransfer} "Transfer completed"
+// @Failure 400 {object} utils.Response "Insufficient balance or daily limit exceeded"
+// @Failure 403 {object} utils.Response "Source card is blocked or expired"
+// @Failure 404 {object} utils.Response "Transfer not found"
+// @Failure 409 {object} utils.Response "Transfer is no longer pending"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/transfers/{id}/approve [post]
+func ApproveMyTransfer(c *gin.Context) {
+	id, ok := transferIDParam(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := utils.ApproveTransfer(id, currentUserID(c))
+	if err != nil {
+		transferError(c, err, "failed to approve transfer")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "balance transferred successfully", transfer)
+}
+
+// RejectMyTransfer handles POST /me/transfers/:id/reject
+// @Summary Reject an incoming transfer
+// @Description Decline a pending transfer to a card owned by the authenticated user
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} utils.Response{data=models.BalanceTransfer} "Transfer rejected"
+// @Failure 404 {object} utils.Response "Transfer not found"
+// @Failure 409 {object} utils.Response "Transfer is no longer pending"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/transfers/{id}/reject [post]
+func RejectMyTransfer(c *gin.Context) {
+	id, ok := transferIDParam(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := utils.RejectTransfer(id, currentUserID(c))
+	if err != nil {
+		transferError(c, err, "failed to reject transfer")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "transfer rejected", transfer)
+}
+
+// CancelMyTransfer handles POST /me/transfers/:id/cancel
+// @Summary Cancel my pending transfer
+// @Description Withdraw a transfer requested by the authenticated user before the recipient approves it
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} utils.Response{data=models.BalanceTransfer} "Transfer cancelled"
+// @Failure 404 {object} utils.Response "Transfer not found"
+// @Failure 409 {object} utils.Response "Transfer is no longer pending"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /me/transfers/{id}/cancel [post]
+func CancelMyTransfer(c *gin.Context) {
+	id, ok := transferIDParam(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := utils.CancelTransfer(id, currentUserID(c))
+	if err != nil {
+		transferError(c, err, "failed to cancel transfer")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "transfer cancelled", transfer)
+}
+
+// CreateStaffTransfer handles POST /card/transfers
+// @Summary Transfer balance between cards at the counter
+// @Description Move balance immediately between two cards of the same registered owner. Transferring from a blocked or expired card needs override with a reason, and only then may the destination belong to someone else. Transfers between different owners must be requested by the card holder and approved by the recipient.
+// @Tags card
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body StaffTransferReq true "Cards, amount and override"
+// @Success 200 {object} utils.Response{data=models.BalanceTransfer} "Transfer completed"
+// @Failure 400 {object} utils.Response "Invalid transfer, cards of different owners, insufficient balance or daily limit exceeded"
+// @Failure 403 {object} utils.Response "Source card is blocked or expired and no override was given"
+// @Failure 404 {object} utils.Response "Card not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /card/transfers [post]
+func CreateStaffTransfer(c *gin.Context) {
+	var request StaffTransferReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Override && request.Reason == "" {
+		utils.BadRequest(c, "reason is required when overriding a blocked or expired card")
+		return
+	}
+
+	transfer, err := utils.StaffTransfer(utils.RequestDB(c), currentUserID(c),
+		strings.TrimSpace(request.FromCardID), strings.TrimSpace(request.ToCardID),
+		request.Amount, request.Override, request.Reason)
+	if err != nil {
+		transferError(c, err, "failed to transfer balance")
+		return
+	}
+
+	utils.AuditTarget(c, strconv.FormatUint(uint64(transfer.ID), 10))
+	utils.AuditAfter(c, transfer)
+	utils.SuccessResponse(c, http.StatusOK, "balance transferred successfully", transfer)
+}
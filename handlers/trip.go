@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"go-metro/config"
+	"go-metro/consts"
 	"go-metro/models"
 	"go-metro/utils"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 // @Tags trip
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param trip body models.Trip true "Trip information"
 // @Success 200 {object} utils.Response{data=models.Trip} "Trip created successfully"
 // @Failure 400 {object} utils.Response "Bad request - validation error"
@@ -29,11 +32,14 @@ func CreateTrip(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&trip).Error; err != nil {
+	if err := utils.RequestDB(c).Create(&trip).Error; err != nil {
 		utils.InternalServerError(c, "failed to create trip")
 		return
 	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(trip.ID), 10))
+	utils.AuditAfter(c, trip)
 
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
 	utils.SuccessResponse(c, http.StatusCreated, "trip created successfully", trip)
 }
 
@@ -52,7 +58,7 @@ func CreateTrip(c *gin.Context) {
 // @Router /trip [get]
 func GetTrips(c *gin.Context) {
 	var trips []models.Trip
-	query := config.DB.Preload("Train")
+	query := config.DB.Preload("Train", models.WithDeleted)
 
 	// Apply filters
 	if trainID := c.Query("train_id"); trainID != "" {
@@ -89,7 +95,7 @@ func GetTripByID(c *gin.Context) {
 	id := c.Param("id")
 	var trip models.Trip
 
-	if err := config.DB.Preload("Train").First(&trip, id).Error; err != nil {
+	if err := config.DB.Preload("Train", models.WithDeleted).First(&trip, id).Error; err != nil {
 		utils.NotFound(c, "trip not found")
 		return
 	}
@@ -103,6 +109,7 @@ func GetTripByID(c *gin.Context) {
 // @Tags trip
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Trip ID"
 // @Param trip body models.Trip true "Updated trip information"
 // @Success 200 {object} utils.Response{data=models.Trip} "Trip updated successfully"
@@ -112,25 +119,30 @@ func GetTripByID(c *gin.Context) {
 // @Router /trip/{id} [put]
 func UpdateTrip(c *gin.Context) {
 	id := c.Param("id")
+	db := utils.RequestDB(c)
 	var trip models.Trip
 
 	// Check if record exists
-	if err := config.DB.First(&trip, id).Error; err != nil {
+	if err := db.First(&trip, id).Error; err != nil {
 		utils.NotFound(c, "trip not found")
 		return
 	}
 
+	utils.AuditBefore(c, trip)
+
 	// Bind update data
 	if err := c.ShouldBindJSON(&trip); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
-	if err := config.DB.Save(&trip).Error; err != nil {
+	if err := db.Save(&trip).Error; err != nil {
 		utils.InternalServerError(c, "failed to update trip")
 		return
 	}
+	utils.AuditAfter(c, trip)
 
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
 	utils.SuccessResponse(c, http.StatusOK, "trip updated successfully", trip)
 }
 
@@ -140,6 +152,7 @@ func UpdateTrip(c *gin.Context) {
 // @Tags trip
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Trip ID"
 // @Success 200 {object} utils.Response "Trip deleted successfully"
 // @Failure 404 {object} utils.Response "Trip not found"
@@ -147,19 +160,23 @@ func UpdateTrip(c *gin.Context) {
 // @Router /trip/{id} [delete]
 func DeleteTrip(c *gin.Context) {
 	id := c.Param("id")
+	db := utils.RequestDB(c)
 	var trip models.Trip
 
 	// Check if record exists
-	if err := config.DB.First(&trip, id).Error; err != nil {
+	if err := db.First(&trip, id).Error; err != nil {
 		utils.NotFound(c, "trip not found")
 		return
 	}
 
-	if err := config.DB.Delete(&trip).Error; err != nil {
+	utils.AuditBefore(c, trip)
+
+	if err := db.Delete(&trip).Error; err != nil {
 		utils.InternalServerError(c, "failed to delete trip")
 		return
 	}
 
+	utils.AfterCommit(c, utils.Boards.MarkAllDirty)
 	utils.SuccessResponse(c, http.StatusOK, "trip deleted successfully", nil)
 }
 
@@ -177,7 +194,7 @@ func GetTripsByTrainID(c *gin.Context) {
 	trainID := c.Param("train_id")
 	var trips []models.Trip
 
-	if err := config.DB.Preload("Train").Where("train_id = ?", trainID).Order("start_time DESC").Find(&trips).Error; err != nil {
+	if err := config.DB.Preload("Train", models.WithDeleted).Where("train_id = ?", trainID).Order("start_time DESC").Find(&trips).Error; err != nil {
 		utils.InternalServerError(c, "failed to fetch trips")
 		return
 	}
@@ -199,7 +216,7 @@ func GetTripsByDirection(c *gin.Context) {
 	direction := c.Param("direction")
 	var trips []models.Trip
 
-	if err := config.DB.Preload("Train").Where("direction = ?", direction).Order("start_time DESC").Find(&trips).Error; err != nil {
+	if err := config.DB.Preload("Train", models.WithDeleted).Where("direction = ?", direction).Order("start_time DESC").Find(&trips).Error; err != nil {
 		utils.InternalServerError(c, "failed to fetch trips")
 		return
 	}
@@ -209,7 +226,7 @@ func GetTripsByDirection(c *gin.Context) {
 
 // GetActiveTrips handles GET /trip/active
 // @Summary Get active trips
-// @Description Retrieve all currently active trips (where end_time is null or in the future)
+// @Description Retrieve all trips that are not completed or cancelled and whose end time, including the reported delay, is in the future
 // @Tags trip
 // @Accept json
 // @Produce json
@@ -219,10 +236,109 @@ func GetTripsByDirection(c *gin.Context) {
 func GetActiveTrips(c *gin.Context) {
 	var trips []models.Trip
 
-	if err := config.DB.Preload("Train").Where("end_time IS NULL OR end_time > NOW()").Order("start_time DESC").Find(&trips).Error; err != nil {
+	if err := config.DB.Preload("Train", models.WithDeleted).Preload("Progress").
+		Joins("LEFT JOIN trip_progresses ON trip_progresses.trip_id = trips.id").
+		Where("COALESCE(trip_progresses.status, 'scheduled') NOT IN ?", []string{string(consts.TripCompleted), string(consts.TripCancelled)}).
+		Where("trips.end_time IS NULL OR trips.end_time + COALESCE(trip_progresses.delay_seconds, 0) * INTERVAL '1 second' > NOW()").
+		Order("trips.start_time DESC").
+		Find(&trips).Error; err != nil {
 		utils.InternalServerError(c, "failed to fetch active trips")
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "active trips retrieved successfully", trips)
-} 
\ No newline at end of file
+}
+
+// TripPositionReq struct for a real-time position report
+type TripPositionReq struct {
+	StationID    uint   `json:"station_id" binding:"required_unless=Status cancelled"`
+	Status       string `json:"status" binding:"required,oneof=at_station in_transit completed cancelled"`
+	DelaySeconds *int   `json:"delay_seconds"` // bỏ trống để server tự tính theo lịch
+}
+
+// GetTripProgress handles GET /trip/:id/progress
+// @Summary Get trip progress
+// @Description Retrieve the live state of a trip with scheduled and predicted arrival times for each stop
+// @Tags trip
+// @Accept json
+// @Produce json
+// @Param id path int true "Trip ID"
+// @Success 200 {object} utils.Response{data=models.TripProgressDetail} "Trip progress retrieved successfully"
+// @Failure 404 {object} utils.Response "Trip not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /trip/{id}/progress [get]
+func GetTripProgress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.NotFound(c, "trip not found")
+		return
+	}
+
+	detail, err := utils.GetTripProgressDetail(uint(id))
+	if errors.Is(err, utils.ErrTripNotFound) {
+		utils.NotFound(c, "trip not found")
+		return
+	}
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch trip progress")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "trip progress retrieved successfully", detail)
+}
+
+// ReportTripPosition handles POST /trip/:id/position
+// @Summary Report trip position
+// @Description Report the current station, status and delay of a trip. If delay_seconds is omitted it is computed from the schedule.
+// @Tags trip
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Trip ID"
+// @Param request body TripPositionReq true "Position report"
+// @Success 200 {object} utils.Response{data=models.TripProgressDetail} "Trip position updated successfully"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Trip not found"
+// @Failure 409 {object} utils.Response "Trip already completed or cancelled"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /trip/{id}/position [post]
+func ReportTripPosition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.NotFound(c, "trip not found")
+		return
+	}
+
+	var request TripPositionReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	reporterID, _ := userID.(uint)
+
+	detail, err := utils.ReportTripPosition(uint(id), utils.TripPositionReport{
+		StationID:    request.StationID,
+		Status:       consts.TripStatus(request.Status),
+		DelaySeconds: request.DelaySeconds,
+		ReportedBy:   reporterID,
+	})
+	switch {
+	case errors.Is(err, utils.ErrTripNotFound):
+		utils.NotFound(c, "trip not found")
+		return
+	case errors.Is(err, utils.ErrStationNotOnTrip):
+		utils.BadRequest(c, err.Error())
+		return
+	case errors.Is(err, utils.ErrTripAlreadyClosed):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.InternalServerError(c, "failed to update trip position")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "trip position updated successfully", detail)
+}
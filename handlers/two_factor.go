@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TwoFactorLoginReq struct for the second step of a login
+type TwoFactorLoginReq struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // mã TOTP 6 số hoặc mã khôi phục
+}
+
+// PasswordConfirmReq struct for actions that re-check the password
+type PasswordConfirmReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorCodeReq struct for confirming with an authenticator code
+type TwoFactorCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorReq struct for turning two-factor authentication off
+type DisableTwoFactorReq struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // mã TOTP 6 số hoặc mã khôi phục
+}
+
+// TwoFactorStatusRes is the two-factor state of the current user
+type TwoFactorStatusRes struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	Required               bool       `json:"required"` // role bắt buộc bật 2FA
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupRes is the secret to add to an authenticator app
+type TwoFactorSetupRes struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://, hiển thị dưới dạng mã QR
+}
+
+// RecoveryCodesRes lists freshly generated recovery codes, shown only once
+type RecoveryCodesRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorError trả lỗi của các thao tác 2FA
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidTwoFactorCode),
+		errors.Is(err, utils.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, utils.ErrTwoFactorNotEnrolled),
+		errors.Is(err, utils.ErrTwoFactorNotEnabled):
+		utils.BadRequest(c, err.Error())
+	case errors.Is(err, utils.ErrTwoFactorRequired):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	default:
+		utils.InternalServerError(c, "failed to update two-factor authentication")
+	}
+}
+
+// currentUser lấy user đang đăng nhập và kiểm tra lại mật khẩu nếu password khác rỗng
+func currentUser(c *gin.Context, password string) (models.User, bool) {
+	userID, _ := c.Get("user_id")
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		utils.NotFound(c, "user not found")
+		return user, false
+	}
+	if password != "" {
+		if ok, _, err := utils.CheckPassword(password, user.Password); err != nil || !ok {
+			utils.BadRequest(c, "wrong password")
+			return user, false
+		}
+	}
+	return user, true
+}
+
+// twoFactorEvent ghi sự kiện 2FA vào nhật ký bảo mật
+func twoFactorEvent(c *gin.Context, event string, user models.User, actorID *uint) {
+	utils.LogSecurityEvent(models.SecurityEvent{
+		Event:     event,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		ActorID:   actorID,
+	})
+}
+
+// LoginTwoFactor handles POST /auth/login/2fa
+// @Summary Complete two-factor login
+// @Description Exchange the challenge_token from /auth/login and an authenticator code (or a recovery code) for an access token and a refresh token. A wrong code keeps the challenge valid until it expires and counts as a failed login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginReq true "Challenge token and code"
+// @Success 200 {object} utils.Response "Logged in"
+// @Failure 400 {object} utils.Response "Invalid or expired challenge, or wrong code"
+// @Failure 429 {object} utils.Response "Too many failed attempts; see Retry-After header"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var request TwoFactorLoginReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	client := clientInfo(c)
+	var user models.User
+	var attempt utils.LoginAttempt
+	usedRecovery := false
+
+	// Mã sai làm rollback transaction nên challenge vẫn dùng được cho tới khi hết hạn
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := utils.ConsumeUserToken(tx, request.ChallengeToken, models.TokenLoginChallenge)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return utils.ErrInvalidUserToken
+		}
+
+		attempt = utils.LoginAttempt{Email: user.Email, UserID: &user.ID, IPAddress: client.IPAddress, UserAgent: client.UserAgent}
+		if err := utils.CheckLoginAllowed(attempt); err != nil {
+			return err
+		}
+
+		usedRecovery, err = utils.VerifySecondFactor(tx, user.ID, request.Code)
+		return err
+	})
+	if err != nil {
+		var throttled *utils.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			loginGuardError(c, err)
+		case errors.Is(err, utils.ErrInvalidTwoFactorCode), errors.Is(err, utils.ErrTwoFactorNotEnrolled):
+			utils.RecordLoginFailure(attempt, "wrong two-factor code")
+			utils.BadRequest(c, utils.ErrInvalidTwoFactorCode.Error())
+		default:
+			userTokenError(c, err)
+		}
+		return
+	}
+
+	if user.Status != "active" {
+		utils.BadRequest(c, "Tài khoản bị khóa")
+		return
+	}
+	if usedRecovery {
+		twoFactorEvent(c, models.EventRecoveryCodeUsed, user, nil)
+	}
+
+	completeLogin(c, user, attempt, client)
+}
+
+// GetTwoFactorStatus handles GET /user/2fa
+// @Summary Get two-factor status
+// @Description Show whether two-factor authentication is enabled or required for the current user, and how many recovery codes are left
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=TwoFactorStatusRes} "Two-factor status"
+// @Failure 404 {object} utils.Response "User not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /user/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c, "")
+	if !ok {
+		return
+	}
+
+	remaining, err := utils.RemainingRecoveryCodes(user.ID)
+	if err != nil {
+		utils.InternalServerError(c, "failed to fetch two-factor status")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor status retrieved successfully", TwoFactorStatusRes{
+		Enabled:                user.TwoFactorEnabled(),
+		EnabledAt:              user.TwoFactorEnabledAt,
+		Required:               utils.TwoFactorRequired(user.Role),
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// SetupTwoFactor handles POST /user/2fa/setup
+// @Summary Start two-factor enrolment
+// @Description Generate a TOTP secret and provisioning URI after re-checking the password. Two-factor authentication is only turned on after /user/2fa/enable confirms a code.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PasswordConfirmReq true "Current password"
+// @Success 200 {object} utils.Response{data=TwoFactorSetupRes} "Secret generated"
+// @Failure 400 {object} utils.Response "Wrong password or already enabled"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /user/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	var request PasswordConfirmReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, ok := currentUser(c, request.Password)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := utils.BeginTOTPEnrollment(user)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "scan the provisioning URI and confirm with a code", TwoFactorSetupRes{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	})
+}
+
+// EnableTwoFactor handles POST /user/2fa/enable
+// @Summary Enable two-factor authentication
+// @Description Confirm the secret from /user/2fa/setup with a current code. Returns recovery codes, shown only once. Refresh the access token afterwards to lift the two-factor restriction.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeReq true "Authenticator code"
+// @Success 200 {object} utils.Response{data=RecoveryCodesRes} "Two-factor authentication enabled"
+// @Failure 400 {object} utils.Response "Wrong code, not set up or already enabled"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /user/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var request TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, ok := currentUser(c, "")
+	if !ok {
+		return
+	}
+
+	codes, err := utils.EnableTOTP(user.ID, request.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	twoFactorEvent(c, models.EventTwoFactorEnabled, user, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor authentication enabled, store the recovery codes safely", RecoveryCodesRes{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor handles POST /user/2fa/disable
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with the password and a current code or recovery code. Not allowed for roles that require it.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DisableTwoFactorReq true "Password and code"
+// @Success 200 {object} utils.Response "Two-factor authentication disabled"
+// @Failure 400 {object} utils.Response "Wrong password or code, or not enabled"
+// @Failure 403 {object} utils.Response "Two-factor authentication is required for this role"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /user/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var request DisableTwoFactorReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, ok := currentUser(c, request.Password)
+	if !ok {
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		twoFactorError(c, utils.ErrTwoFactorNotEnabled)
+		return
+	}
+	if utils.TwoFactorRequired(user.Role) {
+		twoFactorError(c, utils.ErrTwoFactorRequired)
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := utils.VerifySecondFactor(tx, user.ID, request.Code); err != nil {
+			return err
+		}
+		return utils.DisableTwoFactor(tx, user.ID)
+	})
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	twoFactorEvent(c, models.EventTwoFactorDisabled, user, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes handles POST /user/2fa/recovery-codes
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming with a current authenticator code. Old codes stop working.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeReq true "Authenticator code"
+// @Success 200 {object} utils.Response{data=RecoveryCodesRes} "Recovery codes regenerated"
+// @Failure 400 {object} utils.Response "Wrong code or not enabled"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /user/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var request TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, ok := currentUser(c, "")
+	if !ok {
+		return
+	}
+
+	codes, err := utils.RegenerateRecoveryCodes(user.ID, request.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	twoFactorEvent(c, models.EventRecoveryCodesRegenerated, user, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "recovery codes regenerated", RecoveryCodesRes{RecoveryCodes: codes})
+}
+
+// ResetUserTwoFactor handles DELETE /admin/users/:id/2fa
+// @Summary Reset user two-factor authentication
+// @Description Remove the TOTP secret and recovery codes of a user who lost their device, and sign out all their sessions. Users whose role requires two-factor authentication must enrol again on next login.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response "Two-factor authentication reset"
+// @Failure 400 {object} utils.Response "Not enabled"
+// @Failure 404 {object} utils.Response "User not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/users/{id}/2fa [delete]
+func ResetUserTwoFactor(c *gin.Context) {
+	db := utils.RequestDB(c)
+	var user models.User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "user not found")
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		twoFactorError(c, utils.ErrTwoFactorNotEnabled)
+		return
+	}
+	utils.AuditBefore(c, user)
+
+	// Gỡ 2FA và đăng xuất mọi phiên trong cùng transaction
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := utils.DisableTwoFactor(tx, user.ID); err != nil {
+			return err
+		}
+		return utils.RevokeUserSessions(tx, user.ID)
+	}); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	user.TwoFactorEnabledAt = nil
+	utils.AuditAfter(c, user)
+
+	actorID, _ := c.Get("user_id")
+	actor, _ := actorID.(uint)
+	twoFactorEvent(c, models.EventTwoFactorReset, user, &actor)
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor authentication reset", nil)
+}
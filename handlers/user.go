@@ -1,8 +1,12 @@
 package handlers
 
 import (
+  "errors"
+  "log"
+  "net/http"
   "strconv"
   "strings"
+  "time"
 
   "go-metro/config"
   "go-metro/consts"
@@ -13,7 +17,7 @@ import (
 )
 
 type RegisterReq struct {
-  Password string `json:"password" binding:"required,min=6"`
+  Password string `json:"password" binding:"required"` // độ mạnh kiểm tra theo PasswordPolicy
   Email    string `json:"email" binding:"required,email"`
   FullName string `json:"full_name" binding:"required"`
 }
@@ -23,10 +27,16 @@ type LoginReq struct {
   Email    string `json:"email" binding:"required,email"`
 }
 
+type RefreshTokenReq struct {
+  RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type LoginRes struct {
-  Email    string `json:"email"`
-  Role     string `json:"role"`
-  Username string `json:"username"`
+  Email                  string `json:"email"`
+  Role                   string `json:"role"`
+  Username               string `json:"username"`
+  EmailVerified          bool   `json:"email_verified"`            // false: tài khoản bị giới hạn cho tới khi xác minh email
+  TwoFactorSetupRequired bool   `json:"two_factor_setup_required"` // true: role bắt buộc 2FA, chưa dùng được quyền cho tới khi bật
 }
 
 // Bind update data
@@ -38,7 +48,7 @@ type UpdateInfoReq struct {
 // Bind request data
 type ChangePasswordReq struct {
   OldPassword string `json:"old_password" binding:"required"`
-  NewPassword string `json:"new_password" binding:"required,min=6"`
+  NewPassword string `json:"new_password" binding:"required"` // độ mạnh kiểm tra theo PasswordPolicy
 }
 
 type AdminUpdateInfoReq struct {
@@ -59,7 +69,7 @@ type UserStats struct {
 // Ok
 // Register handles POST /auth/register
 // @Summary Register a new user
-// @Description Register a new user account with username, password, email, and full name
+// @Description Register a new user account with username, password, email, and full name. A verification link is emailed; the account is restricted until the email is verified.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -76,6 +86,11 @@ func Register(c *gin.Context) {
     return
   }
 
+  if err := utils.LoadPasswordPolicy().Validate(request.Password); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
   // Check if username already exists
   var existingUser models.User
   //if err := config.DB.Where("username = ?", request.Username).First(&existingUser).Error; err == nil {
@@ -91,12 +106,18 @@ func Register(c *gin.Context) {
 
   //username := RandomUsername()
 
+  hashedPassword, err := utils.HashPassword(request.Password)
+  if err != nil {
+    utils.InternalServerError(c, "Lỗi khi tạo tài khoản")
+    return
+  }
+
   // Create new user
   user := models.User{
-    Password: utils.HashPassword(request.Password),
+    Password: hashedPassword,
     Email:    request.Email,
     FullName: request.FullName,
-    Role:     2, // Default role user
+    Role:     consts.UserRole, // Tài khoản tự đăng ký luôn là khách; admin cấp role khác qua PUT /admin/users/:id
     Status:   "active",
   }
 
@@ -105,7 +126,12 @@ func Register(c *gin.Context) {
     return
   }
 
-  utils.SuccessResponse(c, 201, "Đã tạo tài khoản thành công", gin.H{
+  // Tài khoản bị giới hạn cho tới khi xác minh email
+  if err := utils.SendVerificationEmail(user); err != nil {
+    log.Println("⚠️ Failed to send verification email:", err)
+  }
+
+  utils.SuccessResponse(c, 201, "Đã tạo tài khoản thành công, vui lòng kiểm tra email để xác minh tài khoản", gin.H{
     "user": user,
   })
 }
@@ -113,11 +139,14 @@ func Register(c *gin.Context) {
 // Ok
 // Login handles POST /auth/login
 // @Summary User login
-// @Description Authenticate user with email and password, returns JWT token
+// @Description Authenticate user with email and password, returns a short-lived JWT access token and a refresh token. If two-factor authentication is enabled, returns a challenge_token instead; complete the login at /auth/login/2fa. Repeated failures per account or per IP trigger exponential backoff and then a temporary lock.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginReq true "Login credentials"
+// @Success 200 {object} utils.Response "Logged in, or two-factor challenge issued"
+// @Failure 400 {object} utils.Response "Wrong email or password, or account disabled"
+// @Failure 429 {object} utils.Response "Too many failed attempts; see Retry-After header"
 // @Router /auth/login [post]
 func Login(c *gin.Context) {
   var request LoginReq
@@ -127,43 +156,178 @@ func Login(c *gin.Context) {
     return
   }
 
+  // Chặn thử tiếp khi tài khoản hoặc IP đang bị backoff/khóa tạm thời
+  client := clientInfo(c)
+  attempt := utils.LoginAttempt{Email: request.Email, IPAddress: client.IPAddress, UserAgent: client.UserAgent}
+  if err := utils.CheckLoginAllowed(attempt); err != nil {
+    loginGuardError(c, err)
+    return
+  }
+
   // Find user by username
   var user models.User
   if err := config.DB.Where("email = ?", request.Email).First(&user).Error; err != nil {
+    utils.RecordLoginFailure(attempt, "unknown email")
     utils.BadRequest(c, "Email không đúng")
     return
   }
+  attempt.UserID = &user.ID
 
   // Check password
-  if user.Password != utils.HashPassword(request.Password) {
+  ok, needsRehash, err := utils.CheckPassword(request.Password, user.Password)
+  if err != nil || !ok {
+    utils.RecordLoginFailure(attempt, "wrong password")
     utils.BadRequest(c, "Sai mật khẩu")
     return
   }
 
+  // Nâng cấp mật khẩu cũ (MD5 hoặc tham số yếu) sang thuật toán hiện hành
+  if needsRehash {
+    if hashedPassword, err := utils.HashPassword(request.Password); err == nil {
+      if err := config.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+        log.Println("⚠️ Failed to upgrade password hash:", err)
+      }
+    }
+  }
+
   // Check if user is active
   if user.Status != "active" {
     utils.BadRequest(c, "Tài khoản bị khóa")
     return
   }
 
-  // Generate token
-  token, err := utils.GenerateToken(user.ID, user.Email, int(user.Role))
+  // Đã bật 2FA: chưa cấp token, client gửi mã TOTP kèm challenge_token tới /auth/login/2fa
+  if user.TwoFactorEnabled() {
+    challenge, expiresAt, err := utils.IssueLoginChallenge(user.ID)
+    if err != nil {
+      utils.InternalServerError(c, "Lỗi trong quá trình đăng nhập")
+      return
+    }
+
+    utils.SuccessResponse(c, 200, "Nhập mã xác thực hai bước để hoàn tất đăng nhập", gin.H{
+      "two_factor_required": true,
+      "challenge_token":     challenge,
+      "expires_at":          expiresAt,
+    })
+    return
+  }
+
+  completeLogin(c, user, attempt, client)
+}
+
+// completeLogin xóa bộ đếm đăng nhập sai và cấp access token + refresh token
+func completeLogin(c *gin.Context, user models.User, attempt utils.LoginAttempt, client utils.ClientInfo) {
+  utils.RecordLoginSuccess(attempt)
+
+  // Generate access token + refresh token
+  tokens, err := utils.IssueTokenPair(user, client)
   if err != nil {
     utils.InternalServerError(c, "Lỗi trong quá trình tạo token ")
     return
   }
 
   login := LoginRes{
-    Email: user.Email,
-    Role:  user.Role.ToText(),
+    Email:                  user.Email,
+    Role:                   user.Role.ToText(),
+    EmailVerified:          user.EmailVerified(),
+    TwoFactorSetupRequired: utils.TwoFactorRequired(user.Role) && !user.TwoFactorEnabled(),
   }
 
   utils.SuccessResponse(c, 200, "Đã đăng nhập thành công!", gin.H{
-    "user":  login,
-    "token": token,
+    "user":   login,
+    "token":  tokens.AccessToken, // giữ cho client cũ, giống tokens.access_token
+    "tokens": tokens,
   })
 }
 
+// loginGuardError trả 429 kèm Retry-After khi tài khoản hoặc IP đang bị chặn đăng nhập
+func loginGuardError(c *gin.Context, err error) {
+  var throttled *utils.LoginThrottledError
+  if errors.As(err, &throttled) {
+    c.Header("Retry-After", strconv.Itoa(throttled.RetryAfter()))
+    utils.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+    return
+  }
+  utils.InternalServerError(c, "Lỗi trong quá trình đăng nhập")
+}
+
+// clientInfo lấy thông tin thiết bị để gắn vào phiên đăng nhập
+func clientInfo(c *gin.Context) utils.ClientInfo {
+  return utils.ClientInfo{
+    UserAgent: c.Request.UserAgent(),
+    IPAddress: c.ClientIP(),
+  }
+}
+
+// RefreshToken handles POST /auth/refresh
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access/refresh token pair. Each refresh token can be used once; reusing an old one revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenReq true "Refresh token"
+// @Success 200 {object} utils.Response{data=utils.TokenPair} "Token refreshed"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Invalid, expired or reused refresh token"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+  var request RefreshTokenReq
+
+  if err := c.ShouldBindJSON(&request); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
+  tokens, err := utils.RotateRefreshToken(request.RefreshToken, clientInfo(c))
+  if err != nil {
+    switch {
+    case errors.Is(err, utils.ErrInvalidRefreshToken),
+      errors.Is(err, utils.ErrRefreshTokenExpired),
+      errors.Is(err, utils.ErrRefreshTokenReused),
+      errors.Is(err, utils.ErrUserInactive):
+      utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+    default:
+      utils.InternalServerError(c, "Lỗi trong quá trình tạo token ")
+    }
+    return
+  }
+
+  utils.SuccessResponse(c, 200, "Đã làm mới token", tokens)
+}
+
+// Logout handles POST /auth/logout
+// @Summary Logout
+// @Description Revoke the session of the given refresh token. Access tokens already issued stay valid until they expire (ACCESS_TOKEN_TTL_MINUTES).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenReq true "Refresh token"
+// @Success 200 {object} utils.Response "Logged out"
+// @Failure 400 {object} utils.Response "Bad request - validation error"
+// @Failure 401 {object} utils.Response "Invalid refresh token"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+  var request RefreshTokenReq
+
+  if err := c.ShouldBindJSON(&request); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
+  if err := utils.RevokeRefreshToken(request.RefreshToken); err != nil {
+    if errors.Is(err, utils.ErrInvalidRefreshToken) {
+      utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+      return
+    }
+    utils.InternalServerError(c, "Lỗi khi đăng xuất")
+    return
+  }
+
+  utils.SuccessResponse(c, 200, "Đã đăng xuất", nil)
+}
+
 // Ok
 // GetProfile handles GET /user/profile
 // @Summary Get user profile
@@ -177,7 +341,7 @@ func GetProfile(c *gin.Context) {
   userID, _ := c.Get("user_id")
   var user models.User
 
-  if err := config.DB.First(&user, userID).Error; err != nil {
+  if err := config.DB.Preload("Stations").First(&user, userID).Error; err != nil {
     utils.NotFound(c, "Thông tin không tồn tại")
     return
   }
@@ -263,18 +427,33 @@ func ChangePassword(c *gin.Context) {
   }
 
   // Verify old password
-  if user.Password != utils.HashPassword(request.OldPassword) {
+  if ok, _, err := utils.CheckPassword(request.OldPassword, user.Password); err != nil || !ok {
     utils.BadRequest(c, "Mật khẩu cũ không đúng")
     return
   }
 
+  if err := utils.LoadPasswordPolicy().Validate(request.NewPassword); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
   // Update password
-  user.Password = utils.HashPassword(request.NewPassword)
+  hashedPassword, err := utils.HashPassword(request.NewPassword)
+  if err != nil {
+    utils.InternalServerError(c, "failed to change password")
+    return
+  }
+  user.Password = hashedPassword
   if err := config.DB.Save(&user).Error; err != nil {
     utils.InternalServerError(c, "failed to change password")
     return
   }
 
+  // Đăng xuất mọi phiên đang mở bằng mật khẩu cũ
+  if err := utils.RevokeUserSessions(config.DB, user.ID); err != nil {
+    log.Println("⚠️ Failed to revoke sessions after password change:", err)
+  }
+
   utils.SuccessResponse(c, 200, "Đổi mật khẩu thành công", nil)
 }
 
@@ -588,10 +767,11 @@ func GetUserByID(c *gin.Context) {
 // @Router /admin/users/{id} [put]
 func UpdateUser(c *gin.Context) {
   id := c.Param("id")
+  db := utils.RequestDB(c)
   var user models.User
 
   // Check if user exists
-  if err := config.DB.First(&user, id).Error; err != nil {
+  if err := db.First(&user, id).Error; err != nil {
     utils.NotFound(c, "Thông tin không tồn tại")
     return
   }
@@ -604,10 +784,12 @@ func UpdateUser(c *gin.Context) {
     return
   }
 
+  utils.AuditBefore(c, user)
+
   // Update user
   if request.Email != "" {
     var existingUser models.User
-    if err := config.DB.Where("email = ? AND id != ?", request.Email, id).First(&existingUser).Error; err == nil {
+    if err := db.Where("email = ? AND id != ?", request.Email, id).First(&existingUser).Error; err == nil {
       utils.BadRequest(c, "Email đã tồn tại")
       return
     }
@@ -615,7 +797,7 @@ func UpdateUser(c *gin.Context) {
   }
   if request.Username != "" {
     var existingUser models.User
-    if err := config.DB.Where("username = ? AND id != ?", request.Username, id).First(&existingUser).Error; err == nil {
+    if err := db.Where("username = ? AND id != ?", request.Username, id).First(&existingUser).Error; err == nil {
       utils.BadRequest(c, "Tên người dùng đã tồn tại")
       return
     }
@@ -637,6 +819,10 @@ func UpdateUser(c *gin.Context) {
       return
     }
 
+    if user.Role != roleValue {
+      now := time.Now()
+      user.RoleGrantedAt = &now
+    }
     user.Role = roleValue
   }
 
@@ -648,10 +834,11 @@ func UpdateUser(c *gin.Context) {
     user.Status = request.Status
   }
 
-  if err := config.DB.Save(&user).Error; err != nil {
+  if err := db.Save(&user).Error; err != nil {
     utils.InternalServerError(c, "failed to update user")
     return
   }
+  utils.AuditAfter(c, user)
 
   utils.SuccessResponse(c, 200, "Cập nhật thông tin người dùng thành công", user)
 }
@@ -673,17 +860,98 @@ func UpdateUser(c *gin.Context) {
 // @Router /admin/users/{id} [delete]
 func DeleteUser(c *gin.Context) {
   id := c.Param("id")
+  db := utils.RequestDB(c)
   var user models.User
 
-  if err := config.DB.First(&user, id).Error; err != nil {
+  if err := db.First(&user, id).Error; err != nil {
     utils.NotFound(c, "Thông tin không tồn tại")
     return
   }
 
-  if err := config.DB.Delete(&user).Error; err != nil {
+  utils.AuditBefore(c, user)
+
+  if err := db.Delete(&user).Error; err != nil {
     utils.InternalServerError(c, "failed to delete user")
     return
   }
 
   utils.SuccessResponse(c, 200, "Xóa thành công", nil)
 }
+
+// Bind station assignment data
+type UserStationsReq struct {
+  StationIDs []uint `json:"station_ids" binding:"required"`
+}
+
+// Admin: Get stations assigned to a staff member
+// @Summary Get assigned stations of a user (Admin only)
+// @Description Retrieve the stations a staff member is assigned to. Staff only sell cards and see history at these stations.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response{data=[]models.Station} "Stations retrieved successfully"
+// @Failure 404 {object} utils.Response "User not found"
+// @Router /admin/users/{id}/stations [get]
+func GetUserStations(c *gin.Context) {
+  id := c.Param("id")
+  var user models.User
+
+  if err := config.DB.Preload("Stations").First(&user, id).Error; err != nil {
+    utils.NotFound(c, "Thông tin không tồn tại")
+    return
+  }
+
+  utils.SuccessResponse(c, 200, "", user.Stations)
+}
+
+// Admin: Replace stations assigned to a staff member
+// @Summary Assign stations to a user (Admin only)
+// @Description Replace the list of stations a staff member is assigned to
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body UserStationsReq true "Assigned station IDs"
+// @Success 200 {object} utils.Response{data=[]models.Station} "Stations assigned successfully"
+// @Failure 400 {object} utils.Response "Bad request - station not found"
+// @Failure 404 {object} utils.Response "User not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/users/{id}/stations [put]
+func UpdateUserStations(c *gin.Context) {
+  id := c.Param("id")
+  db := utils.RequestDB(c)
+  var user models.User
+
+  if err := db.Preload("Stations").First(&user, id).Error; err != nil {
+    utils.NotFound(c, "Thông tin không tồn tại")
+    return
+  }
+
+  request := UserStationsReq{}
+  if err := c.ShouldBindJSON(&request); err != nil {
+    utils.BadRequest(c, err.Error())
+    return
+  }
+
+  stations := []models.Station{}
+  if len(request.StationIDs) > 0 {
+    if err := db.Find(&stations, request.StationIDs).Error; err != nil || len(stations) != len(request.StationIDs) {
+      utils.BadRequest(c, "Trạm không tồn tại")
+      return
+    }
+  }
+
+  utils.AuditBefore(c, user)
+
+  if err := db.Model(&user).Omit("Stations.*").Association("Stations").Replace(stations); err != nil {
+    utils.InternalServerError(c, "Lỗi khi phân công trạm")
+    return
+  }
+  user.Stations = stations
+  utils.AuditAfter(c, user)
+
+  utils.SuccessResponse(c, 200, "Phân công trạm thành công", stations)
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"go-metro/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscriptionReq struct for creating a webhook subscription
+type WebhookSubscriptionReq struct {
+	URL         string                   `json:"url" binding:"required"`
+	EventTypes  []consts.DomainEventType `json:"event_types" binding:"required,min=1"` // "*": mọi loại sự kiện
+	Description string                   `json:"description"`
+}
+
+// UpdateWebhookSubscriptionReq struct for updating a webhook subscription; omitted fields keep their value
+type UpdateWebhookSubscriptionReq struct {
+	URL         *string                  `json:"url"`
+	EventTypes  []consts.DomainEventType `json:"event_types"`
+	Description *string                  `json:"description"`
+	Active      *bool                    `json:"active"` // tắt subscription chuyển các lần gửi còn chờ sang dead-letter
+}
+
+// validateWebhookURL chỉ chấp nhận URL http(s) tuyệt đối
+func validateWebhookURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return raw, false
+	}
+	return raw, true
+}
+
+// validateWebhookEventTypes kiểm tra các loại sự kiện đăng ký
+func validateWebhookEventTypes(eventTypes []consts.DomainEventType) bool {
+	if len(eventTypes) == 0 {
+		return false
+	}
+	for _, eventType := range eventTypes {
+		if eventType != models.WebhookAllEvents && !eventType.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookIDParam đọc ID subscription hoặc lần gửi trên URL
+func webhookIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequest(c, "invalid id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findWebhookSubscription tìm subscription theo ID trên URL
+func findWebhookSubscription(c *gin.Context) (models.WebhookSubscription, bool) {
+	var subscription models.WebhookSubscription
+	id, ok := webhookIDParam(c)
+	if !ok {
+		return subscription, false
+	}
+	if err := utils.RequestDB(c).First(&subscription, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "webhook subscription not found")
+		} else {
+			utils.InternalServerError(c, "failed to fetch webhook subscription")
+		}
+		return subscription, false
+	}
+	return subscription, true
+}
+
+// CreateWebhookSubscription handles POST /admin/webhooks
+// @Summary Create webhook subscription
+// @Description Register a partner URL to receive card and journey events by HTTP POST. Each request is signed with HMAC-SHA256; the signing secret is only returned in this response.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body WebhookSubscriptionReq true "Subscription"
+// @Success 201 {object} utils.Response{data=models.WebhookSubscription} "Subscription created"
+// @Failure 400 {object} utils.Response "Invalid URL or event type"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks [post]
+func CreateWebhookSubscription(c *gin.Context) {
+	var request WebhookSubscriptionReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	target, ok := validateWebhookURL(request.URL)
+	if !ok {
+		utils.BadRequest(c, "url must be an absolute http or https URL")
+		return
+	}
+	if !validateWebhookEventTypes(request.EventTypes) {
+		utils.BadRequest(c, "unknown event type")
+		return
+	}
+
+	secret, err := utils.NewWebhookSecret()
+	if err != nil {
+		utils.InternalServerError(c, "failed to create webhook subscription")
+		return
+	}
+	createdBy := currentUserID(c)
+	subscription := models.WebhookSubscription{
+		URL:         target,
+		EventTypes:  request.EventTypes,
+		Description: request.Description,
+		Active:      true,
+		Secret:      secret,
+		CreatedBy:   &createdBy,
+	}
+	if err := utils.RequestDB(c).Create(&subscription).Error; err != nil {
+		utils.InternalServerError(c, "failed to create webhook subscription")
+		return
+	}
+	utils.AuditTarget(c, strconv.FormatUint(uint64(subscription.ID), 10))
+	utils.AuditAfter(c, subscription)
+
+	subscription.SigningSecret = secret
+	utils.SuccessResponse(c, http.StatusCreated, "webhook subscription created", subscription)
+}
+
+// GetWebhookSubscriptions handles GET /admin/webhooks
+// @Summary List webhook subscriptions
+// @Description Partner webhook subscriptions without their signing secrets
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.WebhookSubscription} "Subscriptions retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks [get]
+func GetWebhookSubscriptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	subscriptions := []models.WebhookSubscription{}
+	if err := config.DB.Order("id").Offset(offset).Limit(limit).Find(&subscriptions).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch webhook subscriptions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook subscriptions retrieved successfully", subscriptions)
+}
+
+// GetWebhookSubscriptionByID handles GET /admin/webhooks/:id
+// @Summary Get webhook subscription
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} utils.Response{data=models.WebhookSubscription} "Subscription retrieved successfully"
+// @Failure 404 {object} utils.Response "Subscription not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/{id} [get]
+func GetWebhookSubscriptionByID(c *gin.Context) {
+	subscription, ok := findWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook subscription retrieved successfully", subscription)
+}
+
+// UpdateWebhookSubscription handles PUT /admin/webhooks/:id
+// @Summary Update webhook subscription
+// @Description Change the URL, event types or description, or turn the subscription on or off. Pending deliveries of an inactive subscription are moved to the dead letters.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param request body UpdateWebhookSubscriptionReq true "Fields to change"
+// @Success 200 {object} utils.Response{data=models.WebhookSubscription} "Subscription updated"
+// @Failure 400 {object} utils.Response "Invalid URL or event type"
+// @Failure 404 {object} utils.Response "Subscription not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/{id} [put]
+func UpdateWebhookSubscription(c *gin.Context) {
+	var request UpdateWebhookSubscriptionReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	subscription, ok := findWebhookSubscription(c)
+	if !ok {
+		return
+	}
+	utils.AuditBefore(c, subscription)
+
+	if request.URL != nil {
+		target, ok := validateWebhookURL(*request.URL)
+		if !ok {
+			utils.BadRequest(c, "url must be an absolute http or https URL")
+			return
+		}
+		subscription.URL = target
+	}
+	if request.EventTypes != nil {
+		if !validateWebhookEventTypes(request.EventTypes) {
+			utils.BadRequest(c, "unknown event type")
+			return
+		}
+		subscription.EventTypes = request.EventTypes
+	}
+	if request.Description != nil {
+		subscription.Description = *request.Description
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+
+	if err := utils.RequestDB(c).Model(&subscription).
+		Select("url", "event_types", "description", "active").
+		Updates(&subscription).Error; err != nil {
+		utils.InternalServerError(c, "failed to update webhook subscription")
+		return
+	}
+	utils.AuditAfter(c, subscription)
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook subscription updated", subscription)
+}
+
+// DeleteWebhookSubscription handles DELETE /admin/webhooks/:id
+// @Summary Delete webhook subscription
+// @Description Delete the subscription together with its delivery history
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} utils.Response "Subscription deleted"
+// @Failure 404 {object} utils.Response "Subscription not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/{id} [delete]
+func DeleteWebhookSubscription(c *gin.Context) {
+	subscription, ok := findWebhookSubscription(c)
+	if !ok {
+		return
+	}
+	utils.AuditBefore(c, subscription)
+
+	if err := utils.RequestDB(c).Delete(&subscription).Error; err != nil {
+		utils.InternalServerError(c, "failed to delete webhook subscription")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook subscription deleted", nil)
+}
+
+// RotateWebhookSecret handles POST /admin/webhooks/:id/rotate-secret
+// @Summary Rotate webhook signing secret
+// @Description Replace the signing secret; deliveries from now on are signed with the new secret, which is only returned in this response
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} utils.Response{data=models.WebhookSubscription} "Secret rotated"
+// @Failure 404 {object} utils.Response "Subscription not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/{id}/rotate-secret [post]
+func RotateWebhookSecret(c *gin.Context) {
+	subscription, ok := findWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	secret, err := utils.NewWebhookSecret()
+	if err != nil {
+		utils.InternalServerError(c, "failed to rotate webhook secret")
+		return
+	}
+	subscription.Secret = secret
+	if err := utils.RequestDB(c).Model(&subscription).Update("secret", secret).Error; err != nil {
+		utils.InternalServerError(c, "failed to rotate webhook secret")
+		return
+	}
+
+	subscription.SigningSecret = secret
+	utils.SuccessResponse(c, http.StatusOK, "webhook secret rotated", subscription)
+}
+
+// GetWebhookDeliveries handles GET /admin/webhooks/:id/deliveries
+// @Summary List webhook deliveries
+// @Description Deliveries of a subscription with their attempts and last error, newest first
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param status query string false "Filter by status (pending, delivered, dead)"
+// @Param event_type query string false "Filter by event type"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.WebhookDelivery} "Deliveries retrieved successfully"
+// @Failure 404 {object} utils.Response "Subscription not found"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	subscription, ok := findWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("subscription_id = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch webhook deliveries")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook deliveries retrieved successfully", deliveries)
+}
+
+// GetWebhookDeadLetters handles GET /admin/webhooks/dead-letters
+// @Summary List webhook dead letters
+// @Description Deliveries of every subscription that ran out of attempts or whose subscription was turned off, newest first
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription_id query int false "Filter by subscription"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of records per page"
+// @Success 200 {object} utils.Response{data=[]models.WebhookDelivery} "Dead letters retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/dead-letters [get]
+func GetWebhookDeadLetters(c *gin.Context) {
+	query := config.DB.Where("status = ?", consts.WebhookDead)
+	if subscriptionID := c.Query("subscription_id"); subscriptionID != "" {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		utils.InternalServerError(c, "failed to fetch webhook dead letters")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook dead letters retrieved successfully", deliveries)
+}
+
+// RedeliverWebhook handles POST /admin/webhooks/deliveries/:id/redeliver
+// @Summary Redeliver webhook
+// @Description Put a dead delivery back in the queue with a fresh set of attempts. The payload is sent unchanged with the same event ID so partners can drop duplicates.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} utils.Response{data=models.WebhookDelivery} "Delivery queued"
+// @Failure 404 {object} utils.Response "Delivery not found"
+// @Failure 409 {object} utils.Response "Delivery is not in the dead letters"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/webhooks/deliveries/{id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	id, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := utils.RedeliverWebhook(utils.RequestDB(c), id)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFound(c, "webhook delivery not found")
+		return
+	case errors.Is(err, utils.ErrWebhookNotDead):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.InternalServerError(c, "failed to redeliver webhook")
+		return
+	}
+	utils.AuditAfter(c, delivery)
+	utils.AfterCommit(c, utils.Webhooks.Wake)
+
+	utils.SuccessResponse(c, http.StatusOK, "webhook delivery queued", delivery)
+}
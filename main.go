@@ -1,6 +1,7 @@
 package main
 
 import (
+  "log"
   "os"
   "strings"
 
@@ -8,6 +9,7 @@ import (
   _ "go-metro/docs" // This will be generated by swag
   "go-metro/models"
   "go-metro/routes"
+  "go-metro/utils"
 
   "github.com/gin-contrib/cors"
   "github.com/gin-gonic/gin"
@@ -35,16 +37,58 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey DeviceID
+// @in header
+// @name X-Device-ID
+// @description Key ID of a registered station device.
+
+// @securityDefinitions.apikey DeviceKey
+// @in header
+// @name X-Device-Key
+// @description Device secret. Devices may instead sign requests with X-Device-Timestamp, X-Device-Nonce and X-Device-Signature (HMAC-SHA256).
+
 func main() {
   // Initialize database
   config.ConnectDB()
 
+  // Không khởi động khi chưa cấu hình khóa ký JWT
+  if err := utils.LoadSigningKeys(); err != nil {
+    log.Fatal("❌ Failed to load JWT signing keys: ", err)
+  }
+
   // Kiểm tra biến MIGRATE trong env
   migrate := strings.ToLower(os.Getenv("MIGRATE")) == "true"
   if migrate {
     models.MigrateAll()
   }
 
+  // Bảng giờ tàu trực tiếp tại các trạm
+  go utils.Boards.Run()
+
+  // Đối chiếu hotlist và đẩy phiên bản mới xuống cổng soát vé
+  go utils.Hotlists.Run()
+
+  // Dọn nonce của các request thiết bị đã hết hạn
+  go utils.RunDeviceNonceCleanup()
+
+  // Theo dõi thiết bị mất kết nối
+  go utils.RunDeviceHealthMonitor()
+
+  // Xóa hẳn bản ghi đã xóa mềm quá thời gian lưu giữ
+  go utils.RunSoftDeletePurge()
+
+  // Gửi thông báo cho khách (số dư thấp, nạp tiền, khóa thẻ, thẻ sắp hết hạn)
+  go utils.Notifications.Run()
+
+  // Hết hạn thẻ quá hạn dùng và báo thẻ sắp hết hạn
+  go utils.RunCardExpiry()
+
+  // Công bố sự kiện nghiệp vụ trong outbox ra các sink (webhook đối tác, file, stdout)
+  go utils.Outbox.Run()
+
+  // Gửi sự kiện tới webhook đối tác, thử lại theo exponential backoff
+  go utils.Webhooks.Run()
+
   // Setup Gin router
   r := gin.Default()
 
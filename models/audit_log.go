@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"go-metro/config"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable báo nhật ký audit chỉ được ghi thêm, không được sửa hay xóa
+var ErrAuditLogImmutable = errors.New("audit log entries are immutable")
+
+// AuditChange là giá trị của một trường trước và sau thay đổi
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditLog là một thao tác quản trị đã thực hiện. Các bản ghi nối với nhau bằng hash
+// (Hash = SHA-256 của PrevHash và nội dung bản ghi) nên sửa hoặc xóa một bản ghi sẽ làm gãy chuỗi.
+type AuditLog struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	ActorID    *uint                  `gorm:"index" json:"actor_id"`
+	ActorEmail string                 `json:"actor_email"`
+	ActorRole  int                    `json:"actor_role"`
+	Action     string                 `gorm:"size:64;not null;index" json:"action"` // ví dụ "user.update", "card.delete"
+	TargetType string                 `gorm:"size:32;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string                 `gorm:"size:64;index:idx_audit_target" json:"target_id"`
+	Before     map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"before"`
+	After      map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"after"`
+	Changes    map[string]AuditChange `gorm:"type:jsonb;serializer:json" json:"changes"`
+	Method     string                 `gorm:"size:8" json:"method"`
+	Path       string                 `json:"path"`
+	Status     int                    `json:"status"`
+	IPAddress  string                 `gorm:"size:64" json:"ip_address"`
+	UserAgent  string                 `json:"user_agent"`
+	PrevHash   string                 `gorm:"size:64" json:"prev_hash"`
+	Hash       string                 `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+	CreatedAt  time.Time              `gorm:"index" json:"created_at"`
+}
+
+// BeforeUpdate chặn sửa bản ghi audit qua GORM
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete chặn xóa bản ghi audit qua GORM
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// MigrateAuditLog tạo bảng và trigger chặn UPDATE/DELETE/TRUNCATE ngay trong database
+func MigrateAuditLog() {
+	config.DB.AutoMigrate(&AuditLog{})
+
+	config.DB.Exec(`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql`)
+	config.DB.Exec(`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`)
+	config.DB.Exec(`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+  FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`)
+	config.DB.Exec(`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`)
+	config.DB.Exec(`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`)
+}
@@ -5,22 +5,48 @@ import (
 
   "go-metro/config"
   "go-metro/consts"
+
+  "gorm.io/gorm"
 )
 
 type Card struct {
   ID        uint            `gorm:"primaryKey" json:"id"`
-  UserID    uint            `gorm:"not null" json:"user_id"`
-  RFID      string          `gorm:"uniqueIndex;not null" json:"rf_id"`
+  UserID    *uint           `gorm:"index" json:"user_id"` // nil: thẻ vô danh bán ở kiosk, chưa được khách nhận
+  RFID      string          `gorm:"uniqueIndex:idx_cards_rf_id;not null" json:"rf_id"`
   Balance   float64         `json:"balance" gorm:"default:0"`
   Status    consts.Status   `json:"status"`
   Price     float64         `json:"price" gorm:"default:0"`
   Type      consts.CardType `json:"type"`
+  ExpiresAt *time.Time      `gorm:"index" json:"expires_at"` // hạn dùng của thẻ (thẻ học sinh, vé tháng); nil: không hết hạn
   CreatedAt time.Time       `json:"created_at"`
   UpdatedAt time.Time       `json:"updated_at"`
+  DeletedAt gorm.DeletedAt  `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // xóa mềm; lịch sử vẫn tham chiếu được
+
+  // Thẻ vô danh bán ở kiosk được khách nhận bằng RFID và mã xác minh in trên thẻ.
+  // Chỉ lưu hash của mã; mã dạng rõ chỉ trả về một lần khi tạo thẻ để in.
+  ClaimedAt            *time.Time `json:"claimed_at"`
+  VerificationCodeHash string     `gorm:"size:64" json:"-"`
+  VerificationCode     string     `gorm:"-" json:"verification_code,omitempty"`
 
   User *User `gorm:"foreignKey:UserID" json:"user"`
 }
 
+// Registered cho biết thẻ đã gắn với tài khoản (chỉ thẻ đã đăng ký mới được hoàn tiền, cấp lại)
+func (c Card) Registered() bool {
+  return c.UserID != nil
+}
+
 func MigrateCard() {
+  // RFID là mã vật lý của thẻ, duy nhất trên toàn bảng kể cả thẻ đã xóa mềm để lịch sử theo rf_id
+  // không bị gán cho thẻ khác; bỏ unique index cũ chỉ trên các thẻ chưa xóa
+  if config.DB.Migrator().HasIndex(&Card{}, "idx_cards_rf_id_active") {
+    config.DB.Migrator().DropIndex(&Card{}, "idx_cards_rf_id_active")
+  }
+
+  // Thẻ vô danh không có user_id
+  if config.DB.Migrator().HasTable(&Card{}) {
+    config.DB.Exec("ALTER TABLE cards ALTER COLUMN user_id DROP NOT NULL")
+  }
+
   config.DB.AutoMigrate(&Card{})
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// Device là cổng soát vé hoặc máy bán vé đã đăng ký tại một trạm.
+// Thiết bị xác thực bằng KeyID + Secret (API key hoặc chữ ký HMAC).
+type Device struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	Name       string            `gorm:"not null" json:"name"`
+	Type       consts.DeviceType `gorm:"size:32;default:'gate'" json:"type"`
+	StationID  uint              `gorm:"index;not null" json:"station_id"`
+	Status     consts.Status     `gorm:"size:16;default:'active'" json:"status"`
+	KeyID      string            `gorm:"size:64;uniqueIndex;not null" json:"key_id"`
+	Secret     string            `gorm:"not null" json:"-"`
+	IPAddress  string            `json:"ip_address"` // nếu có, chỉ chấp nhận request từ IP này
+	LastUsedAt *time.Time        `json:"last_used_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+
+	// Tình trạng theo heartbeat gần nhất
+	Connectivity    consts.DeviceConnectivity `gorm:"size:16;default:'unknown';index" json:"connectivity"`
+	LastSeenAt      *time.Time                `gorm:"index" json:"last_seen_at"` // request đã xác thực gần nhất
+	LastHeartbeatAt *time.Time                `json:"last_heartbeat_at"`
+	FirmwareVersion string                    `gorm:"size:64" json:"firmware_version"`
+	ClockDriftMs    int64                     `json:"clock_drift_ms"` // đồng hồ thiết bị trừ đồng hồ máy chủ
+	QueueSize       int                       `json:"queue_size"`     // số lượt quẹt ngoại tuyến chưa gửi
+	ErrorCount      int64                     `json:"error_count"`    // tổng bộ đếm lỗi trong heartbeat gần nhất
+
+	Station Station `gorm:"foreignKey:StationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"station"`
+}
+
+// DeviceNonce lưu nonce của các request đã ký để chống gửi lại
+type DeviceNonce struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  uint      `gorm:"not null;uniqueIndex:idx_device_nonce" json:"device_id"`
+	Nonce     string    `gorm:"size:128;not null;uniqueIndex:idx_device_nonce" json:"nonce"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// DeviceHeartbeat lưu từng heartbeat thiết bị gửi lên để theo dõi lịch sử
+type DeviceHeartbeat struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	DeviceID        uint             `gorm:"not null;index:idx_device_heartbeat_time" json:"device_id"`
+	ReceivedAt      time.Time        `gorm:"not null;index:idx_device_heartbeat_time" json:"received_at"`
+	DeviceTime      time.Time        `json:"device_time"`
+	FirmwareVersion string           `gorm:"size:64" json:"firmware_version"`
+	ClockDriftMs    int64            `json:"clock_drift_ms"`
+	QueueSize       int              `json:"queue_size"`
+	Errors          map[string]int64 `gorm:"serializer:json" json:"errors"` // bộ đếm lỗi theo loại, vd. {"reader": 2}
+	UptimeSeconds   int64            `json:"uptime_seconds"`
+
+	Device Device `gorm:"foreignKey:DeviceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateDevice() {
+	config.DB.AutoMigrate(&Device{}, &DeviceNonce{}, &DeviceHeartbeat{})
+}
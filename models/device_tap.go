@@ -0,0 +1,36 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+// Kết quả xử lý một lượt quẹt ngoại tuyến
+const (
+	TapAccepted         = "accepted"
+	TapAcceptedWithDebt = "accepted_with_debt"
+	TapRejected         = "rejected"
+)
+
+// DeviceTap lưu từng lượt quẹt ngoại tuyến cổng đã gửi lên, theo số thứ tự của thiết bị.
+// Cặp (DeviceID, Sequence) là duy nhất nên gửi lại cùng một lượt quẹt không bị ghi hai lần.
+type DeviceTap struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	DeviceID         uint      `gorm:"not null;uniqueIndex:idx_device_tap_sequence" json:"device_id"`
+	Sequence         uint64    `gorm:"not null;uniqueIndex:idx_device_tap_sequence" json:"sequence"`
+	Action           string    `gorm:"size:16;not null" json:"action"`
+	CardID           string    `gorm:"index;not null" json:"card_id"`
+	TappedAt         time.Time `json:"tapped_at"` // thời gian theo đồng hồ của thiết bị
+	Result           string    `gorm:"size:32;not null" json:"result"`
+	Reason           string    `json:"reason,omitempty"`
+	StationHistoryID *uint     `json:"station_history_id"`
+	Balance          float64   `json:"balance"` // số dư thẻ sau khi xử lý
+	CreatedAt        time.Time `json:"created_at"`
+
+	Device         Device          `gorm:"foreignKey:DeviceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	StationHistory *StationHistory `gorm:"foreignKey:StationHistoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+}
+
+func MigrateDeviceTap() {
+	config.DB.AutoMigrate(&DeviceTap{})
+}
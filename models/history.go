@@ -14,6 +14,7 @@ type History struct {
 	Balance    float64           `json:"balance"`
 	UserAction consts.UserAction `json:"user_action"`
 	CardAction consts.CardAction `json:"card_action"`
+	TransferID *uint             `gorm:"index" json:"transfer_id,omitempty"` // cặp bút toán transfer_out/transfer_in của cùng một lần chuyển số dư
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
 }
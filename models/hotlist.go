@@ -0,0 +1,27 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// Thay đổi trong danh sách chặn
+const (
+	HotlistAdd    = "add"
+	HotlistRemove = "remove"
+)
+
+// HotlistEntry là một thay đổi của danh sách thẻ bị chặn (hotlist) gửi xuống cổng soát vé.
+// Bảng chỉ ghi thêm; ID chính là số phiên bản, thiết bị tải các thay đổi có ID lớn hơn phiên bản đang giữ.
+type HotlistEntry struct {
+	ID        uint64        `gorm:"primaryKey" json:"version"`
+	CardID    string        `gorm:"index;not null" json:"card_id"`
+	Action    string        `gorm:"size:16;not null" json:"action"` // "add" hoặc "remove"
+	Status    consts.Status `gorm:"size:16" json:"status"`          // trạng thái thẻ tại thời điểm thay đổi
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func MigrateHotlist() {
+	config.DB.AutoMigrate(&HotlistEntry{})
+}
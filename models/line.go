@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+type Line struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name        string    `gorm:"not null" json:"name"`
+	Color       string    `json:"color"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func MigrateLine() {
+	config.DB.AutoMigrate(&Line{})
+}
@@ -2,12 +2,30 @@ package models
 
 // MigrateAll chạy migration cho tất cả các models
 func MigrateAll() {
+  MigrateStation() // users tham chiếu stations qua bảng user_stations
   MigrateUser()
+  MigrateRefreshToken()
+  MigrateUserToken()
+  MigrateSecurity()
+  MigrateTwoFactor()
+  MigrateRolePermission()
   MigrateCard()
+  MigrateOrganization()
+  MigrateHotlist()
   MigrateHistory()
-  MigrateStation()
+  MigrateTransfer()
+  MigrateNotification()
+  MigrateOutbox()
+  MigrateWebhook()
   MigrateTrain()
+  MigrateLine()
+  MigrateServicePattern()
   MigrateTrip()
+  MigrateTripProgress()
+  MigrateServiceAlert()
+  MigrateDevice()
   MigrateSellHistory()
   MigrateStationHistory()
+  MigrateDeviceTap()
+  MigrateAuditLog()
 }
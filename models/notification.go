@@ -0,0 +1,80 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// NotificationPreference là lựa chọn nhận thông báo của người dùng.
+// Người dùng chưa lưu lựa chọn dùng DefaultNotificationPreference.
+type NotificationPreference struct {
+	UserID              uint      `gorm:"primaryKey" json:"user_id"`
+	LowBalance          bool      `json:"low_balance"`
+	LowBalanceThreshold float64   `json:"low_balance_threshold"` // báo khi số dư thẻ xuống dưới mức này
+	TopUp               bool      `json:"topup"`
+	CardBlocked         bool      `json:"card_blocked"`
+	CardExpiring        bool      `json:"card_expiring"`
+	Email               bool      `json:"email"`
+	SMS                 bool      `json:"sms"`        // gửi tới số điện thoại trong hồ sơ
+	Push                bool      `json:"push"`       // gửi tới PushToken
+	PushToken           string    `json:"push_token"` // token thiết bị của ứng dụng di động
+	UpdatedAt           time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// DefaultNotificationPreference bật mọi loại thông báo qua email
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	return NotificationPreference{
+		UserID:              userID,
+		LowBalance:          true,
+		LowBalanceThreshold: 10000,
+		TopUp:               true,
+		CardBlocked:         true,
+		CardExpiring:        true,
+		Email:               true,
+	}
+}
+
+// Wants cho biết người dùng có muốn nhận loại thông báo event hay không
+func (p NotificationPreference) Wants(event consts.NotificationEvent) bool {
+	switch event {
+	case consts.NotifyLowBalance:
+		return p.LowBalance
+	case consts.NotifyTopUp:
+		return p.TopUp
+	case consts.NotifyCardBlocked:
+		return p.CardBlocked
+	case consts.NotifyCardExpiring:
+		return p.CardExpiring
+	default:
+		return false
+	}
+}
+
+// Notification là một thông báo gửi qua một kênh. DedupKey cùng Channel là duy nhất
+// nên một sự kiện được ghi nhận nhiều lần chỉ gửi một thông báo trên mỗi kênh.
+type Notification struct {
+	ID            uint                       `gorm:"primaryKey" json:"id"`
+	UserID        uint                       `gorm:"not null;index" json:"user_id"`
+	Event         consts.NotificationEvent   `gorm:"size:32;not null" json:"event"`
+	Channel       consts.NotificationChannel `gorm:"size:16;not null;uniqueIndex:idx_notification_dedup" json:"channel"`
+	DedupKey      string                     `gorm:"size:191;not null;uniqueIndex:idx_notification_dedup" json:"dedup_key"`
+	Recipient     string                     `gorm:"not null" json:"-"` // email, số điện thoại hoặc push token
+	Subject       string                     `json:"subject"`
+	Body          string                     `json:"body"`
+	Status        consts.NotificationStatus  `gorm:"size:16;not null;index:idx_notification_due" json:"status"`
+	Attempts      int                        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time                  `gorm:"index:idx_notification_due" json:"next_attempt_at"`
+	LastError     string                     `json:"last_error,omitempty"`
+	SentAt        *time.Time                 `json:"sent_at"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateNotification() {
+	config.DB.AutoMigrate(&NotificationPreference{}, &Notification{})
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// Organization là tài khoản gia đình hoặc doanh nghiệp sở hữu nhiều người dùng và thẻ,
+// với một ví chung dùng để nạp tiền cho thẻ của thành viên
+type Organization struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Name          string         `gorm:"not null" json:"name"`
+	Type          consts.OrgType `gorm:"size:16;not null" json:"type"`
+	WalletBalance float64        `gorm:"not null;default:0" json:"wallet_balance"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// OrganizationMember gắn người dùng vào tổ chức; mỗi người dùng thuộc tối đa một tổ chức
+type OrganizationMember struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	OrganizationID uint           `gorm:"not null;index" json:"organization_id"`
+	UserID         uint           `gorm:"not null;uniqueIndex" json:"user_id"`
+	Role           consts.OrgRole `gorm:"size:16;not null;default:'member'" json:"role"`
+	CreatedAt      time.Time      `json:"created_at"`
+
+	Organization Organization `gorm:"foreignKey:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User         User         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+}
+
+// OrganizationCard là thẻ do tổ chức sở hữu. MonthlyFundingLimit giới hạn số tiền ví chung
+// được nạp cho thẻ trong một tháng (0: không giới hạn); không giới hạn số tiền thẻ chi tiêu.
+type OrganizationCard struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID      uint      `gorm:"not null;index" json:"organization_id"`
+	CardID              string    `gorm:"not null;uniqueIndex" json:"card_id"` // rf_id của thẻ
+	MonthlyFundingLimit float64   `gorm:"not null;default:0" json:"monthly_funding_limit"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+
+	Organization Organization `gorm:"foreignKey:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// WalletTransaction là một dòng sổ cái của ví chung: nạp tiền vào ví hoặc ví nạp tiền cho thẻ
+type WalletTransaction struct {
+	ID             uint                   `gorm:"primaryKey" json:"id"`
+	OrganizationID uint                   `gorm:"not null;index:idx_wallet_org_time" json:"organization_id"`
+	Type           consts.WalletEntryType `gorm:"size:16;not null" json:"type"`
+	Amount         float64                `gorm:"not null" json:"amount"` // dương: tiền vào ví, âm: tiền ra khỏi ví
+	BalanceAfter   float64                `gorm:"not null" json:"balance_after"`
+	CardID         *string                `gorm:"index" json:"card_id"` // thẻ được nạp (chỉ với topup)
+	ActorID        *uint                  `json:"actor_id"`             // người thực hiện
+	Note           string                 `json:"note"`
+	CreatedAt      time.Time              `gorm:"index:idx_wallet_org_time" json:"created_at"`
+}
+
+func MigrateOrganization() {
+	// Hạn mức chỉ áp dụng cho tiền ví nạp vào thẻ; đổi tên cột cũ cho đúng ý nghĩa
+	if config.DB.Migrator().HasColumn(&OrganizationCard{}, "monthly_limit") {
+		config.DB.Migrator().RenameColumn(&OrganizationCard{}, "monthly_limit", "monthly_funding_limit")
+	}
+
+	config.DB.AutoMigrate(&Organization{}, &OrganizationMember{}, &OrganizationCard{}, &WalletTransaction{})
+}
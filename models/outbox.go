@@ -0,0 +1,29 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// OutboxEvent là sự kiện nghiệp vụ ghi trong cùng transaction với thay đổi dữ liệu,
+// sau đó được dispatcher công bố ra các sink. ID tăng dần là thứ tự công bố;
+// các sự kiện cùng CardID được công bố đúng thứ tự này.
+type OutboxEvent struct {
+	ID            uint                   `gorm:"primaryKey" json:"id"`
+	EventID       string                 `gorm:"size:36;not null;uniqueIndex" json:"event_id"` // UUID, để bên nhận loại bỏ bản trùng
+	Type          consts.DomainEventType `gorm:"size:64;not null;index" json:"type"`
+	Version       int                    `gorm:"not null" json:"version"`
+	CardID        string                 `gorm:"size:64;not null;index:idx_outbox_card_pending" json:"card_id"` // rf_id, khóa thứ tự
+	Data          map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"data"`
+	OccurredAt    time.Time              `gorm:"not null" json:"occurred_at"`
+	PublishedAt   *time.Time             `gorm:"index;index:idx_outbox_card_pending" json:"published_at"` // nil: chưa công bố
+	Attempts      int                    `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time              `json:"next_attempt_at"`
+	LastError     string                 `json:"last_error,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+func MigrateOutbox() {
+	config.DB.AutoMigrate(&OutboxEvent{})
+}
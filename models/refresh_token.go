@@ -0,0 +1,29 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+// RefreshToken là một refresh token đã cấp; chỉ lưu SHA-256 của token.
+// Các token xoay vòng từ cùng một lần đăng nhập có chung FamilyID.
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID   string     `gorm:"size:64;index;not null" json:"family_id"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`    // thời điểm token được đổi lấy token mới
+	RevokedAt  *time.Time `json:"revoked_at"` // logout hoặc phát hiện dùng lại
+	ReplacedBy *uint      `json:"replaced_by"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateRefreshToken() {
+	config.DB.AutoMigrate(&RefreshToken{})
+}
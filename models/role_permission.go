@@ -0,0 +1,74 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RolePermission gán một quyền cho một role
+type RolePermission struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	Role       consts.Role       `gorm:"not null;uniqueIndex:idx_role_permission" json:"role"`
+	Permission consts.Permission `gorm:"size:64;not null;uniqueIndex:idx_role_permission" json:"permission"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// SeededPermission ghi nhận quyền đã được nạp mặc định một lần. Quyền mới thêm vào hệ thống
+// chưa có ở đây; quyền admin đã gỡ thì vẫn còn nên không bị cấp lại.
+type SeededPermission struct {
+	Permission consts.Permission `gorm:"primaryKey;size:64" json:"permission"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// MigrateRolePermission tạo bảng và nạp phân quyền mặc định cho các quyền chưa từng được nạp:
+// lần đầu là toàn bộ bảng mặc định, sau đó chỉ các quyền mới thêm vào hệ thống.
+func MigrateRolePermission() {
+	config.DB.AutoMigrate(&RolePermission{}, &SeededPermission{})
+
+	var seeded []consts.Permission
+	config.DB.Model(&SeededPermission{}).Pluck("permission", &seeded)
+	done := make(map[consts.Permission]bool, len(seeded))
+	for _, permission := range seeded {
+		done[permission] = true
+	}
+
+	// Bảng phân quyền có từ trước khi ghi nhận quyền đã nạp: phân quyền của các role khác đã được
+	// chỉnh nên chỉ bổ sung quyền cho admin như trước đây
+	defaultPermissions := consts.DefaultRolePermissions
+	var count int64
+	config.DB.Model(&RolePermission{}).Count(&count)
+	if len(seeded) == 0 && count > 0 {
+		defaultPermissions = map[consts.Role][]consts.Permission{consts.AdminRole: consts.AllPermissions}
+	}
+
+	var defaults []RolePermission
+	for role, permissions := range defaultPermissions {
+		for _, permission := range permissions {
+			if !done[permission] {
+				defaults = append(defaults, RolePermission{Role: role, Permission: permission})
+			}
+		}
+	}
+	var added []SeededPermission
+	for _, permission := range consts.AllPermissions {
+		if !done[permission] {
+			added = append(added, SeededPermission{Permission: permission})
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	config.DB.Transaction(func(tx *gorm.DB) error {
+		if len(defaults) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&added).Error
+	})
+}
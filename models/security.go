@@ -0,0 +1,65 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+// Phạm vi đếm đăng nhập sai
+const (
+	ThrottleAccount = "account"
+	ThrottleIP      = "ip"
+
+	ThrottleCardClaim = "card_claim" // nhập sai mã xác minh khi nhận thẻ vô danh, theo user ID
+)
+
+// Sự kiện bảo mật
+const (
+	EventLoginSucceeded  = "login_succeeded"
+	EventLoginFailed     = "login_failed"
+	EventLoginThrottled  = "login_throttled"
+	EventAccountLocked   = "account_locked"
+	EventIPLocked        = "ip_locked"
+	EventAccountUnlocked = "account_unlocked"
+	EventIPUnlocked      = "ip_unlocked"
+
+	EventTwoFactorEnabled         = "two_factor_enabled"
+	EventTwoFactorDisabled        = "two_factor_disabled"
+	EventTwoFactorReset           = "two_factor_reset" // admin gỡ 2FA của user mất thiết bị
+	EventRecoveryCodeUsed         = "recovery_code_used"
+	EventRecoveryCodesRegenerated = "recovery_codes_regenerated"
+
+	EventCardClaimed     = "card_claimed"
+	EventCardClaimFailed = "card_claim_failed"
+)
+
+// LoginThrottle đếm số lần đăng nhập sai theo tài khoản (email) hoặc theo IP.
+// Lưu trong database để trạng thái khóa giữ nguyên khi khởi động lại.
+type LoginThrottle struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Scope         string     `gorm:"size:16;not null;uniqueIndex:idx_login_throttle_key" json:"scope"` // "account" hoặc "ip"
+	Key           string     `gorm:"size:255;not null;uniqueIndex:idx_login_throttle_key" json:"key"`  // email (chữ thường) hoặc IP
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until"` // backoff: chưa được thử lại trước thời điểm này
+	LockedUntil   *time.Time `json:"locked_until"`  // khóa tạm thời sau khi vượt ngưỡng
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// SecurityEvent là nhật ký sự kiện bảo mật (đăng nhập, khóa, mở khóa)
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Event     string    `gorm:"size:32;not null;index" json:"event"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	Email     string    `gorm:"index" json:"email"`
+	IPAddress string    `gorm:"size:64;index" json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	ActorID   *uint     `json:"actor_id"` // admin thực hiện (mở khóa)
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func MigrateSecurity() {
+	config.DB.AutoMigrate(&LoginThrottle{}, &SecurityEvent{})
+}
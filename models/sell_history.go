@@ -9,14 +9,16 @@ type SellHistory struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
 	CardID        string    `json:"card_id"`
 	SellerID      uint      `json:"seller_id"`
+	StationID     *uint     `gorm:"index" json:"station_id"` // trạm nơi bán thẻ
 	CardPriceSold float64   `json:"card_price_sold"`
 	Time          time.Time `json:"time"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Foreign key relationships
-	Card   Card `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"card"`
-	Seller User `gorm:"foreignKey:SellerID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"seller"`
+	Card    Card     `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"card"`
+	Seller  User     `gorm:"foreignKey:SellerID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"seller"`
+	Station *Station `gorm:"foreignKey:StationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"station,omitempty"`
 }
 
 func MigrateSellHistory() {
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// ServiceAlert là thông báo gián đoạn dịch vụ (đóng ga, trễ chuyến, sự cố tại trạm)
+type ServiceAlert struct {
+	ID            uint                 `gorm:"primaryKey" json:"id"`
+	Severity      consts.AlertSeverity `gorm:"not null" json:"severity"`
+	Effect        consts.AlertEffect   `gorm:"not null" json:"effect"`
+	HeaderVi      string               `gorm:"not null" json:"header_vi"`
+	HeaderEn      string               `json:"header_en"`
+	DescriptionVi string               `json:"description_vi"`
+	DescriptionEn string               `json:"description_en"`
+	ActiveFrom    time.Time            `gorm:"index" json:"active_from"`
+	ActiveUntil   *time.Time           `json:"active_until"`
+	ResolvedAt    *time.Time           `gorm:"index" json:"resolved_at"`
+	CreatedBy     uint                 `json:"created_by"`
+	ResolvedBy    *uint                `json:"resolved_by"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+
+	Lines    []Line    `gorm:"many2many:service_alert_lines;constraint:OnDelete:CASCADE;" json:"lines"`
+	Stations []Station `gorm:"many2many:service_alert_stations;constraint:OnDelete:CASCADE;" json:"stations"`
+	Trips    []Trip    `gorm:"many2many:service_alert_trips;constraint:OnDelete:CASCADE;" json:"trips"`
+}
+
+// IsActive cho biết thông báo có đang hiệu lực tại thời điểm at hay không
+func (a ServiceAlert) IsActive(at time.Time) bool {
+	if a.ResolvedAt != nil || at.Before(a.ActiveFrom) {
+		return false
+	}
+	return a.ActiveUntil == nil || at.Before(*a.ActiveUntil)
+}
+
+func MigrateServiceAlert() {
+	config.DB.AutoMigrate(&ServiceAlert{})
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// ServicePattern mô tả một mẫu vận hành: tuyến, hướng, các ga dừng và các ngày chạy.
+// Các chuyến (Trip) cụ thể được sinh ra từ mẫu này.
+type ServicePattern struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	Name           string           `gorm:"not null" json:"name"`
+	LineID         uint             `gorm:"not null" json:"line_id"`
+	TrainID        uint             `gorm:"not null" json:"train_id"`
+	Direction      consts.Direction `json:"direction"`
+	Monday         bool             `json:"monday"`
+	Tuesday        bool             `json:"tuesday"`
+	Wednesday      bool             `json:"wednesday"`
+	Thursday       bool             `json:"thursday"`
+	Friday         bool             `json:"friday"`
+	Saturday       bool             `json:"saturday"`
+	Sunday         bool             `json:"sunday"`
+	StartDate      time.Time        `gorm:"type:date" json:"start_date"`
+	EndDate        time.Time        `gorm:"type:date" json:"end_date"`
+	FirstDeparture string           `gorm:"not null" json:"first_departure"` // "HH:MM"
+	LastDeparture  string           `gorm:"not null" json:"last_departure"`  // "HH:MM"
+	HeadwayMinutes int              `json:"headway_minutes"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+
+	// Foreign key relationships
+	Line       Line               `gorm:"foreignKey:LineID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"line"`
+	Train      Train              `gorm:"foreignKey:TrainID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"train"`
+	Stops      []PatternStop      `gorm:"foreignKey:PatternID;constraint:OnDelete:CASCADE;" json:"stops"`
+	Exceptions []ServiceException `gorm:"foreignKey:PatternID;constraint:OnDelete:CASCADE;" json:"exceptions"`
+}
+
+// PatternStop là một ga dừng trong mẫu vận hành, thời gian tính bằng giây kể từ lúc chuyến xuất phát
+type PatternStop struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	PatternID       uint      `gorm:"uniqueIndex:idx_pattern_stop_sequence;not null" json:"pattern_id"`
+	StopSequence    int       `gorm:"uniqueIndex:idx_pattern_stop_sequence;not null" json:"stop_sequence"`
+	StationID       uint      `gorm:"not null" json:"station_id"`
+	ArrivalOffset   int       `json:"arrival_offset"`
+	DepartureOffset int       `json:"departure_offset"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	Station Station `gorm:"foreignKey:StationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"station"`
+}
+
+// ServiceException thêm hoặc bỏ một ngày chạy của mẫu vận hành (ví dụ ngày lễ)
+type ServiceException struct {
+	ID          uint                        `gorm:"primaryKey" json:"id"`
+	PatternID   uint                        `gorm:"uniqueIndex:idx_pattern_exception_date;not null" json:"pattern_id"`
+	Date        time.Time                   `gorm:"type:date;uniqueIndex:idx_pattern_exception_date;not null" json:"date"`
+	Type        consts.ServiceExceptionType `gorm:"not null" json:"type"`
+	Description string                      `json:"description"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+}
+
+// Departure là một lượt tàu rời ga, được tính từ Trip, PatternStop và TripProgress
+type Departure struct {
+	TripID                uint              `json:"trip_id"`
+	StationID             uint              `json:"station_id"`
+	LineCode              string            `json:"line_code"`
+	LineName              string            `json:"line_name"`
+	Direction             string            `json:"direction"`
+	TrainName             string            `json:"train_name"`
+	StopSequence          int               `json:"stop_sequence"`
+	ArrivalTime           time.Time         `json:"arrival_time"`
+	DepartureTime         time.Time         `json:"departure_time"`
+	Status                consts.TripStatus `json:"status"`
+	DelaySeconds          int               `json:"delay_seconds"`
+	Late                  bool              `json:"late"`
+	ExpectedDepartureTime time.Time         `json:"expected_departure_time"`
+}
+
+// ParseClock chuyển chuỗi "HH:MM" thành khoảng thời gian tính từ nửa đêm
+func ParseClock(value string) (time.Duration, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	if hour < 0 || hour > 47 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+// RunsOn cho biết mẫu vận hành có chạy vào ngày day hay không.
+// Ngoại lệ được ưu tiên hơn lịch theo thứ trong tuần.
+func (p ServicePattern) RunsOn(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	for _, exception := range p.Exceptions {
+		if exception.Date.Format("2006-01-02") != date {
+			continue
+		}
+		return exception.Type == consts.ServiceAdded
+	}
+
+	if !p.StartDate.IsZero() && date < p.StartDate.Format("2006-01-02") {
+		return false
+	}
+	if !p.EndDate.IsZero() && date > p.EndDate.Format("2006-01-02") {
+		return false
+	}
+
+	switch day.Weekday() {
+	case time.Monday:
+		return p.Monday
+	case time.Tuesday:
+		return p.Tuesday
+	case time.Wednesday:
+		return p.Wednesday
+	case time.Thursday:
+		return p.Thursday
+	case time.Friday:
+		return p.Friday
+	case time.Saturday:
+		return p.Saturday
+	default:
+		return p.Sunday
+	}
+}
+
+// DepartureTimes trả về giờ xuất phát của các chuyến trong ngày day
+func (p ServicePattern) DepartureTimes(day time.Time) ([]time.Time, error) {
+	first, err := ParseClock(p.FirstDeparture)
+	if err != nil {
+		return nil, err
+	}
+	last, err := ParseClock(p.LastDeparture)
+	if err != nil {
+		return nil, err
+	}
+
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if p.HeadwayMinutes <= 0 {
+		return []time.Time{midnight.Add(first)}, nil
+	}
+
+	var times []time.Time
+	headway := time.Duration(p.HeadwayMinutes) * time.Minute
+	for offset := first; offset <= last; offset += headway {
+		times = append(times, midnight.Add(offset))
+	}
+	return times, nil
+}
+
+// Duration là thời gian từ lúc xuất phát đến khi tới ga cuối
+func (p ServicePattern) Duration() time.Duration {
+	var duration int
+	for _, stop := range p.Stops {
+		if stop.ArrivalOffset > duration {
+			duration = stop.ArrivalOffset
+		}
+	}
+	return time.Duration(duration) * time.Second
+}
+
+func MigrateServicePattern() {
+	// train_id không được null nên không thể SET NULL khi xóa tàu; tạo lại khóa ngoại cũ với RESTRICT
+	var setNull int64
+	config.DB.Raw("SELECT COUNT(*) FROM information_schema.referential_constraints WHERE constraint_name = ? AND delete_rule = ?",
+		"fk_service_patterns_train", "SET NULL").Scan(&setNull)
+	if setNull > 0 {
+		config.DB.Migrator().DropConstraint(&ServicePattern{}, "Train")
+	}
+	config.DB.AutoMigrate(&ServicePattern{}, &PatternStop{}, &ServiceException{})
+}
@@ -0,0 +1,9 @@
+package models
+
+import "gorm.io/gorm"
+
+// WithDeleted bỏ default scope xóa mềm, dùng trong Preload để lịch sử vẫn hiển thị
+// thẻ, trạm, user, tàu đã bị xóa: Preload("Card", models.WithDeleted)
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
@@ -4,19 +4,25 @@ import (
   "time"
 
   "go-metro/config"
+
+  "gorm.io/gorm"
 )
 
 type Station struct {
-  ID          uint      `gorm:"primaryKey" json:"id"`
-  Name        string    `gorm:"not null" json:"name"`
-  IPAddress   string    `json:"ip_address"`
-  Capacity    int       `json:"capacity"`
-  Address     string    `json:"address"`
-  Status      string    `json:"status"`
-  Description string    `json:"description"`
-  ImageURL    string    `json:"image_url"`
-  CreatedAt   time.Time `json:"created_at"`
-  UpdatedAt   time.Time `json:"updated_at"`
+  ID          uint           `gorm:"primaryKey" json:"id"`
+  Name        string         `gorm:"not null" json:"name"`
+  IPAddress   string         `json:"ip_address"`
+  Capacity    int            `json:"capacity"`
+  Address     string         `json:"address"`
+  Status      string         `json:"status"`
+  Description string         `json:"description"`
+  ImageURL    string         `json:"image_url"`
+  Latitude    float64        `json:"latitude"`
+  Longitude   float64        `json:"longitude"`
+  Closed      bool           `gorm:"-" json:"closed"` // trạm đóng cửa theo status hoặc thông báo đóng ga
+  CreatedAt   time.Time      `json:"created_at"`
+  UpdatedAt   time.Time      `json:"updated_at"`
+  DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // xóa mềm; lịch sử vẫn tham chiếu được
 }
 
 func MigrateStation() {
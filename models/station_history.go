@@ -12,12 +12,15 @@ type StationHistory struct {
 	CardID     string    `json:"card_id"`
 	StationID  uint      `json:"station_id"`
 	UsedBalance float64  `json:"used_balance"`
+	DeviceID   *uint     `gorm:"index" json:"device_id"` // thiết bị ghi nhận lượt quẹt
+	Offline    bool      `gorm:"default:false" json:"offline"` // lượt quẹt cổng lưu khi mất kết nối và gửi bù
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 
 	// Foreign key relationships
 	Card    Card    `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"card"`
 	Station Station `gorm:"foreignKey:StationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"station"`
+	Device  *Device `gorm:"foreignKey:DeviceID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"device,omitempty"`
 }
 
 func MigrateStationHistory() {
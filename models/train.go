@@ -3,17 +3,20 @@ package models
 import (
 	"go-metro/config"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Train struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Type      string    `json:"type"`
-	Company   string    `json:"company"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"not null" json:"name"`
+	Type      string         `json:"type"`
+	Company   string         `json:"company"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // xóa mềm; chuyến tàu cũ vẫn tham chiếu được
 }
 
 func MigrateTrain() {
 	config.DB.AutoMigrate(&Train{})
-} 
\ No newline at end of file
+}
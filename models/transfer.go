@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// BalanceTransfer là một lần chuyển số dư từ thẻ này sang thẻ khác. Chuyển giữa hai thẻ của cùng
+// chủ được thực hiện ngay; chuyển sang thẻ của người khác chờ chủ thẻ nhận đồng ý.
+type BalanceTransfer struct {
+	ID          uint                  `gorm:"primaryKey" json:"id"`
+	FromCardID  string                `gorm:"not null;index:idx_transfer_from_time" json:"from_card_id"` // rf_id thẻ nguồn
+	ToCardID    string                `gorm:"not null;index" json:"to_card_id"`                          // rf_id thẻ nhận
+	Amount      float64               `gorm:"not null" json:"amount"`
+	Status      consts.TransferStatus `gorm:"size:16;not null;index" json:"status"`
+	RequestedBy uint                  `gorm:"not null" json:"requested_by"`
+	ApprovedBy  *uint                 `json:"approved_by"` // chủ thẻ nhận đồng ý/từ chối
+	OverrideBy  *uint                 `json:"override_by"` // nhân viên cho phép chuyển từ thẻ bị khóa/hết hạn
+	Reason      string                `json:"reason"`
+	CompletedAt *time.Time            `gorm:"index:idx_transfer_from_time" json:"completed_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+func MigrateTransfer() {
+	config.DB.AutoMigrate(&BalanceTransfer{})
+}
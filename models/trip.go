@@ -6,18 +6,22 @@ import (
 )
 
 type Trip struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	StartTime  time.Time `json:"start_time"`
-	EndTime    time.Time `json:"end_time"`
-	Direction  string    `json:"direction"`
-	TrainID    uint      `json:"train_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	StartTime   time.Time  `gorm:"uniqueIndex:idx_trip_pattern_start" json:"start_time"`
+	EndTime     time.Time  `json:"end_time"`
+	Direction   string     `json:"direction"`
+	TrainID     uint       `json:"train_id"`
+	PatternID   *uint      `gorm:"uniqueIndex:idx_trip_pattern_start" json:"pattern_id"` // nil với chuyến tạo thủ công
+	ServiceDate *time.Time `gorm:"type:date;index" json:"service_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Foreign key relationships
-	Train Train `gorm:"foreignKey:TrainID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"train"`
+	Train    Train           `gorm:"foreignKey:TrainID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"train"`
+	Pattern  *ServicePattern `gorm:"foreignKey:PatternID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"pattern,omitempty"`
+	Progress *TripProgress   `gorm:"foreignKey:TripID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"progress,omitempty"`
 }
 
 func MigrateTrip() {
 	config.DB.AutoMigrate(&Trip{})
-}
\ No newline at end of file
+}
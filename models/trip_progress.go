@@ -0,0 +1,48 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// TripProgress là trạng thái thời gian thực của một chuyến, do tàu (hoặc bộ giả lập) báo về
+type TripProgress struct {
+	ID                  uint              `gorm:"primaryKey" json:"id"`
+	TripID              uint              `gorm:"uniqueIndex;not null" json:"trip_id"`
+	CurrentStationID    *uint             `json:"current_station_id"`
+	CurrentStopSequence int               `json:"current_stop_sequence"`
+	Status              consts.TripStatus `gorm:"default:'scheduled'" json:"status"`
+	DelaySeconds        int               `json:"delay_seconds"`
+	Late                bool              `json:"late"`
+	ReportedBy          uint              `json:"reported_by"`
+	ReportedAt          time.Time         `json:"reported_at"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+
+	CurrentStation *Station `gorm:"foreignKey:CurrentStationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"current_station,omitempty"`
+}
+
+// StopPrediction là giờ đến/rời dự kiến của chuyến tại một ga
+type StopPrediction struct {
+	StopSequence       int       `json:"stop_sequence"`
+	StationID          uint      `json:"station_id"`
+	StationName        string    `json:"station_name"`
+	ScheduledArrival   time.Time `json:"scheduled_arrival"`
+	ScheduledDeparture time.Time `json:"scheduled_departure"`
+	PredictedArrival   time.Time `json:"predicted_arrival"`
+	PredictedDeparture time.Time `json:"predicted_departure"`
+	Passed             bool      `json:"passed"`
+	Late               bool      `json:"late"`
+}
+
+// TripProgressDetail là tiến độ của chuyến kèm giờ dự kiến tại các ga
+type TripProgressDetail struct {
+	Trip     Trip             `json:"trip"`
+	Progress TripProgress     `json:"progress"`
+	Stops    []StopPrediction `json:"stops"`
+}
+
+func MigrateTripProgress() {
+	config.DB.AutoMigrate(&TripProgress{})
+}
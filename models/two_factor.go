@@ -0,0 +1,35 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+// UserTOTP là secret TOTP (RFC 6238) của user.
+// Secret được tạo khi bắt đầu đăng ký và chỉ có hiệu lực sau khi user xác nhận bằng một mã đúng
+// (User.TwoFactorEnabledAt khác nil).
+type UserTOTP struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	Secret       string    `gorm:"size:64;not null" json:"-"`   // base32
+	LastUsedStep int64     `gorm:"not null;default:0" json:"-"` // bước thời gian của mã dùng gần nhất, chống dùng lại mã
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// RecoveryCode là mã khôi phục dùng một lần khi mất thiết bị TOTP. Chỉ lưu SHA-256 của mã.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateTwoFactor() {
+	config.DB.AutoMigrate(&UserTOTP{}, &RecoveryCode{})
+}
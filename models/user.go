@@ -1,25 +1,92 @@
 package models
 
 import (
+  "log"
+  "strings"
   "time"
 
   "go-metro/config"
   "go-metro/consts"
+
+  "gorm.io/gorm"
 )
 
 type User struct {
-  ID        uint        `gorm:"primaryKey" json:"id"`
-  Password  string      `gorm:"not null" json:"-"` // "-" để không trả về password trong JSON
-  Email     string      `gorm:"uniqueIndex" json:"email"`
-  FullName  string      `json:"full_name"`
-  Role      consts.Role `gorm:"default:3" json:"role"`          // "ADMIN", "STAFF", "USER"
-  Status    string      `gorm:"default:'active'" json:"status"` // "active" hoặc "inactive"
-  Avatar    string      `json:"avatar"`
-  Phone     string      `json:"phone"`
-  CreatedAt time.Time   `json:"created_at"`
-  UpdatedAt time.Time   `json:"updated_at"`
+  ID                 uint           `gorm:"primaryKey" json:"id"`
+  Password           string         `gorm:"not null" json:"-"` // "-" để không trả về password trong JSON
+  Email              string         `gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email"`
+  FullName           string         `json:"full_name"`
+  Role               consts.Role    `gorm:"default:3" json:"role"`          // "ADMIN", "STAFF", "USER"
+  Status             string         `gorm:"default:'active'" json:"status"` // "active" hoặc "inactive"
+  Avatar             string         `json:"avatar"`
+  Phone              string         `json:"phone"`
+  EmailVerifiedAt    *time.Time     `json:"email_verified_at"`     // nil: chưa xác minh email, bị giới hạn quyền
+  TwoFactorEnabledAt *time.Time     `json:"two_factor_enabled_at"` // nil: chưa bật xác thực hai bước (TOTP)
+  RoleGrantedAt      *time.Time     `json:"role_granted_at"`       // lần admin đổi role gần nhất; nil: role mặc định khi đăng ký
+  CreatedAt          time.Time      `json:"created_at"`
+  UpdatedAt          time.Time      `json:"updated_at"`
+  DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // xóa mềm; lịch sử vẫn tham chiếu được
+
+  // Các trạm nhân viên được phân công; admin không cần phân công (toàn mạng lưới)
+  Stations []Station `gorm:"many2many:user_stations;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"stations,omitempty"`
+}
+
+// EmailVerified cho biết user đã xác minh email hay chưa
+func (u User) EmailVerified() bool {
+  return u.EmailVerifiedAt != nil
+}
+
+// TwoFactorEnabled cho biết user đã bật xác thực hai bước hay chưa
+func (u User) TwoFactorEnabled() bool {
+  return u.TwoFactorEnabledAt != nil
 }
 
 func MigrateUser() {
+  // Tài khoản có trước khi bắt buộc xác minh email được coi là đã xác minh
+  backfillVerified := !config.DB.Migrator().HasColumn(&User{}, "email_verified_at")
+  // Đăng ký từng gán nhầm role staff; chạy một lần khi thêm cột role_granted_at
+  demoteSelfRegistered := config.DB.Migrator().HasTable(&User{}) && !config.DB.Migrator().HasColumn(&User{}, "role_granted_at")
+
+  // Email chỉ duy nhất giữa các tài khoản chưa xóa; unique index cũ chặn đăng ký lại email của tài khoản đã xóa mềm
+  if config.DB.Migrator().HasIndex(&User{}, "idx_users_email") {
+    config.DB.Migrator().DropIndex(&User{}, "idx_users_email")
+  }
+
   config.DB.AutoMigrate(&User{})
+
+  if demoteSelfRegistered {
+    demoteSelfRegisteredStaff()
+  }
+
+  if backfillVerified {
+    config.DB.Model(&User{}).Where("email_verified_at IS NULL").
+      UpdateColumn("email_verified_at", gorm.Expr("created_at"))
+  }
+}
+
+// demoteSelfRegisteredStaff chuyển các tài khoản role staff do tự đăng ký về role user. Tài khoản được coi là
+// nhân viên thật nếu đã được phân công trạm hoặc từng bán thẻ; các tài khoản này được đánh dấu đã được cấp role.
+func demoteSelfRegisteredStaff() {
+  var staffEvidence []string
+  if config.DB.Migrator().HasTable("user_stations") {
+    staffEvidence = append(staffEvidence, "EXISTS (SELECT 1 FROM user_stations WHERE user_stations.user_id = users.id)")
+  }
+  if config.DB.Migrator().HasTable("sell_histories") {
+    staffEvidence = append(staffEvidence, "EXISTS (SELECT 1 FROM sell_histories WHERE sell_histories.seller_id = users.id)")
+  }
+
+  staff := config.DB.Unscoped().Model(&User{}).Where("role = ?", consts.StaffRole)
+  if len(staffEvidence) > 0 {
+    evidence := strings.Join(staffEvidence, " OR ")
+    config.DB.Unscoped().Model(&User{}).Where("role = ?", consts.StaffRole).Where(evidence).
+      UpdateColumn("role_granted_at", time.Now())
+    staff = staff.Where("NOT (" + evidence + ")")
+  }
+
+  result := staff.UpdateColumn("role", consts.UserRole)
+  if result.Error != nil {
+    log.Println("⚠️ Failed to move self-registered accounts off the staff role:", result.Error)
+  } else if result.RowsAffected > 0 {
+    log.Printf("✅ Moved %d self-registered accounts from staff to user role", result.RowsAffected)
+  }
 }
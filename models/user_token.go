@@ -0,0 +1,31 @@
+package models
+
+import (
+	"go-metro/config"
+	"time"
+)
+
+// Mục đích của token
+const (
+	TokenEmailVerification = "email_verification"
+	TokenPasswordReset     = "password_reset"
+	TokenLoginChallenge    = "login_challenge" // đã đúng mật khẩu, chờ mã xác thực hai bước
+)
+
+// UserToken là token dùng một lần (xác minh email, đặt lại mật khẩu, bước hai của đăng nhập).
+// Chỉ lưu SHA-256 của token.
+type UserToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Purpose   string     `gorm:"size:32;not null;index" json:"purpose"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateUserToken() {
+	config.DB.AutoMigrate(&UserToken{})
+}
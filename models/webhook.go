@@ -0,0 +1,61 @@
+package models
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"time"
+)
+
+// WebhookSubscription là địa chỉ của đối tác nhận sự kiện nghiệp vụ qua HTTP POST.
+// Secret dùng để ký HMAC payload; chỉ trả về khi tạo hoặc đổi secret.
+type WebhookSubscription struct {
+	ID          uint                     `gorm:"primaryKey" json:"id"`
+	URL         string                   `gorm:"not null" json:"url"`
+	EventTypes  []consts.DomainEventType `gorm:"type:jsonb;serializer:json" json:"event_types"` // "*": mọi loại sự kiện
+	Description string                   `json:"description"`
+	Active      bool                     `gorm:"not null;default:true" json:"active"`
+	Secret      string                   `gorm:"size:128;not null" json:"-"`
+	CreatedBy   *uint                    `json:"created_by"`
+	CreatedAt   time.Time                `json:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+
+	// SigningSecret chỉ có giá trị trong response tạo/đổi secret
+	SigningSecret string `gorm:"-" json:"secret,omitempty"`
+}
+
+// WebhookAllEvents trong EventTypes đăng ký mọi loại sự kiện
+const WebhookAllEvents consts.DomainEventType = "*"
+
+// Wants cho biết subscription có nhận loại sự kiện eventType hay không
+func (s WebhookSubscription) Wants(eventType consts.DomainEventType) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType || t == WebhookAllEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery là một lần gửi sự kiện tới một subscription. Payload là JSON của sự kiện,
+// được ký đúng theo các byte này ở mỗi lần gửi.
+type WebhookDelivery struct {
+	ID             uint                         `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint                         `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"subscription_id"`
+	EventID        string                       `gorm:"size:36;not null;uniqueIndex:idx_webhook_delivery_event" json:"event_id"`
+	EventType      consts.DomainEventType       `gorm:"size:64;not null" json:"event_type"`
+	Payload        string                       `gorm:"type:text;not null" json:"payload"`
+	Status         consts.WebhookDeliveryStatus `gorm:"size:16;not null;index:idx_webhook_delivery_due" json:"status"`
+	Attempts       int                          `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time                    `gorm:"index:idx_webhook_delivery_due" json:"next_attempt_at"`
+	LastStatusCode int                          `json:"last_status_code"`
+	LastError      string                       `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time                   `json:"delivered_at"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	UpdatedAt      time.Time                    `json:"updated_at"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func MigrateWebhook() {
+	config.DB.AutoMigrate(&WebhookSubscription{}, &WebhookDelivery{})
+}
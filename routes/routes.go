@@ -1,6 +1,7 @@
 package routes
 
 import (
+  "go-metro/consts"
   "go-metro/handlers"
   "go-metro/utils"
 
@@ -9,8 +10,15 @@ import (
 
 // SetupRoutes configures all routes for the application
 func SetupRoutes(r *gin.Engine) {
+  auth := utils.AuthMiddleware()
+  can := utils.RequirePermission
+  audit := utils.Audit
+  device := utils.DeviceAuthMiddleware()
+  verified := utils.RequireVerifiedEmail()
+
   // History routes (read-only)
   historyGroup := r.Group("/history")
+  historyGroup.Use(auth, can(consts.PermissionReportRead))
   {
     historyGroup.GET("", handlers.GetHistories)
     historyGroup.GET("/:id", handlers.GetHistoryByID)
@@ -18,6 +26,7 @@ func SetupRoutes(r *gin.Engine) {
 
   // Sell History routes (read-only)
   sellHistoryGroup := r.Group("/sell-history")
+  sellHistoryGroup.Use(auth, can(consts.PermissionReportRead))
   {
     sellHistoryGroup.GET("", handlers.GetSellHistories)
     sellHistoryGroup.GET("/:id", handlers.GetSellHistoryByID)
@@ -27,6 +36,7 @@ func SetupRoutes(r *gin.Engine) {
 
   // Station History routes (read-only)
   stationHistoryGroup := r.Group("/station-history")
+  stationHistoryGroup.Use(auth, can(consts.PermissionReportRead))
   {
     stationHistoryGroup.GET("", handlers.GetStationHistories)
     stationHistoryGroup.GET("/:id", handlers.GetStationHistoryByID)
@@ -35,83 +45,261 @@ func SetupRoutes(r *gin.Engine) {
     stationHistoryGroup.GET("/action/:action", handlers.GetStationHistoriesByAction)
   }
 
-  // Trip routes
+  // Dữ liệu vận hành công khai (trip, train, line, schedule, station, GTFS export, alerts) được ứng dụng
+  // hành khách và bảng giờ tàu đọc không cần đăng nhập, nên các route GET này cố ý không có auth/can;
+  // mọi route ghi đều cần quyền tương ứng
+  // Trip routes (đọc công khai, ghi cần quyền)
   tripGroup := r.Group("/trip")
   {
-    tripGroup.POST("", handlers.CreateTrip)
+    tripGroup.POST("", auth, can(consts.PermissionTripManage), audit("trip.create", "trip"), handlers.CreateTrip)
     tripGroup.GET("", handlers.GetTrips)
     tripGroup.GET("/:id", handlers.GetTripByID)
-    tripGroup.PUT("/:id", handlers.UpdateTrip)
-    tripGroup.DELETE("/:id", handlers.DeleteTrip)
+    tripGroup.PUT("/:id", auth, can(consts.PermissionTripManage), audit("trip.update", "trip"), handlers.UpdateTrip)
+    tripGroup.DELETE("/:id", auth, can(consts.PermissionTripManage), audit("trip.delete", "trip"), handlers.DeleteTrip)
     tripGroup.GET("/train/:train_id", handlers.GetTripsByTrainID)
     tripGroup.GET("/direction/:direction", handlers.GetTripsByDirection)
     tripGroup.GET("/active", handlers.GetActiveTrips)
+    tripGroup.GET("/:id/progress", handlers.GetTripProgress)
+    tripGroup.POST("/:id/position", auth, can(consts.PermissionTripReport), handlers.ReportTripPosition)
   }
 
-  // Train routes
+  // Train routes (đọc công khai, ghi cần quyền)
   trainGroup := r.Group("/train")
   {
-    trainGroup.POST("", handlers.CreateTrain)
+    trainGroup.POST("", auth, can(consts.PermissionTrainManage), audit("train.create", "train"), handlers.CreateTrain)
     trainGroup.GET("", handlers.GetTrains)
     trainGroup.GET("/:id", handlers.GetTrainByID)
-    trainGroup.PUT("/:id", handlers.UpdateTrain)
-    trainGroup.DELETE("/:id", handlers.DeleteTrain)
+    trainGroup.PUT("/:id", auth, can(consts.PermissionTrainManage), audit("train.update", "train"), handlers.UpdateTrain)
+    trainGroup.DELETE("/:id", auth, can(consts.PermissionTrainManage), audit("train.delete", "train"), handlers.DeleteTrain)
     trainGroup.GET("/type/:type", handlers.GetTrainsByType)
     trainGroup.GET("/company/:company", handlers.GetTrainsByCompany)
   }
 
+  // Line routes (đọc công khai, ghi cần quyền)
+  lineGroup := r.Group("/line")
+  {
+    lineGroup.POST("", auth, can(consts.PermissionScheduleManage), audit("line.create", "line"), handlers.CreateLine)
+    lineGroup.GET("", handlers.GetLines)
+    lineGroup.GET("/:id", handlers.GetLineByID)
+    lineGroup.PUT("/:id", auth, can(consts.PermissionScheduleManage), audit("line.update", "line"), handlers.UpdateLine)
+    lineGroup.DELETE("/:id", auth, can(consts.PermissionScheduleManage), audit("line.delete", "line"), handlers.DeleteLine)
+  }
+
+  // Schedule routes (service patterns → generated trips; đọc công khai, ghi cần quyền)
+  scheduleGroup := r.Group("/schedule")
+  {
+    scheduleGroup.POST("/patterns", auth, can(consts.PermissionScheduleManage), audit("schedule.pattern.create", "service_pattern"), handlers.CreateServicePattern)
+    scheduleGroup.GET("/patterns", handlers.GetServicePatterns)
+    scheduleGroup.GET("/patterns/:id", handlers.GetServicePatternByID)
+    scheduleGroup.PUT("/patterns/:id", auth, can(consts.PermissionScheduleManage), audit("schedule.pattern.update", "service_pattern"), handlers.UpdateServicePattern)
+    scheduleGroup.DELETE("/patterns/:id", auth, can(consts.PermissionScheduleManage), audit("schedule.pattern.delete", "service_pattern"), handlers.DeleteServicePattern)
+    scheduleGroup.POST("/patterns/:id/exceptions", auth, can(consts.PermissionScheduleManage), audit("schedule.exception.create", "service_pattern"), handlers.AddServiceException)
+    scheduleGroup.DELETE("/exceptions/:id", auth, can(consts.PermissionScheduleManage), audit("schedule.exception.delete", "service_exception"), handlers.DeleteServiceException)
+    scheduleGroup.POST("/generate", auth, can(consts.PermissionScheduleManage), audit("schedule.generate", "schedule"), handlers.GenerateScheduledTrips)
+  }
+
+  // GTFS static feed routes (xuất công khai cho ứng dụng hành khách, nhập cần quyền)
+  gtfsGroup := r.Group("/gtfs")
+  {
+    gtfsGroup.GET("/export", handlers.ExportGTFS)                                                                                 // Xuất GTFS zip
+    gtfsGroup.POST("/import", auth, can(consts.PermissionScheduleManage), audit("gtfs.import", "gtfs_feed"), handlers.ImportGTFS) // Nhập GTFS zip
+  }
+
   // Card routes
   cardGroup := r.Group("/card")
+  cardGroup.Use(auth)
   {
-    cardGroup.POST("", handlers.CreateCard)                     // Tạo card mới
-    cardGroup.GET("", handlers.GetCards)                        // Lấy danh sách tất cả cards
-    cardGroup.GET("/:id", handlers.GetCardByID)                 // Lấy card theo ID
-    cardGroup.GET("/cardid/:rf_id", handlers.GetCardByCardID)   // Lấy card theo CardID
-    cardGroup.PUT("/:rf_id", handlers.UpdateCard)               // Cập nhật card
-    cardGroup.DELETE("/:id", handlers.DeleteCard)               // Xóa card
-    cardGroup.POST("/:rf_id/topup", handlers.TopUpCard)         // Nạp tiền vào card
-    cardGroup.GET("/user/:owner_id", handlers.GetCardsByUser)   // Lấy cards theo owner_id
-    cardGroup.GET("/status/:status", handlers.GetCardsByStatus) // Lấy cards theo status
+    cardGroup.POST("", can(consts.PermissionCardCreate), audit("card.create", "card"), handlers.CreateCard)                            // Tạo card mới
+    cardGroup.GET("", can(consts.PermissionCardRead), handlers.GetCards)                                                               // Lấy danh sách tất cả cards
+    cardGroup.GET("/:id", can(consts.PermissionCardRead), handlers.GetCardByID)                                                        // Lấy card theo ID
+    cardGroup.GET("/cardid/:rf_id", can(consts.PermissionCardRead), handlers.GetCardByCardID)                                          // Lấy card theo CardID
+    cardGroup.PUT("/:rf_id", can(consts.PermissionCardUpdate), audit("card.update", "card"), handlers.UpdateCard)                      // Cập nhật card
+    cardGroup.DELETE("/:id", can(consts.PermissionCardDelete), audit("card.delete", "card"), handlers.DeleteCard)                      // Xóa card
+    cardGroup.POST("/:rf_id/topup", can(consts.PermissionCardTopup), audit("card.topup", "card"), handlers.TopUpCard)                  // Nạp tiền vào card
+    cardGroup.POST("/:rf_id/block", can(consts.PermissionCardUpdate), audit("card.block", "card"), handlers.BlockCard)                 // Khóa card (đưa vào hotlist)
+    cardGroup.POST("/:rf_id/unblock", can(consts.PermissionCardUpdate), audit("card.unblock", "card"), handlers.UnblockCard)           // Mở khóa card
+    cardGroup.POST("/:rf_id/refund", can(consts.PermissionCardUpdate), audit("card.refund", "card"), handlers.RefundCard)              // Hoàn tiền và thu hồi thẻ đã đăng ký
+    cardGroup.POST("/:rf_id/replace", can(consts.PermissionCardCreate), audit("card.replace", "card"), handlers.ReplaceCard)           // Cấp thẻ mới thay thẻ đã đăng ký
+    cardGroup.POST("/transfers", can(consts.PermissionCardTransfer), audit("card.transfer", "transfer"), handlers.CreateStaffTransfer) // Chuyển số dư giữa thẻ cùng chủ tại quầy (override thẻ bị khóa/hết hạn)
+    cardGroup.PUT("/:rf_id/expiry", can(consts.PermissionCardUpdate), audit("card.expiry", "card"), handlers.SetCardExpiry)            // Đặt hạn dùng của thẻ
+    cardGroup.GET("/user/:owner_id", can(consts.PermissionCardRead), handlers.GetCardsByUser)                                          // Lấy cards theo owner_id
+    cardGroup.GET("/status/:status", can(consts.PermissionCardRead), handlers.GetCardsByStatus)                                        // Lấy cards theo status
   }
 
   // Auth routes (public)
   authGroup := r.Group("/auth")
   {
-    authGroup.POST("/register", handlers.Register) // Đăng ký
-    authGroup.POST("/login", handlers.Login)       // Đăng nhập
+    authGroup.POST("/register", handlers.Register)                      // Đăng ký
+    authGroup.POST("/login", handlers.Login)                            // Đăng nhập
+    authGroup.POST("/login/2fa", handlers.LoginTwoFactor)               // Bước hai: mã TOTP hoặc mã khôi phục
+    authGroup.POST("/refresh", handlers.RefreshToken)                   // Đổi refresh token lấy cặp token mới
+    authGroup.POST("/logout", handlers.Logout)                          // Thu hồi phiên đăng nhập
+    authGroup.POST("/verify-email", handlers.VerifyEmail)               // Xác minh email
+    authGroup.POST("/resend-verification", handlers.ResendVerification) // Gửi lại email xác minh
+    authGroup.POST("/password/forgot", handlers.ForgotPassword)         // Yêu cầu đặt lại mật khẩu
+    authGroup.POST("/password/reset", handlers.ResetPassword)           // Đặt mật khẩu mới bằng token
   }
 
-  // User routes (require authentication)
+  // User routes (require authentication, thao tác trên chính tài khoản nên không cần quyền riêng)
   userGroup := r.Group("/user")
-  userGroup.Use(utils.AuthMiddleware())
+  userGroup.Use(auth)
+  {
+    userGroup.GET("/profile", handlers.GetProfile)                // Xem profile (kể cả khi chưa xác minh email)
+    userGroup.PUT("/profile", verified, handlers.UpdateProfile)   // Cập nhật profile
+    userGroup.PUT("/password", verified, handlers.ChangePassword) // Đổi mật khẩu
+
+    userGroup.GET("/2fa", handlers.GetTwoFactorStatus)                                // Trạng thái xác thực hai bước
+    userGroup.POST("/2fa/setup", verified, handlers.SetupTwoFactor)                   // Tạo secret TOTP
+    userGroup.POST("/2fa/enable", verified, handlers.EnableTwoFactor)                 // Xác nhận mã, bật 2FA
+    userGroup.POST("/2fa/disable", verified, handlers.DisableTwoFactor)               // Tắt 2FA
+    userGroup.POST("/2fa/recovery-codes", verified, handlers.RegenerateRecoveryCodes) // Tạo lại mã khôi phục
+  }
+
+  // Self-service routes cho khách: chỉ thấy thẻ và chuyến đi của chính mình (theo user_id trong JWT)
+  meGroup := r.Group("/me")
+  meGroup.Use(auth)
+  {
+    meGroup.GET("/cards", handlers.GetMyCards)                                          // Thẻ của tôi
+    meGroup.POST("/cards/claim", verified, handlers.ClaimMyCard)                        // Nhận thẻ vô danh bằng mã xác minh
+    meGroup.GET("/cards/:rf_id/history", handlers.GetMyCardHistory)                     // Lượt quẹt của thẻ
+    meGroup.POST("/cards/:rf_id/block", verified, handlers.BlockMyCard)                 // Khóa thẻ bị mất
+    meGroup.GET("/journeys", handlers.GetMyJourneys)                                    // Chuyến đi (ghép lượt vào/ra)
+    meGroup.GET("/transfers", handlers.GetMyTransfers)                                  // Các lần chuyển số dư đã gửi/nhận
+    meGroup.POST("/transfers", verified, handlers.CreateMyTransfer)                     // Chuyển số dư sang thẻ khác
+    meGroup.POST("/transfers/:id/approve", verified, handlers.ApproveMyTransfer)        // Đồng ý nhận tiền chuyển đến
+    meGroup.POST("/transfers/:id/reject", verified, handlers.RejectMyTransfer)          // Từ chối nhận tiền chuyển đến
+    meGroup.POST("/transfers/:id/cancel", verified, handlers.CancelMyTransfer)          // Hủy yêu cầu chuyển chưa được duyệt
+    meGroup.GET("/notifications", handlers.GetMyNotifications)                          // Thông báo đã gửi/chờ gửi
+    meGroup.GET("/notifications/preferences", handlers.GetMyNotificationPreferences)    // Lựa chọn nhận thông báo
+    meGroup.PUT("/notifications/preferences", handlers.UpdateMyNotificationPreferences) // Đổi loại thông báo, ngưỡng số dư, kênh gửi
+  }
+
+  // Tài khoản tổ chức của người dùng hiện tại (quyền theo vai trò trong tổ chức)
+  orgGroup := r.Group("/org")
+  orgGroup.Use(auth, verified)
   {
-    userGroup.GET("/profile", handlers.GetProfile)      // Xem profile
-    userGroup.PUT("/profile", handlers.UpdateProfile)   // Cập nhật profile
-    userGroup.PUT("/password", handlers.ChangePassword) // Đổi mật khẩu
+    orgGroup.GET("", handlers.GetMyOrganization)                             // Tổ chức, thành viên và thẻ
+    orgGroup.PUT("/cards/:rf_id/limit", handlers.SetMyOrganizationCardLimit) // Hạn mức nạp tháng từ ví chung của thẻ (owner/manager)
+    orgGroup.POST("/cards/:rf_id/topup", handlers.TopUpMyOrganizationCard)   // Nạp tiền cho thẻ từ ví chung
+    orgGroup.GET("/statements/:month", handlers.GetMyOrganizationStatement)  // Sao kê tháng (owner/manager)
   }
 
-  // Admin routes (require admin role)
+  // Admin routes
   adminGroup := r.Group("/admin")
-  adminGroup.Use(utils.AuthMiddleware(), utils.AdminMiddleware())
+  adminGroup.Use(auth)
   {
-    adminGroup.GET("/users", handlers.GetAllUsers)              // Lấy tất cả users
-    adminGroup.GET("/users/simple", handlers.GetAllUsersSimple) // Lấy tất cả users
-    adminGroup.GET("/users/statistics", handlers.GetUserStatisticsOptimized)
-    adminGroup.GET("/users/:id", handlers.GetUserByID)   // Lấy user theo ID
-    adminGroup.PUT("/users/:id", handlers.UpdateUser)    // Cập nhật user
-    adminGroup.DELETE("/users/:id", handlers.DeleteUser) // Xóa user
+    adminUserGroup := adminGroup.Group("/users", can(consts.PermissionUserManage))
+    adminUserGroup.GET("", handlers.GetAllUsers)              // Lấy tất cả users
+    adminUserGroup.GET("/simple", handlers.GetAllUsersSimple) // Lấy tất cả users
+    adminUserGroup.GET("/statistics", handlers.GetUserStatisticsOptimized)
+    adminUserGroup.GET("/:id", handlers.GetUserByID)                                                       // Lấy user theo ID
+    adminUserGroup.PUT("/:id", audit("user.update", "user"), handlers.UpdateUser)                          // Cập nhật user
+    adminUserGroup.DELETE("/:id", audit("user.delete", "user"), handlers.DeleteUser)                       // Xóa user
+    adminUserGroup.GET("/:id/stations", handlers.GetUserStations)                                          // Trạm được phân công
+    adminUserGroup.PUT("/:id/stations", audit("user.stations", "user"), handlers.UpdateUserStations)       // Phân công trạm cho nhân viên
+    adminUserGroup.POST("/:id/unlock", audit("user.unlock", "user"), handlers.UnlockUserLogin)             // Mở khóa đăng nhập sai nhiều lần
+    adminUserGroup.DELETE("/:id/2fa", audit("user.two_factor_reset", "user"), handlers.ResetUserTwoFactor) // Gỡ 2FA khi user mất thiết bị
+
+    adminSecurityGroup := adminGroup.Group("/security", can(consts.PermissionUserManage))
+    adminSecurityGroup.GET("/lockouts", handlers.GetLoginLockouts)                     // Tài khoản/IP đang bị chặn
+    adminSecurityGroup.GET("/events", handlers.GetSecurityEvents)                      // Nhật ký sự kiện bảo mật
+    adminSecurityGroup.POST("/unlock-ip", audit("ip.unlock", "ip"), handlers.UnlockIP) // Mở khóa IP
+
+    adminAlertGroup := adminGroup.Group("/alerts", can(consts.PermissionAlertManage))
+    adminAlertGroup.POST("", audit("alert.create", "service_alert"), handlers.CreateServiceAlert)               // Tạo thông báo vận hành
+    adminAlertGroup.PUT("/:id", audit("alert.update", "service_alert"), handlers.UpdateServiceAlert)            // Cập nhật thông báo
+    adminAlertGroup.POST("/:id/resolve", audit("alert.resolve", "service_alert"), handlers.ResolveServiceAlert) // Kết thúc thông báo
+
+    adminAuditGroup := adminGroup.Group("/audit", can(consts.PermissionAuditRead))
+    adminAuditGroup.GET("", handlers.GetAuditLogs)          // Nhật ký audit thao tác quản trị
+    adminAuditGroup.GET("/verify", handlers.VerifyAuditLog) // Kiểm tra chuỗi hash của nhật ký
+
+    // Tài khoản gia đình/doanh nghiệp với ví chung
+    adminOrgGroup := adminGroup.Group("/orgs", can(consts.PermissionOrgManage))
+    adminOrgGroup.POST("", audit("org.create", "organization"), handlers.CreateOrganization)
+    adminOrgGroup.GET("", handlers.GetOrganizations)
+    adminOrgGroup.GET("/:id", handlers.GetOrganizationByID)
+    adminOrgGroup.POST("/:id/members", audit("org.member.set", "organization"), handlers.SetOrganizationMember)
+    adminOrgGroup.DELETE("/:id/members/:user_id", audit("org.member.remove", "organization"), handlers.RemoveOrganizationMember)
+    adminOrgGroup.POST("/:id/cards", audit("org.card.add", "organization"), handlers.AddOrganizationCard)
+    adminOrgGroup.DELETE("/:id/cards/:rf_id", audit("org.card.remove", "organization"), handlers.RemoveOrganizationCard)
+    adminOrgGroup.POST("/:id/wallet/deposit", audit("org.wallet.deposit", "organization"), handlers.DepositOrganizationWallet)
+    adminOrgGroup.GET("/:id/statements/:month", handlers.GetOrganizationStatement)
+
+    // Webhook gửi sự kiện thẻ và hành trình cho ứng dụng đối tác
+    adminWebhookGroup := adminGroup.Group("/webhooks", can(consts.PermissionWebhookManage))
+    adminWebhookGroup.POST("", audit("webhook.create", "webhook"), handlers.CreateWebhookSubscription)
+    adminWebhookGroup.GET("", handlers.GetWebhookSubscriptions)
+    adminWebhookGroup.GET("/dead-letters", handlers.GetWebhookDeadLetters) // Lần gửi đã hết số lần thử
+    adminWebhookGroup.GET("/:id", handlers.GetWebhookSubscriptionByID)
+    adminWebhookGroup.PUT("/:id", audit("webhook.update", "webhook"), handlers.UpdateWebhookSubscription)
+    adminWebhookGroup.DELETE("/:id", audit("webhook.delete", "webhook"), handlers.DeleteWebhookSubscription)
+    adminWebhookGroup.POST("/:id/rotate-secret", audit("webhook.rotate_secret", "webhook"), handlers.RotateWebhookSecret)
+    adminWebhookGroup.GET("/:id/deliveries", handlers.GetWebhookDeliveries)
+    adminWebhookGroup.POST("/deliveries/:id/redeliver", audit("webhook.redeliver", "webhook_delivery"), handlers.RedeliverWebhook) // Gửi lại thủ công
+
+    // Bản ghi đã xóa mềm, khôi phục được cho tới khi hết hạn lưu giữ
+    deletedGroup := adminGroup.Group("/deleted")
+    deletedGroup.GET("/users", can(consts.PermissionUserManage), handlers.GetDeletedUsers)
+    deletedGroup.POST("/users/:id/restore", can(consts.PermissionUserManage), audit("user.restore", "user"), handlers.RestoreUser)
+    deletedGroup.GET("/cards", can(consts.PermissionCardDelete), handlers.GetDeletedCards)
+    deletedGroup.POST("/cards/:id/restore", can(consts.PermissionCardDelete), audit("card.restore", "card"), handlers.RestoreCard)
+    deletedGroup.GET("/stations", can(consts.PermissionStationManage), handlers.GetDeletedStations)
+    deletedGroup.POST("/stations/:id/restore", can(consts.PermissionStationManage), audit("station.restore", "station"), handlers.RestoreStation)
+    deletedGroup.GET("/trains", can(consts.PermissionTrainManage), handlers.GetDeletedTrains)
+    deletedGroup.POST("/trains/:id/restore", can(consts.PermissionTrainManage), audit("train.restore", "train"), handlers.RestoreTrain)
+
+    adminGroup.GET("/permissions", can(consts.PermissionRoleManage), handlers.GetPermissions)                                                       // Bảng phân quyền
+    adminGroup.GET("/roles/:role/permissions", can(consts.PermissionRoleManage), handlers.GetRolePermissions)                                       // Quyền của một role
+    adminGroup.PUT("/roles/:role/permissions", can(consts.PermissionRoleManage), audit("role.permissions", "role"), handlers.UpdateRolePermissions) // Thay quyền của một role
   }
 
-  // Station routes
+  // Service alert routes (public)
+  alertGroup := r.Group("/alerts")
+  {
+    alertGroup.GET("", handlers.GetServiceAlerts)        // Danh sách thông báo (mặc định đang hiệu lực)
+    alertGroup.GET("/:id", handlers.GetServiceAlertByID) // Lấy thông báo theo ID
+  }
+
+  // Station routes (đọc công khai, ghi cần quyền)
   stationGroup := r.Group("/station")
   {
-    stationGroup.POST("", handlers.CreateStation)         // Tạo station mới
-    stationGroup.GET("", handlers.GetStations)            // Lấy danh sách tất cả station
-    stationGroup.GET("/:id", handlers.GetStationByID)     // Lấy station theo ID
-    stationGroup.PUT("/:id", handlers.UpdateStation)      // Cập nhật station
-    stationGroup.DELETE("/:id", handlers.DeleteStation)   // Xóa station
-    stationGroup.POST("/:id/checkin", handlers.CheckIn)   // Check-in tại trạm
-    stationGroup.POST("/:id/checkout", handlers.CheckOut) // Check-out tại trạm
+    stationGroup.POST("", auth, can(consts.PermissionStationManage), audit("station.create", "station"), handlers.CreateStation)       // Tạo station mới
+    stationGroup.GET("", handlers.GetStations)                                                                                         // Lấy danh sách tất cả station
+    stationGroup.GET("/:id", handlers.GetStationByID)                                                                                  // Lấy station theo ID
+    stationGroup.PUT("/:id", auth, can(consts.PermissionStationManage), audit("station.update", "station"), handlers.UpdateStation)    // Cập nhật station
+    stationGroup.DELETE("/:id", auth, can(consts.PermissionStationManage), audit("station.delete", "station"), handlers.DeleteStation) // Xóa station
+    stationGroup.GET("/:id/departures", handlers.GetStationDepartures)                                                                 // Các chuyến sắp rời trạm
+    stationGroup.GET("/:id/board/stream", handlers.StreamStationBoard)                                                                 // Bảng giờ tàu trực tiếp (SSE)
+    stationGroup.POST("/:id/checkin", device, handlers.CheckIn)                                                                        // Check-in tại trạm (cổng soát vé)
+    stationGroup.POST("/:id/checkout", device, handlers.CheckOut)                                                                      // Check-out tại trạm (cổng soát vé)
+    stationGroup.POST("/:id/gate/batch", device, handlers.UploadOfflineTaps)                                                           // Gửi bù lượt quẹt ngoại tuyến (cổng soát vé)
+  }
+
+  // Device routes (đăng ký cổng soát vé, máy bán vé)
+  deviceGroup := r.Group("/device")
+  deviceGroup.Use(auth, can(consts.PermissionDeviceManage))
+  {
+    deviceGroup.POST("", audit("device.create", "device"), handlers.CreateDevice)                       // Đăng ký thiết bị
+    deviceGroup.GET("", handlers.GetDevices)                                                            // Danh sách thiết bị
+    deviceGroup.GET("/fleet", handlers.GetFleetStatus)                                                  // Tình trạng kết nối toàn bộ thiết bị
+    deviceGroup.GET("/:id", handlers.GetDeviceByID)                                                     // Lấy thiết bị theo ID
+    deviceGroup.PUT("/:id", audit("device.update", "device"), handlers.UpdateDevice)                    // Cập nhật thiết bị
+    deviceGroup.DELETE("/:id", audit("device.delete", "device"), handlers.DeleteDevice)                 // Xóa thiết bị
+    deviceGroup.POST("/:id/rotate-key", audit("device.rotate_key", "device"), handlers.RotateDeviceKey) // Cấp lại secret
+    deviceGroup.GET("/:id/taps", handlers.GetDeviceTaps)                                                // Lượt quẹt ngoại tuyến đã gửi bù
+    deviceGroup.GET("/:id/heartbeats", handlers.GetDeviceHeartbeats)                                    // Lịch sử heartbeat
+  }
+
+  // Thiết bị tự báo cáo tình trạng (xác thực bằng key thiết bị)
+  r.POST("/device/heartbeat", device, handlers.PostHeartbeat)
+
+  // Hotlist routes (danh sách thẻ bị chặn cho cổng soát vé)
+  hotlistGroup := r.Group("/hotlist")
+  {
+    hotlistGroup.GET("", device, handlers.GetHotlist)           // Tải hotlist đã ký (toàn bộ hoặc delta)
+    hotlistGroup.GET("/stream", device, handlers.StreamHotlist) // Nhận phiên bản hotlist mới (SSE)
+    hotlistGroup.GET("/key", handlers.GetHotlistKey)            // Khóa công khai kiểm tra chữ ký
   }
 
   // Health check route
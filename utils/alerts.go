@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActiveAlerts giới hạn truy vấn ServiceAlert vào các thông báo đang hiệu lực tại thời điểm at
+func ActiveAlerts(at time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("service_alerts.resolved_at IS NULL").
+			Where("service_alerts.active_from <= ?", at).
+			Where("service_alerts.active_until IS NULL OR service_alerts.active_until > ?", at)
+	}
+}
+
+// AlertsForStation giới hạn truy vấn ServiceAlert vào các thông báo ảnh hưởng tới trạm,
+// trực tiếp hoặc qua tuyến/chuyến có dừng tại trạm
+func AlertsForStation(stationID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"service_alerts.id IN (SELECT service_alert_id FROM service_alert_stations WHERE station_id = ?) OR "+
+				"service_alerts.id IN (SELECT sal.service_alert_id FROM service_alert_lines sal JOIN service_patterns sp ON sp.line_id = sal.line_id JOIN pattern_stops ps ON ps.pattern_id = sp.id WHERE ps.station_id = ?) OR "+
+				"service_alerts.id IN (SELECT sat.service_alert_id FROM service_alert_trips sat JOIN trips t ON t.id = sat.trip_id JOIN pattern_stops ps ON ps.pattern_id = t.pattern_id WHERE ps.station_id = ?)",
+			stationID, stationID, stationID,
+		)
+	}
+}
+
+// GetActiveStationAlerts trả về các thông báo đang hiệu lực ảnh hưởng tới trạm
+func GetActiveStationAlerts(stationID uint) ([]models.ServiceAlert, error) {
+	alerts := []models.ServiceAlert{}
+	err := config.DB.Scopes(ActiveAlerts(time.Now()), AlertsForStation(stationID)).
+		Order("service_alerts.active_from DESC").
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// closedStationIDs trả về ID các trạm đang có thông báo đóng ga hiệu lực
+func closedStationIDs(stationIDs []uint) (map[uint]bool, error) {
+	var ids []uint
+	err := config.DB.Table("service_alert_stations").
+		Select("service_alert_stations.station_id").
+		Joins("JOIN service_alerts ON service_alerts.id = service_alert_stations.service_alert_id").
+		Scopes(ActiveAlerts(time.Now())).
+		Where("service_alerts.effect = ?", consts.AlertEffectStationClosure).
+		Where("service_alert_stations.station_id IN ?", stationIDs).
+		Pluck("service_alert_stations.station_id", &ids).Error
+
+	closed := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		closed[id] = true
+	}
+	return closed, err
+}
+
+// FlagClosedStations đánh dấu Closed cho các trạm có status đóng cửa hoặc đang có thông báo đóng ga
+func FlagClosedStations(stations []models.Station) error {
+	if len(stations) == 0 {
+		return nil
+	}
+
+	stationIDs := make([]uint, 0, len(stations))
+	for _, station := range stations {
+		stationIDs = append(stationIDs, station.ID)
+	}
+
+	closed, err := closedStationIDs(stationIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range stations {
+		stations[i].Closed = consts.StationStatus(stations[i].Status).IsClosure() || closed[stations[i].ID]
+	}
+	return nil
+}
+
+// IsStationClosed cho biết trạm có đang đóng cửa với hành khách hay không
+func IsStationClosed(station *models.Station) (bool, error) {
+	stations := []models.Station{*station}
+	if err := FlagClosedStations(stations); err != nil {
+		return false, err
+	}
+	station.Closed = stations[0].Closed
+	return station.Closed, nil
+}
+
+// AlertStationIDs trả về tất cả các trạm bị ảnh hưởng bởi thông báo (trực tiếp, qua tuyến hoặc qua chuyến)
+func AlertStationIDs(alert models.ServiceAlert) ([]uint, error) {
+	seen := make(map[uint]bool)
+	for _, station := range alert.Stations {
+		seen[station.ID] = true
+	}
+
+	var lineIDs, patternIDs []uint
+	for _, line := range alert.Lines {
+		lineIDs = append(lineIDs, line.ID)
+	}
+	for _, trip := range alert.Trips {
+		if trip.PatternID != nil {
+			patternIDs = append(patternIDs, *trip.PatternID)
+		}
+	}
+
+	if len(lineIDs) > 0 || len(patternIDs) > 0 {
+		var ids []uint
+		query := config.DB.Model(&models.PatternStop{}).Distinct("pattern_stops.station_id")
+		switch {
+		case len(lineIDs) > 0 && len(patternIDs) > 0:
+			query = query.Where("pattern_id IN (?) OR pattern_id IN ?",
+				config.DB.Model(&models.ServicePattern{}).Select("id").Where("line_id IN ?", lineIDs), patternIDs)
+		case len(lineIDs) > 0:
+			query = query.Where("pattern_id IN (?)",
+				config.DB.Model(&models.ServicePattern{}).Select("id").Where("line_id IN ?", lineIDs))
+		default:
+			query = query.Where("pattern_id IN ?", patternIDs)
+		}
+		if err := query.Pluck("pattern_stops.station_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+
+	stationIDs := make([]uint, 0, len(seen))
+	for id := range seen {
+		stationIDs = append(stationIDs, id)
+	}
+	return stationIDs, nil
+}
+
+// BroadcastAlert đẩy thông báo tới bảng giờ tàu của các trạm bị ảnh hưởng
+func BroadcastAlert(alert models.ServiceAlert) error {
+	stationIDs, err := AlertStationIDs(alert)
+	if err != nil {
+		return err
+	}
+
+	for _, stationID := range stationIDs {
+		Boards.Publish(stationID, "alert", alert)
+	}
+	Boards.MarkDirty(stationIDs...)
+	return nil
+}
@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// auditLockKey là khóa advisory tuần tự hóa việc ghi audit để chuỗi hash không bị rẽ nhánh
+	auditLockKey = 0x61756474
+
+	auditContextKey = "audit"
+)
+
+// errStopAuditVerify dừng duyệt khi đã tìm thấy chỗ gãy đầu tiên
+var errStopAuditVerify = errors.New("audit chain broken")
+
+// auditIgnoredFields không tính là thay đổi khi so sánh trước/sau
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditRecord là thông tin handler bổ sung cho bản ghi audit của request hiện tại
+type auditRecord struct {
+	targetID    string
+	before      map[string]interface{}
+	after       map[string]interface{}
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// auditResponseWriter giữ response của handler lại cho tới khi transaction được commit, để trả 500
+// thay cho response thành công nếu không ghi được audit
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *auditResponseWriter) WriteHeaderNow() {}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *auditResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *auditResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *auditResponseWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// flush gửi response đã giữ lại qua writer gốc
+func (w *auditResponseWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Println("⚠️ Failed to write response:", err)
+	}
+}
+
+// Audit middleware ghi nhật ký audit cho một thao tác quản trị. Handler chạy trong một transaction
+// (lấy qua RequestDB); bản ghi audit được ghi vào cùng transaction đó trước khi commit, nên thay đổi
+// không bao giờ được lưu mà thiếu audit: ghi audit lỗi thì thay đổi bị rollback và request trả 500.
+// Request lỗi (status >= 400) cũng bị rollback. Handler gọi AuditBefore/AuditAfter để lưu trạng thái
+// đối tượng trước và sau thay đổi. Phải đặt sau AuthMiddleware.
+func Audit(action, targetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		record := &auditRecord{targetID: c.Param("id")}
+		if record.targetID == "" {
+			record.targetID = c.Param("rf_id")
+		}
+
+		record.tx = config.DB.Begin()
+		if record.tx.Error != nil {
+			log.Printf("⚠️ Failed to begin audit transaction for %s: %v", action, record.tx.Error)
+			InternalServerError(c, "failed to start transaction")
+			c.Abort()
+			return
+		}
+		c.Set(auditContextKey, record)
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		defer func() {
+			if r := recover(); r != nil {
+				record.tx.Rollback()
+				c.Writer = writer.ResponseWriter
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		status := writer.status
+		if status >= 400 {
+			record.tx.Rollback()
+			writer.flush()
+			return
+		}
+
+		entry := models.AuditLog{
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   record.targetID,
+			Before:     record.before,
+			After:      record.after,
+			Changes:    auditDiff(record.before, record.after),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Status:     status,
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			if id, ok := userID.(uint); ok {
+				entry.ActorID = &id
+			}
+		}
+		entry.ActorEmail = c.GetString("username")
+		entry.ActorRole = c.GetInt("role")
+
+		if err := appendAuditLog(record.tx, &entry); err != nil {
+			record.tx.Rollback()
+			log.Printf("⚠️ Failed to write audit log for %s %s: %v", action, record.targetID, err)
+			InternalServerError(c, "failed to write audit log")
+			return
+		}
+		if err := record.tx.Commit().Error; err != nil {
+			log.Printf("⚠️ Failed to commit %s %s: %v", action, record.targetID, err)
+			InternalServerError(c, "failed to commit changes")
+			return
+		}
+
+		writer.flush()
+		for _, fn := range record.afterCommit {
+			fn()
+		}
+	}
+}
+
+func currentAuditRecord(c *gin.Context) *auditRecord {
+	if value, ok := c.Get(auditContextKey); ok {
+		if record, ok := value.(*auditRecord); ok {
+			return record
+		}
+	}
+	return nil
+}
+
+// RequestDB trả về transaction của request trên route có middleware Audit, để thay đổi và bản ghi
+// audit được commit cùng nhau; các route khác dùng config.DB
+func RequestDB(c *gin.Context) *gorm.DB {
+	if record := currentAuditRecord(c); record != nil {
+		return record.tx
+	}
+	return config.DB
+}
+
+// AfterCommit chạy fn sau khi transaction của request được commit (ngay lập tức nếu route không có
+// middleware Audit); dùng để đánh thức các dispatcher chỉ khi thay đổi đã được lưu
+func AfterCommit(c *gin.Context, fn func()) {
+	if record := currentAuditRecord(c); record != nil {
+		record.afterCommit = append(record.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// AuditBefore lưu trạng thái đối tượng trước khi thay đổi; bỏ qua nếu route không có middleware Audit
+func AuditBefore(c *gin.Context, target interface{}) {
+	if record := currentAuditRecord(c); record != nil {
+		record.before = auditSnapshot(target)
+	}
+}
+
+// AuditAfter lưu trạng thái đối tượng sau khi thay đổi
+func AuditAfter(c *gin.Context, target interface{}) {
+	if record := currentAuditRecord(c); record != nil {
+		record.after = auditSnapshot(target)
+	}
+}
+
+// AuditTarget ghi đè ID đối tượng khi khác tham số trên URL
+func AuditTarget(c *gin.Context, targetID string) {
+	if record := currentAuditRecord(c); record != nil {
+		record.targetID = targetID
+	}
+}
+
+// auditSnapshot chụp đối tượng theo JSON của nó, nên các trường ẩn (json:"-") như mật khẩu không bị ghi lại
+func auditSnapshot(target interface{}) map[string]interface{} {
+	data, err := json.Marshal(target)
+	if err != nil {
+		log.Println("⚠️ Failed to snapshot audit target:", err)
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Println("⚠️ Failed to snapshot audit target:", err)
+		return nil
+	}
+	return snapshot
+}
+
+// auditDiff liệt kê các trường khác nhau giữa hai snapshot; nil nếu thiếu một trong hai (tạo mới, xóa)
+func auditDiff(before, after map[string]interface{}) map[string]models.AuditChange {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for field, from := range before {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if to, ok := after[field]; !ok || !reflect.DeepEqual(from, to) {
+			changes[field] = models.AuditChange{From: from, To: after[field]}
+		}
+	}
+	for field, to := range after {
+		if _, ok := before[field]; !ok && !auditIgnoredFields[field] {
+			changes[field] = models.AuditChange{From: nil, To: to}
+		}
+	}
+	return changes
+}
+
+// auditHashPayload là nội dung được băm của một bản ghi audit; thứ tự trường cố định
+type auditHashPayload struct {
+	PrevHash   string                        `json:"prev_hash"`
+	ActorID    *uint                         `json:"actor_id"`
+	ActorEmail string                        `json:"actor_email"`
+	ActorRole  int                           `json:"actor_role"`
+	Action     string                        `json:"action"`
+	TargetType string                        `json:"target_type"`
+	TargetID   string                        `json:"target_id"`
+	Before     map[string]interface{}        `json:"before"`
+	After      map[string]interface{}        `json:"after"`
+	Changes    map[string]models.AuditChange `json:"changes"`
+	Method     string                        `json:"method"`
+	Path       string                        `json:"path"`
+	Status     int                           `json:"status"`
+	IPAddress  string                        `json:"ip_address"`
+	UserAgent  string                        `json:"user_agent"`
+	CreatedAt  string                        `json:"created_at"`
+}
+
+// AuditHash tính hash của bản ghi audit (gồm cả PrevHash của bản ghi trước)
+func AuditHash(entry models.AuditLog) (string, error) {
+	data, err := json.Marshal(auditHashPayload{
+		PrevHash:   entry.PrevHash,
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		Changes:    entry.Changes,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		Status:     entry.Status,
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// appendAuditLog nối bản ghi vào cuối chuỗi audit trong transaction db
+func appendAuditLog(db *gorm.DB, entry *models.AuditLog) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		if err := tx.Select("hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+
+		// Postgres lưu thời gian tới micro giây; làm tròn trước khi băm để kiểm tra lại được
+		entry.PrevHash = last.Hash
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		hash, err := AuditHash(*entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		return tx.Create(entry).Error
+	})
+}
+
+// AuditChainReport là kết quả kiểm tra chuỗi hash của nhật ký audit
+type AuditChainReport struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt *uint  `json:"broken_at,omitempty"` // ID bản ghi đầu tiên không khớp
+	Reason   string `json:"reason,omitempty"`
+}
+
+// VerifyAuditChain tính lại hash của toàn bộ nhật ký theo thứ tự ID
+func VerifyAuditChain() (AuditChainReport, error) {
+	report := AuditChainReport{Valid: true}
+	prevHash := ""
+
+	var batch []models.AuditLog
+	result := config.DB.Order("id ASC").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, entry := range batch {
+			report.Checked++
+
+			reason := ""
+			if entry.PrevHash != prevHash {
+				reason = "prev_hash does not match the previous entry (entry removed or reordered)"
+			} else if hash, err := AuditHash(entry); err != nil {
+				return err
+			} else if hash != entry.Hash {
+				reason = "hash does not match the entry content (entry modified)"
+			}
+
+			if reason != "" {
+				id := entry.ID
+				report.Valid = false
+				report.BrokenAt = &id
+				report.Reason = reason
+				return errStopAuditVerify
+			}
+			prevHash = entry.Hash
+		}
+		return nil
+	})
+	if result.Error != nil && !errors.Is(result.Error, errStopAuditVerify) {
+		return report, result.Error
+	}
+	return report, nil
+}
@@ -1,50 +1,145 @@
 package utils
 
 import (
-  "crypto/md5"
+  "errors"
   "fmt"
+  "log"
+  "os"
+  "strconv"
+  "strings"
+  "sync"
   "time"
 
+  "go-metro/models"
+
   "github.com/gin-gonic/gin"
   "github.com/golang-jwt/jwt/v4"
 )
 
-var jwtSecret = []byte("your-secret-key-change-in-production")
+// defaultAccessTokenTTL là thời hạn mặc định của access token
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// signingKeySet là tập khóa ký JWT theo kid; token mới luôn ký bằng khóa active
+type signingKeySet struct {
+  activeKID string
+  keys      map[string][]byte
+}
+
+var (
+  signingKeysOnce sync.Once
+  signingKeys     signingKeySet
+  signingKeysErr  error
+)
+
+// ErrNoSigningKeys được trả về khi chưa cấu hình JWT_SIGNING_KEYS hoặc JWT_SECRET
+var ErrNoSigningKeys = errors.New("JWT_SIGNING_KEYS or JWT_SECRET must be set")
+
+// loadSigningKeys đọc khóa ký từ JWT_SIGNING_KEYS ("kid1:secret1,kid2:secret2") và JWT_ACTIVE_KID.
+// Khi xoay khóa, thêm khóa mới lên đầu và giữ khóa cũ cho tới khi các token cũ hết hạn.
+func loadSigningKeys() (signingKeySet, error) {
+  set := signingKeySet{keys: make(map[string][]byte)}
+
+  for _, entry := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+    kid, secret, found := strings.Cut(strings.TrimSpace(entry), ":")
+    if !found || kid == "" || secret == "" {
+      continue
+    }
+    set.keys[kid] = []byte(secret)
+    if set.activeKID == "" {
+      set.activeKID = kid
+    }
+  }
+  if kid := os.Getenv("JWT_ACTIVE_KID"); kid != "" {
+    if _, ok := set.keys[kid]; ok {
+      set.activeKID = kid
+    } else {
+      log.Println("⚠️ JWT_ACTIVE_KID not found in JWT_SIGNING_KEYS, using", set.activeKID)
+    }
+  }
+
+  if len(set.keys) == 0 {
+    secret := os.Getenv("JWT_SECRET")
+    if secret == "" {
+      return set, ErrNoSigningKeys
+    }
+    set.activeKID = "default"
+    set.keys["default"] = []byte(secret)
+  }
+
+  return set, nil
+}
+
+// LoadSigningKeys đọc khóa ký JWT một lần; main gọi khi khởi động và dừng server nếu chưa cấu hình khóa
+func LoadSigningKeys() error {
+  signingKeysOnce.Do(func() {
+    signingKeys, signingKeysErr = loadSigningKeys()
+  })
+  return signingKeysErr
+}
+
+func currentSigningKeys() signingKeySet {
+  LoadSigningKeys()
+  return signingKeys
+}
+
+// AccessTokenTTL đọc thời hạn access token từ ACCESS_TOKEN_TTL_MINUTES (mặc định 15 phút)
+func AccessTokenTTL() time.Duration {
+  if minutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && minutes > 0 {
+    return time.Duration(minutes) * time.Minute
+  }
+  return defaultAccessTokenTTL
+}
 
 // Claims represents JWT claims
 type Claims struct {
-  UserID   uint   `json:"user_id"`
-  Username string `json:"username"`
-  Role     int    `json:"role"`
+  UserID      uint   `json:"user_id"`
+  Username    string `json:"username"`
+  Role        int    `json:"role"`
+  SessionID   string `json:"sid,omitempty"` // FamilyID của refresh token đã cấp cùng phiên
+  Unverified  bool   `json:"unv,omitempty"` // email chưa xác minh; token cũ không có claim này được coi là đã xác minh
+  NoTwoFactor bool   `json:"ntf,omitempty"` // role bắt buộc xác thực hai bước nhưng user chưa bật
   jwt.RegisteredClaims
 }
 
-// HashPassword creates MD5 hash of password (đơn giản cho demo)
-func HashPassword(password string) string {
-  hash := md5.Sum([]byte(password))
-  return fmt.Sprintf("%x", hash)
-}
-
-// GenerateToken creates JWT token
-func GenerateToken(userID uint, username string, role int) (string, error) {
+// GenerateToken creates a short-lived JWT access token signed with the active key
+func GenerateToken(user models.User, sessionID string) (string, error) {
+  now := time.Now()
   claims := Claims{
-    UserID:   userID,
-    Username: username,
-    Role:     role,
+    UserID:      user.ID,
+    Username:    user.Email,
+    Role:        int(user.Role),
+    SessionID:   sessionID,
+    Unverified:  !user.EmailVerified(),
+    NoTwoFactor: TwoFactorRequired(user.Role) && !user.TwoFactorEnabled(),
     RegisteredClaims: jwt.RegisteredClaims{
-      ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hours
-      IssuedAt:  jwt.NewNumericDate(time.Now()),
+      ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+      IssuedAt:  jwt.NewNumericDate(now),
     },
   }
 
+  keys := currentSigningKeys()
   token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-  return token.SignedString(jwtSecret)
+  token.Header["kid"] = keys.activeKID
+  return token.SignedString(keys.keys[keys.activeKID])
 }
 
-// ValidateToken validates JWT token
+// ValidateToken validates JWT token, chọn khóa theo header kid
 func ValidateToken(tokenString string) (*Claims, error) {
+  keys := currentSigningKeys()
   token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-    return jwtSecret, nil
+    if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+      return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+    }
+
+    kid, _ := token.Header["kid"].(string)
+    if kid == "" {
+      kid = keys.activeKID
+    }
+    key, ok := keys.keys[kid]
+    if !ok {
+      return nil, fmt.Errorf("unknown signing key: %s", kid)
+    }
+    return key, nil
   })
 
   if err != nil {
@@ -84,7 +179,22 @@ func AuthMiddleware() gin.HandlerFunc {
     c.Set("user_id", claims.UserID)
     c.Set("username", claims.Username)
     c.Set("role", claims.Role)
+    c.Set("session_id", claims.SessionID)
+    c.Set("email_verified", !claims.Unverified)
+    c.Set("two_factor_missing", claims.NoTwoFactor)
+
+    c.Next()
+  }
+}
 
+// RequireVerifiedEmail chặn tài khoản chưa xác minh email (phải đặt sau AuthMiddleware)
+func RequireVerifiedEmail() gin.HandlerFunc {
+  return func(c *gin.Context) {
+    if verified, _ := c.Get("email_verified"); verified != true {
+      c.JSON(403, gin.H{"error": "Email verification required"})
+      c.Abort()
+      return
+    }
     c.Next()
   }
 }
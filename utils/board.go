@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"encoding/json"
+	"go-metro/models"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// BoardSize là số chuyến hiển thị trên bảng giờ tàu
+	BoardSize = 10
+	// BoardRefreshInterval là chu kỳ tính lại bảng giờ tàu kể cả khi không có thay đổi
+	BoardRefreshInterval = 30 * time.Second
+	// BoardHeartbeatInterval là chu kỳ gửi heartbeat để giữ kết nối
+	BoardHeartbeatInterval = 15 * time.Second
+
+	boardSubscriberBuffer = 16
+)
+
+// BoardEvent là một sự kiện gửi tới màn hình tại trạm
+type BoardEvent struct {
+	ID    uint64
+	Event string
+	Data  interface{}
+}
+
+// BoardSnapshot là toàn bộ nội dung bảng giờ tàu của một trạm
+type BoardSnapshot struct {
+	StationID   uint                  `json:"station_id"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Departures  []models.Departure    `json:"departures"`
+	Alerts      []models.ServiceAlert `json:"alerts"`
+}
+
+// BoardSubscriber là một kết nối đang theo dõi bảng giờ tàu của một trạm
+type BoardSubscriber struct {
+	stationID uint
+	events    chan BoardEvent
+	closed    bool
+}
+
+// Events trả về kênh sự kiện; kênh bị đóng khi client đọc quá chậm và cần kết nối lại
+func (s *BoardSubscriber) Events() <-chan BoardEvent {
+	return s.events
+}
+
+// BoardHub phân phối bảng giờ tàu tới nhiều màn hình theo từng trạm.
+// Luồng ghi chỉ đánh dấu trạm cần cập nhật (không chặn); việc tính lại và gửi do Run đảm nhận.
+type BoardHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[*BoardSubscriber]struct{}
+	lastPayload map[uint]string
+	dirty       chan uint
+	wake        chan struct{}
+	dirtyAll    atomic.Bool
+	nextID      atomic.Uint64
+}
+
+// Boards là hub dùng chung cho toàn bộ ứng dụng
+var Boards = NewBoardHub()
+
+func NewBoardHub() *BoardHub {
+	return &BoardHub{
+		subscribers: make(map[uint]map[*BoardSubscriber]struct{}),
+		lastPayload: make(map[uint]string),
+		dirty:       make(chan uint, 256),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Subscribe đăng ký nhận sự kiện của một trạm; gọi hàm trả về để hủy đăng ký
+func (h *BoardHub) Subscribe(stationID uint) (*BoardSubscriber, func()) {
+	subscriber := &BoardSubscriber{
+		stationID: stationID,
+		events:    make(chan BoardEvent, boardSubscriberBuffer),
+	}
+
+	h.mu.Lock()
+	if h.subscribers[stationID] == nil {
+		h.subscribers[stationID] = make(map[*BoardSubscriber]struct{})
+	}
+	h.subscribers[stationID][subscriber] = struct{}{}
+	h.mu.Unlock()
+
+	return subscriber, func() {
+		h.mu.Lock()
+		h.removeLocked(subscriber)
+		h.mu.Unlock()
+	}
+}
+
+func (h *BoardHub) removeLocked(subscriber *BoardSubscriber) {
+	if subscriber.closed {
+		return
+	}
+	subscriber.closed = true
+	close(subscriber.events)
+
+	delete(h.subscribers[subscriber.stationID], subscriber)
+	if len(h.subscribers[subscriber.stationID]) == 0 {
+		delete(h.subscribers, subscriber.stationID)
+		delete(h.lastPayload, subscriber.stationID)
+	}
+}
+
+// NextEventID trả về ID tăng dần cho sự kiện SSE
+func (h *BoardHub) NextEventID() uint64 {
+	return h.nextID.Add(1)
+}
+
+// Publish gửi sự kiện tới mọi client của trạm mà không chờ.
+// Client có hàng đợi đầy bị ngắt để kết nối lại và nhận bảng mới.
+func (h *BoardHub) Publish(stationID uint, event string, data interface{}) {
+	message := BoardEvent{ID: h.NextEventID(), Event: event, Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscriber := range h.subscribers[stationID] {
+		select {
+		case subscriber.events <- message:
+		default:
+			h.removeLocked(subscriber)
+		}
+	}
+}
+
+// MarkDirty báo các trạm cần tính lại bảng giờ tàu. Hàm không bao giờ chặn luồng ghi.
+func (h *BoardHub) MarkDirty(stationIDs ...uint) {
+	for _, stationID := range stationIDs {
+		select {
+		case h.dirty <- stationID:
+		default:
+			h.MarkAllDirty()
+			return
+		}
+	}
+}
+
+// MarkAllDirty báo tất cả các trạm cần tính lại bảng giờ tàu
+func (h *BoardHub) MarkAllDirty() {
+	h.dirtyAll.Store(true)
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (h *BoardHub) watchedStations() []uint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stationIDs := make([]uint, 0, len(h.subscribers))
+	for stationID := range h.subscribers {
+		stationIDs = append(stationIDs, stationID)
+	}
+	return stationIDs
+}
+
+// BuildBoardSnapshot tính nội dung bảng giờ tàu hiện tại của một trạm
+func BuildBoardSnapshot(stationID uint) (BoardSnapshot, error) {
+	snapshot := BoardSnapshot{StationID: stationID, GeneratedAt: time.Now()}
+
+	departures, err := GetNextDepartures(stationID, snapshot.GeneratedAt, BoardSize)
+	if err != nil {
+		return snapshot, err
+	}
+	if departures == nil {
+		departures = []models.Departure{}
+	}
+	snapshot.Departures = departures
+
+	snapshot.Alerts, err = GetActiveStationAlerts(stationID)
+	return snapshot, err
+}
+
+// refresh tính lại bảng của trạm và chỉ gửi khi nội dung thay đổi
+func (h *BoardHub) refresh(stationID uint) {
+	h.mu.Lock()
+	_, watched := h.subscribers[stationID]
+	h.mu.Unlock()
+	if !watched {
+		return
+	}
+
+	snapshot, err := BuildBoardSnapshot(stationID)
+	if err != nil {
+		log.Println("⚠️ Failed to build departure board:", err)
+		return
+	}
+
+	payload, _ := json.Marshal([]interface{}{snapshot.Departures, snapshot.Alerts})
+	h.mu.Lock()
+	changed := h.lastPayload[stationID] != string(payload)
+	h.lastPayload[stationID] = string(payload)
+	h.mu.Unlock()
+
+	if changed {
+		h.Publish(stationID, "departures", snapshot)
+	}
+}
+
+// Run xử lý các yêu cầu cập nhật và làm mới định kỳ; chạy trong một goroutine riêng
+func (h *BoardHub) Run() {
+	ticker := time.NewTicker(BoardRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case stationID := <-h.dirty:
+			h.refresh(stationID)
+		case <-h.wake:
+		case <-ticker.C:
+			h.dirtyAll.Store(true)
+		}
+
+		if h.dirtyAll.Swap(false) {
+			for _, stationID := range h.watchedStations() {
+				h.refresh(stationID)
+			}
+		}
+	}
+}
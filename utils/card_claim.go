@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidCardClaim không phân biệt thẻ không tồn tại, đã có chủ hay sai mã để không lộ thông tin thẻ
+	ErrInvalidCardClaim  = errors.New("invalid card or verification code")
+	ErrCardNotRegistered = errors.New("card is not registered to an account")
+)
+
+// NewCardVerificationCode tạo mã xác minh in trên thẻ vô danh (cùng định dạng xxxxx-xxxxx với mã khôi phục)
+// và hash để lưu vào thẻ
+func NewCardVerificationCode() (code, hash string, err error) {
+	code, err = newRecoveryCode()
+	if err != nil {
+		return "", "", err
+	}
+	return code, hashToken(normalizeRecoveryCode(code)), nil
+}
+
+// cardClaimMaxFailures là số lần nhập sai trước khi tạm khóa việc nhận thẻ (CARD_CLAIM_MAX_FAILURES, mặc định 5)
+func cardClaimMaxFailures() int {
+	return envInt("CARD_CLAIM_MAX_FAILURES", 5)
+}
+
+// ClaimCard gắn thẻ vô danh vào tài khoản userID sau khi kiểm tra mã xác minh in trên thẻ.
+// Nhập sai bị đếm theo tài khoản với backoff và thời gian khóa như đăng nhập; trả về *LoginThrottledError khi đang bị chặn.
+func ClaimCard(userID uint, rfID, code string, client ClientInfo) (models.Card, error) {
+	var card models.Card
+	key := strconv.FormatUint(uint64(userID), 10)
+
+	var throttle models.LoginThrottle
+	if err := config.DB.Where("scope = ? AND key = ?", models.ThrottleCardClaim, key).Limit(1).Find(&throttle).Error; err != nil {
+		return card, err
+	}
+	if blocked := throttleState(throttle, time.Now()); blocked != nil {
+		return card, blocked
+	}
+
+	event := models.SecurityEvent{
+		UserID:    &userID,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Detail:    rfID,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", rfID).First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCardClaim
+			}
+			return err
+		}
+
+		hash := hashToken(normalizeRecoveryCode(code))
+		if card.Registered() || card.VerificationCodeHash == "" ||
+			subtle.ConstantTimeCompare([]byte(hash), []byte(card.VerificationCodeHash)) != 1 {
+			return ErrInvalidCardClaim
+		}
+
+		now := time.Now()
+		card.UserID = &userID
+		card.ClaimedAt = &now
+		card.VerificationCodeHash = ""
+		return tx.Model(&card).Select("user_id", "claimed_at", "verification_code_hash").Updates(&card).Error
+	})
+
+	if errors.Is(err, ErrInvalidCardClaim) {
+		policy := LoadLoginGuardPolicy()
+		if ferr := config.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := recordFailure(tx, models.ThrottleCardClaim, key, cardClaimMaxFailures(), policy, time.Now())
+			return err
+		}); ferr != nil {
+			log.Println("⚠️ Failed to record card claim failure:", ferr)
+		}
+		event.Event = models.EventCardClaimFailed
+		LogSecurityEvent(event)
+		return models.Card{}, err
+	}
+	if err != nil {
+		return models.Card{}, err
+	}
+
+	if err := config.DB.Where("scope = ? AND key = ?", models.ThrottleCardClaim, key).Delete(&models.LoginThrottle{}).Error; err != nil {
+		log.Println("⚠️ Failed to reset card claim throttle:", err)
+	}
+	event.Event = models.EventCardClaimed
+	LogSecurityEvent(event)
+	return card, nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CardExpiryNotice là số ngày trước hạn dùng bắt đầu báo thẻ sắp hết hạn (CARD_EXPIRY_NOTICE_DAYS, mặc định 7)
+func CardExpiryNotice() time.Duration {
+	return time.Duration(envInt("CARD_EXPIRY_NOTICE_DAYS", 7)) * 24 * time.Hour
+}
+
+// ExpireDueCards chuyển các thẻ đã quá hạn dùng sang expired và đưa vào hotlist. Trả về số thẻ đã chuyển.
+func ExpireDueCards(now time.Time) (int, error) {
+	var due []models.Card
+	if err := config.DB.Where("expires_at <= ? AND status <> ?", now, consts.ExpiredStatus).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, card := range due {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, card.ID).Error; err != nil {
+				return err
+			}
+			if card.ExpiresAt == nil || card.ExpiresAt.After(now) || card.Status == consts.ExpiredStatus {
+				return nil
+			}
+			card.Status = consts.ExpiredStatus
+			if err := tx.Model(&card).Update("status", card.Status).Error; err != nil {
+				return err
+			}
+			expired++
+			return SyncCardHotlist(tx, card)
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to expire card %s: %v", card.RFID, err)
+		}
+	}
+
+	if expired > 0 {
+		Hotlists.Wake()
+	}
+	return expired, nil
+}
+
+// NotifyExpiringCards báo chủ các thẻ sẽ hết hạn trong CardExpiryNotice; mỗi hạn dùng chỉ báo một lần
+func NotifyExpiringCards(now time.Time) error {
+	var expiring []models.Card
+	if err := config.DB.Where("user_id IS NOT NULL AND expires_at > ? AND expires_at <= ?", now, now.Add(CardExpiryNotice())).
+		Where("status NOT IN ?", consts.HotlistStatuses).
+		Find(&expiring).Error; err != nil {
+		return err
+	}
+
+	for _, card := range expiring {
+		NotifyCardExpiring(config.DB, card)
+	}
+	if len(expiring) > 0 {
+		Notifications.Wake()
+	}
+	return nil
+}
+
+// RunCardExpiry hết hạn thẻ quá hạn dùng và báo thẻ sắp hết hạn mỗi giờ; chạy trong một goroutine riêng
+func RunCardExpiry() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		if expired, err := ExpireDueCards(now); err != nil {
+			log.Println("⚠️ Failed to expire cards:", err)
+		} else if expired > 0 {
+			log.Printf("✅ Expired %d cards past their expiry date", expired)
+		}
+		if err := NotifyExpiringCards(now); err != nil {
+			log.Println("⚠️ Failed to notify expiring cards:", err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Header dùng để xác thực thiết bị
+const (
+	DeviceIDHeader        = "X-Device-ID"
+	DeviceKeyHeader       = "X-Device-Key"
+	DeviceTimestampHeader = "X-Device-Timestamp"
+	DeviceNonceHeader     = "X-Device-Nonce"
+	DeviceSignatureHeader = "X-Device-Signature"
+)
+
+// defaultDeviceSignatureSkew là độ lệch đồng hồ tối đa cho request đã ký
+const defaultDeviceSignatureSkew = 5 * time.Minute
+
+var (
+	ErrDeviceUnauthorized  = errors.New("device authentication failed")
+	ErrDeviceInactive      = errors.New("device is not active")
+	ErrDeviceSignatureOnly = errors.New("device requests must be signed")
+	ErrDeviceStale         = errors.New("device request timestamp out of range")
+	ErrDeviceReplay        = errors.New("device request nonce already used")
+)
+
+// DeviceSignatureSkew đọc độ lệch tối đa từ DEVICE_SIGNATURE_MAX_SKEW_SECONDS (mặc định 300 giây)
+func DeviceSignatureSkew() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("DEVICE_SIGNATURE_MAX_SKEW_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultDeviceSignatureSkew
+}
+
+// NewDeviceCredentials sinh KeyID công khai và Secret cho thiết bị
+func NewDeviceCredentials() (keyID string, secret string, err error) {
+	if keyID, err = randomToken(12); err != nil {
+		return "", "", err
+	}
+	if secret, err = randomToken(32); err != nil {
+		return "", "", err
+	}
+	return "dev_" + keyID, secret, nil
+}
+
+// DeviceSignaturePayload là chuỗi được ký: METHOD\nPATH\nTIMESTAMP\nNONCE\nSHA256(BODY)
+func DeviceSignaturePayload(method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{method, path, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+// SignDeviceRequest tính chữ ký HMAC-SHA256 (hex) cho một request của thiết bị
+func SignDeviceRequest(secret, method, path, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(DeviceSignaturePayload(method, path, timestamp, nonce, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDeviceSignature kiểm tra chữ ký, thời gian và nonce của request đã ký
+func verifyDeviceSignature(c *gin.Context, device models.Device) error {
+	timestamp := c.GetHeader(DeviceTimestampHeader)
+	nonce := c.GetHeader(DeviceNonceHeader)
+	signature := c.GetHeader(DeviceSignatureHeader)
+	if timestamp == "" || nonce == "" || len(nonce) > 128 {
+		return ErrDeviceUnauthorized
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrDeviceUnauthorized
+	}
+	skew := DeviceSignatureSkew()
+	if diff := time.Since(time.Unix(seconds, 0)); diff > skew || diff < -skew {
+		return ErrDeviceStale
+	}
+
+	// Đọc body để ký rồi trả lại cho handler
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ErrDeviceUnauthorized
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	expected := SignDeviceRequest(device.Secret, c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrDeviceUnauthorized
+	}
+
+	// Nonce chỉ dùng được một lần trong khoảng thời gian cho phép
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.DeviceNonce{DeviceID: device.ID, Nonce: nonce})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeviceReplay
+	}
+	return nil
+}
+
+// AuthenticateDevice xác thực request của thiết bị bằng chữ ký HMAC hoặc API key
+func AuthenticateDevice(c *gin.Context) (*models.Device, error) {
+	keyID := c.GetHeader(DeviceIDHeader)
+	if keyID == "" {
+		return nil, ErrDeviceUnauthorized
+	}
+
+	var device models.Device
+	if err := config.DB.Where("key_id = ?", keyID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceUnauthorized
+		}
+		return nil, err
+	}
+
+	if c.GetHeader(DeviceSignatureHeader) != "" {
+		if err := verifyDeviceSignature(c, device); err != nil {
+			return nil, err
+		}
+	} else {
+		if os.Getenv("DEVICE_REQUIRE_SIGNATURE") == "true" {
+			return nil, ErrDeviceSignatureOnly
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(DeviceKeyHeader)), []byte(device.Secret)) != 1 {
+			return nil, ErrDeviceUnauthorized
+		}
+	}
+
+	if device.IPAddress != "" && device.IPAddress != c.ClientIP() {
+		return nil, ErrDeviceUnauthorized
+	}
+	if device.Status != consts.ActiveStatus {
+		return nil, ErrDeviceInactive
+	}
+
+	// Mọi request đã xác thực đều cho biết thiết bị còn kết nối
+	now := time.Now()
+	config.DB.Model(&device).UpdateColumns(map[string]interface{}{
+		"last_used_at": now,
+		"last_seen_at": now,
+		"connectivity": consts.DeviceOnline,
+	})
+	device.LastUsedAt = &now
+	device.LastSeenAt = &now
+	device.Connectivity = consts.DeviceOnline
+	return &device, nil
+}
+
+// DeviceAuthMiddleware chỉ cho phép thiết bị đã đăng ký và đang hoạt động.
+// Thiết bị được lưu trong context với key "device".
+func DeviceAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device, err := AuthenticateDevice(c)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrDeviceInactive):
+				c.JSON(403, gin.H{"error": err.Error()})
+			case errors.Is(err, ErrDeviceUnauthorized),
+				errors.Is(err, ErrDeviceSignatureOnly),
+				errors.Is(err, ErrDeviceStale),
+				errors.Is(err, ErrDeviceReplay):
+				c.JSON(401, gin.H{"error": err.Error()})
+			default:
+				log.Println("⚠️ Failed to authenticate device:", err)
+				c.JSON(500, gin.H{"error": "Failed to authenticate device"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("device", *device)
+		c.Set("device_id", device.ID)
+		c.Next()
+	}
+}
+
+// PurgeDeviceNonces xóa các nonce đã quá thời gian cho phép
+func PurgeDeviceNonces() error {
+	return config.DB.Where("created_at < ?", time.Now().Add(-2*DeviceSignatureSkew())).
+		Delete(&models.DeviceNonce{}).Error
+}
+
+// RunDeviceNonceCleanup dọn nonce định kỳ; chạy trong một goroutine riêng
+func RunDeviceNonceCleanup() {
+	ticker := time.NewTicker(DeviceSignatureSkew())
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := PurgeDeviceNonces(); err != nil {
+			log.Println("⚠️ Failed to purge device nonces:", err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultDeviceOfflineTimeout     = 2 * time.Minute
+	defaultDeviceHeartbeatRetention = 7 * 24 * time.Hour
+)
+
+// DeviceOfflineTimeout đọc thời gian im lặng tối đa từ DEVICE_OFFLINE_TIMEOUT_SECONDS (mặc định 120 giây)
+func DeviceOfflineTimeout() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("DEVICE_OFFLINE_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultDeviceOfflineTimeout
+}
+
+// DeviceHeartbeatRetention đọc thời gian giữ lịch sử heartbeat từ DEVICE_HEARTBEAT_RETENTION_DAYS (mặc định 7 ngày)
+func DeviceHeartbeatRetention() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("DEVICE_HEARTBEAT_RETENTION_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultDeviceHeartbeatRetention
+}
+
+// Heartbeat là tình trạng thiết bị tự báo cáo
+type Heartbeat struct {
+	FirmwareVersion string
+	DeviceTime      time.Time
+	QueueSize       int
+	Errors          map[string]int64
+	UptimeSeconds   int64
+}
+
+// RecordHeartbeat lưu heartbeat và cập nhật tình trạng hiện tại của thiết bị.
+// Độ lệch đồng hồ tính bằng đồng hồ thiết bị trừ thời điểm máy chủ nhận.
+func RecordHeartbeat(device *models.Device, heartbeat Heartbeat) (models.DeviceHeartbeat, error) {
+	now := time.Now()
+	record := models.DeviceHeartbeat{
+		DeviceID:        device.ID,
+		ReceivedAt:      now,
+		DeviceTime:      heartbeat.DeviceTime,
+		FirmwareVersion: heartbeat.FirmwareVersion,
+		QueueSize:       heartbeat.QueueSize,
+		Errors:          heartbeat.Errors,
+		UptimeSeconds:   heartbeat.UptimeSeconds,
+	}
+	if !heartbeat.DeviceTime.IsZero() {
+		record.ClockDriftMs = heartbeat.DeviceTime.Sub(now).Milliseconds()
+	}
+
+	var errorCount int64
+	for _, count := range heartbeat.Errors {
+		errorCount += count
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Device").Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(device).UpdateColumns(map[string]interface{}{
+			"connectivity":      consts.DeviceOnline,
+			"last_seen_at":      now,
+			"last_heartbeat_at": now,
+			"firmware_version":  heartbeat.FirmwareVersion,
+			"clock_drift_ms":    record.ClockDriftMs,
+			"queue_size":        heartbeat.QueueSize,
+			"error_count":       errorCount,
+		}).Error
+	})
+	if err != nil {
+		return record, err
+	}
+
+	device.Connectivity = consts.DeviceOnline
+	device.LastSeenAt = &now
+	device.LastHeartbeatAt = &now
+	device.FirmwareVersion = heartbeat.FirmwareVersion
+	device.ClockDriftMs = record.ClockDriftMs
+	device.QueueSize = heartbeat.QueueSize
+	device.ErrorCount = errorCount
+	return record, nil
+}
+
+// DeviceConnectivityAt tính trạng thái kết nối của thiết bị tại thời điểm at,
+// không phụ thuộc vào lần quét gần nhất của RunDeviceHealthMonitor
+func DeviceConnectivityAt(device models.Device, at time.Time) consts.DeviceConnectivity {
+	if device.LastSeenAt == nil {
+		return consts.DeviceUnknown
+	}
+	if at.Sub(*device.LastSeenAt) > DeviceOfflineTimeout() {
+		return consts.DeviceOffline
+	}
+	return consts.DeviceOnline
+}
+
+// StationHealth tổng hợp tình trạng thiết bị của một trạm
+type StationHealth struct {
+	StationID   uint       `json:"station_id"`
+	StationName string     `json:"station_name"`
+	Devices     int        `json:"devices"`
+	Online      int        `json:"online"`
+	Offline     int        `json:"offline"`
+	Unknown     int        `json:"unknown"`
+	Gates       int        `json:"gates"`
+	GatesOnline int        `json:"gates_online"`
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+	Silent      bool       `json:"silent"` // trạm có cổng soát vé nhưng không cổng nào đang online
+}
+
+// FleetDashboard là tình trạng toàn bộ thiết bị đang hoạt động
+type FleetDashboard struct {
+	GeneratedAt           time.Time       `json:"generated_at"`
+	OfflineTimeoutSeconds int             `json:"offline_timeout_seconds"`
+	Total                 int             `json:"total"`
+	Online                int             `json:"online"`
+	Offline               int             `json:"offline"`
+	Unknown               int             `json:"unknown"`
+	SilentStations        []uint          `json:"silent_stations"`
+	Stations              []StationHealth `json:"stations"`
+	Devices               []models.Device `json:"devices"`
+}
+
+// BuildFleetDashboard tổng hợp tình trạng các thiết bị active, tùy chọn giới hạn trong một trạm
+func BuildFleetDashboard(stationID uint) (FleetDashboard, error) {
+	now := time.Now()
+	dashboard := FleetDashboard{
+		GeneratedAt:           now,
+		OfflineTimeoutSeconds: int(DeviceOfflineTimeout().Seconds()),
+		SilentStations:        []uint{},
+		Stations:              []StationHealth{},
+		Devices:               []models.Device{},
+	}
+
+	query := config.DB.Preload("Station").Where("status = ?", consts.ActiveStatus)
+	if stationID != 0 {
+		query = query.Where("station_id = ?", stationID)
+	}
+	if err := query.Order("station_id ASC, id ASC").Find(&dashboard.Devices).Error; err != nil {
+		return dashboard, err
+	}
+
+	stations := make(map[uint]*StationHealth)
+	for i := range dashboard.Devices {
+		device := &dashboard.Devices[i]
+		device.Connectivity = DeviceConnectivityAt(*device, now)
+
+		health, ok := stations[device.StationID]
+		if !ok {
+			health = &StationHealth{StationID: device.StationID, StationName: device.Station.Name}
+			stations[device.StationID] = health
+		}
+
+		health.Devices++
+		switch device.Connectivity {
+		case consts.DeviceOnline:
+			health.Online++
+			dashboard.Online++
+		case consts.DeviceOffline:
+			health.Offline++
+			dashboard.Offline++
+		default:
+			health.Unknown++
+			dashboard.Unknown++
+		}
+		if device.Type == consts.GateDevice {
+			health.Gates++
+			if device.Connectivity == consts.DeviceOnline {
+				health.GatesOnline++
+			}
+		}
+		if device.LastSeenAt != nil && (health.LastSeenAt == nil || device.LastSeenAt.After(*health.LastSeenAt)) {
+			health.LastSeenAt = device.LastSeenAt
+		}
+	}
+	dashboard.Total = len(dashboard.Devices)
+
+	for _, health := range stations {
+		health.Silent = health.Gates > 0 && health.GatesOnline == 0
+		if health.Silent {
+			dashboard.SilentStations = append(dashboard.SilentStations, health.StationID)
+		}
+		dashboard.Stations = append(dashboard.Stations, *health)
+	}
+	sort.Slice(dashboard.Stations, func(i, j int) bool { return dashboard.Stations[i].StationID < dashboard.Stations[j].StationID })
+	sort.Slice(dashboard.SilentStations, func(i, j int) bool { return dashboard.SilentStations[i] < dashboard.SilentStations[j] })
+
+	return dashboard, nil
+}
+
+// MarkOfflineDevices chuyển các thiết bị im lặng quá thời gian cho phép sang offline
+func MarkOfflineDevices() ([]models.Device, error) {
+	devices := []models.Device{}
+	err := config.DB.Model(&devices).
+		Clauses(clause.Returning{}).
+		Where("connectivity = ? AND last_seen_at < ?", consts.DeviceOnline, time.Now().Add(-DeviceOfflineTimeout())).
+		UpdateColumn("connectivity", consts.DeviceOffline).Error
+	return devices, err
+}
+
+// PurgeDeviceHeartbeats xóa lịch sử heartbeat quá thời gian lưu giữ
+func PurgeDeviceHeartbeats() error {
+	return config.DB.Where("received_at < ?", time.Now().Add(-DeviceHeartbeatRetention())).
+		Delete(&models.DeviceHeartbeat{}).Error
+}
+
+// RunDeviceHealthMonitor đánh dấu thiết bị offline, cảnh báo trạm mất toàn bộ cổng soát vé
+// và dọn lịch sử heartbeat cũ; chạy trong một goroutine riêng
+func RunDeviceHealthMonitor() {
+	interval := DeviceOfflineTimeout() / 4
+	if interval < 10*time.Second {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	silent := make(map[uint]bool)
+	lastPurge := time.Time{}
+
+	for range ticker.C {
+		devices, err := MarkOfflineDevices()
+		if err != nil {
+			log.Println("⚠️ Failed to mark offline devices:", err)
+		}
+		for _, device := range devices {
+			log.Printf("⚠️ Device %d (%s) at station %d went offline", device.ID, device.Name, device.StationID)
+		}
+
+		dashboard, err := BuildFleetDashboard(0)
+		if err != nil {
+			log.Println("⚠️ Failed to check station gates:", err)
+		} else {
+			current := make(map[uint]bool, len(dashboard.SilentStations))
+			for _, stationID := range dashboard.SilentStations {
+				current[stationID] = true
+				if !silent[stationID] {
+					log.Printf("⚠️ All gates at station %d are silent", stationID)
+				}
+			}
+			for stationID := range silent {
+				if !current[stationID] {
+					log.Printf("✅ Gates at station %d are back online", stationID)
+				}
+			}
+			silent = current
+		}
+
+		if time.Since(lastPurge) > time.Hour {
+			if err := PurgeDeviceHeartbeats(); err != nil {
+				log.Println("⚠️ Failed to purge device heartbeats:", err)
+			}
+			lastPurge = time.Now()
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"go-metro/config"
+	"go-metro/models"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gtfsDateLayout = "20060102"
+
+// gtfsRouteTypeSubway là route_type của GTFS cho metro
+const gtfsRouteTypeSubway = "1"
+
+// formatGTFSTime định dạng khoảng thời gian từ nửa đêm thành HH:MM:SS (giờ có thể >= 24)
+func formatGTFSTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds/60)%60, seconds%60)
+}
+
+func gtfsServiceID(pattern models.ServicePattern) string {
+	return fmt.Sprintf("P%d", pattern.ID)
+}
+
+func gtfsBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func writeGTFSFile(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	file, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func envOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// WriteGTFSFeed ghi bộ dữ liệu GTFS static (dạng zip) từ trạm, tuyến và mẫu vận hành.
+// Mỗi giờ xuất phát của một mẫu vận hành là một trip trong trips.txt;
+// các chuyến tạo thủ công (không có mẫu vận hành) không được xuất.
+func WriteGTFSFeed(w io.Writer) error {
+	var stations []models.Station
+	if err := config.DB.Order("id ASC").Find(&stations).Error; err != nil {
+		return err
+	}
+
+	var lines []models.Line
+	if err := config.DB.Order("id ASC").Find(&lines).Error; err != nil {
+		return err
+	}
+
+	var patterns []models.ServicePattern
+	if err := config.DB.
+		Preload("Stops", func(db *gorm.DB) *gorm.DB { return db.Order("stop_sequence ASC") }).
+		Preload("Exceptions").
+		Order("id ASC").
+		Find(&patterns).Error; err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	agency := [][]string{{
+		"GOMETRO",
+		envOrDefault("GTFS_AGENCY_NAME", "Go Metro"),
+		envOrDefault("GTFS_AGENCY_URL", "https://gometro.vn"),
+		envOrDefault("GTFS_AGENCY_TIMEZONE", "Asia/Ho_Chi_Minh"),
+		"vi",
+	}}
+	if err := writeGTFSFile(zw, "agency.txt", []string{"agency_id", "agency_name", "agency_url", "agency_timezone", "agency_lang"}, agency); err != nil {
+		return err
+	}
+
+	stationNames := make(map[uint]string)
+	var stops [][]string
+	for _, station := range stations {
+		stationNames[station.ID] = station.Name
+		stops = append(stops, []string{
+			strconv.FormatUint(uint64(station.ID), 10),
+			station.Name,
+			station.Address,
+			strconv.FormatFloat(station.Latitude, 'f', 6, 64),
+			strconv.FormatFloat(station.Longitude, 'f', 6, 64),
+		})
+	}
+	if err := writeGTFSFile(zw, "stops.txt", []string{"stop_id", "stop_name", "stop_desc", "stop_lat", "stop_lon"}, stops); err != nil {
+		return err
+	}
+
+	var routes [][]string
+	for _, line := range lines {
+		routes = append(routes, []string{
+			strconv.FormatUint(uint64(line.ID), 10),
+			"GOMETRO",
+			line.Code,
+			line.Name,
+			gtfsRouteTypeSubway,
+			line.Color,
+		})
+	}
+	if err := writeGTFSFile(zw, "routes.txt", []string{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type", "route_color"}, routes); err != nil {
+		return err
+	}
+
+	today := time.Now()
+	var calendar, calendarDates, trips, stopTimes [][]string
+	for _, pattern := range patterns {
+		serviceID := gtfsServiceID(pattern)
+
+		startDate, endDate := pattern.StartDate, pattern.EndDate
+		if startDate.IsZero() {
+			startDate = today
+		}
+		if endDate.IsZero() {
+			endDate = startDate.AddDate(1, 0, 0)
+		}
+		calendar = append(calendar, []string{
+			serviceID,
+			gtfsBool(pattern.Monday), gtfsBool(pattern.Tuesday), gtfsBool(pattern.Wednesday), gtfsBool(pattern.Thursday),
+			gtfsBool(pattern.Friday), gtfsBool(pattern.Saturday), gtfsBool(pattern.Sunday),
+			startDate.Format(gtfsDateLayout),
+			endDate.Format(gtfsDateLayout),
+		})
+
+		for _, exception := range pattern.Exceptions {
+			calendarDates = append(calendarDates, []string{
+				serviceID,
+				exception.Date.Format(gtfsDateLayout),
+				strconv.Itoa(int(exception.Type)),
+			})
+		}
+
+		if len(pattern.Stops) == 0 {
+			continue
+		}
+
+		// Giờ xuất phát được tính theo một ngày bất kỳ rồi quy về khoảng cách từ nửa đêm
+		midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		departures, err := pattern.DepartureTimes(midnight)
+		if err != nil {
+			return err
+		}
+
+		headsign := stationNames[pattern.Stops[len(pattern.Stops)-1].StationID]
+		for _, departure := range departures {
+			start := departure.Sub(midnight)
+			tripID := fmt.Sprintf("%s-%s", serviceID, strings.ReplaceAll(formatGTFSTime(start), ":", ""))
+			trips = append(trips, []string{
+				strconv.FormatUint(uint64(pattern.LineID), 10),
+				serviceID,
+				tripID,
+				headsign,
+				strconv.Itoa(int(pattern.Direction)),
+				strconv.FormatUint(uint64(pattern.TrainID), 10),
+			})
+
+			for _, stop := range pattern.Stops {
+				stopTimes = append(stopTimes, []string{
+					tripID,
+					formatGTFSTime(start + time.Duration(stop.ArrivalOffset)*time.Second),
+					formatGTFSTime(start + time.Duration(stop.DepartureOffset)*time.Second),
+					strconv.FormatUint(uint64(stop.StationID), 10),
+					strconv.Itoa(stop.StopSequence),
+				})
+			}
+		}
+	}
+
+	if err := writeGTFSFile(zw, "calendar.txt", []string{"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"}, calendar); err != nil {
+		return err
+	}
+	if err := writeGTFSFile(zw, "calendar_dates.txt", []string{"service_id", "date", "exception_type"}, calendarDates); err != nil {
+		return err
+	}
+	if err := writeGTFSFile(zw, "trips.txt", []string{"route_id", "service_id", "trip_id", "trip_headsign", "direction_id", "block_id"}, trips); err != nil {
+		return err
+	}
+	if err := writeGTFSFile(zw, "stop_times.txt", []string{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}, stopTimes); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
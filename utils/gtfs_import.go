@@ -0,0 +1,712 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-metro/consts"
+	"go-metro/models"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GTFSRejection là một dòng dữ liệu GTFS bị từ chối khi import
+type GTFSRejection struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// GTFSImportReport tổng hợp kết quả một lần import GTFS
+type GTFSImportReport struct {
+	DryRun   bool            `json:"dry_run"`
+	Created  map[string]int  `json:"created"`
+	Updated  map[string]int  `json:"updated"`
+	Rejected []GTFSRejection `json:"rejected"`
+}
+
+func (r *GTFSImportReport) reject(file string, line int, id string, reason string) {
+	r.Rejected = append(r.Rejected, GTFSRejection{File: file, Line: line, ID: id, Reason: reason})
+}
+
+func (r *GTFSImportReport) count(entity string, created bool) {
+	if created {
+		r.Created[entity]++
+	} else {
+		r.Updated[entity]++
+	}
+}
+
+// ErrGTFSInvalidFeed được trả về khi file zip không phải là một bộ GTFS hợp lệ
+var ErrGTFSInvalidFeed = errors.New("invalid GTFS feed")
+
+var errGTFSDryRun = errors.New("gtfs dry run")
+
+type gtfsRow struct {
+	line   int
+	values map[string]string
+}
+
+func (r gtfsRow) get(key string) string {
+	return strings.TrimSpace(r.values[key])
+}
+
+func readGTFSFile(files map[string]*zip.File, name string) ([]gtfsRow, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	reader := csv.NewReader(rc)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrGTFSInvalidFeed, name, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []gtfsRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrGTFSInvalidFeed, name, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := gtfsRow{line: line, values: make(map[string]string, len(header))}
+		for i, value := range record {
+			if i < len(header) {
+				row.values[header[i]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseGTFSTime đọc thời gian HH:MM:SS (giờ có thể >= 24) thành số giây từ nửa đêm
+func parseGTFSTime(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	var fields [3]int
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("invalid time %q", value)
+		}
+		fields[i] = number
+	}
+	if fields[1] > 59 || fields[2] > 59 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	return fields[0]*3600 + fields[1]*60 + fields[2], nil
+}
+
+func parseGTFSDirection(value string) (consts.Direction, error) {
+	switch value {
+	case "", "0":
+		return consts.OutboundDirection, nil
+	case "1":
+		return consts.InboundDirection, nil
+	default:
+		return 0, fmt.Errorf("invalid direction_id %q", value)
+	}
+}
+
+type gtfsService struct {
+	days       [7]bool // Monday..Sunday
+	startDate  time.Time
+	endDate    time.Time
+	exceptions []models.ServiceException
+}
+
+type gtfsTrip struct {
+	row       gtfsRow
+	routeID   string
+	serviceID string
+	direction consts.Direction
+	trainID   uint
+}
+
+type gtfsStopTime struct {
+	row       gtfsRow
+	sequence  int
+	stationID uint
+	arrival   int
+	departure int
+}
+
+// gtfsPatternGroup gom các trip có cùng tuyến, lịch, hướng, tàu và thời gian chạy giữa các ga
+type gtfsPatternGroup struct {
+	routeID   string
+	serviceID string
+	direction consts.Direction
+	trainID   uint
+	stops     []models.PatternStop
+	starts    []int // phút tính từ nửa đêm
+}
+
+// splitHeadwayRuns chia danh sách giờ xuất phát (đã sắp xếp) thành các đoạn có giãn cách đều nhau
+func splitHeadwayRuns(starts []int) [][]int {
+	var runs [][]int
+	for i := 0; i < len(starts); {
+		j := i + 1
+		if j < len(starts) {
+			headway := starts[j] - starts[i]
+			for j+1 < len(starts) && starts[j+1]-starts[j] == headway {
+				j++
+			}
+			runs = append(runs, starts[i:j+1])
+			i = j + 1
+		} else {
+			runs = append(runs, starts[i:i+1])
+			i++
+		}
+	}
+	return runs
+}
+
+// ImportGTFSFeed kiểm tra và ghi (upsert) một bộ GTFS static trong cùng một transaction.
+// Trạm được khớp theo stop_id (là ID trạm, như khi export) rồi tới stop_name; tuyến theo route_id rồi tới route_short_name.
+// Các trip có cùng lộ trình và thời gian chạy được gộp thành mẫu vận hành với giãn cách đều.
+// Dòng không hợp lệ bị bỏ qua và ghi vào báo cáo; lỗi database làm rollback toàn bộ.
+func ImportGTFSFeed(db *gorm.DB, data []byte, defaultTrainID uint, dryRun bool) (*GTFSImportReport, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGTFSInvalidFeed, err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[path.Base(file.Name)] = file
+	}
+
+	for _, name := range []string{"stops.txt", "routes.txt", "trips.txt", "stop_times.txt"} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrGTFSInvalidFeed, name)
+		}
+	}
+	if files["calendar.txt"] == nil && files["calendar_dates.txt"] == nil {
+		return nil, fmt.Errorf("%w: missing calendar.txt or calendar_dates.txt", ErrGTFSInvalidFeed)
+	}
+
+	content := make(map[string][]gtfsRow)
+	for _, name := range []string{"stops.txt", "routes.txt", "calendar.txt", "calendar_dates.txt", "trips.txt", "stop_times.txt"} {
+		rows, err := readGTFSFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		content[name] = rows
+	}
+
+	report := &GTFSImportReport{
+		DryRun:   dryRun,
+		Created:  make(map[string]int),
+		Updated:  make(map[string]int),
+		Rejected: []GTFSRejection{},
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		stopStations, err := importGTFSStops(tx, content["stops.txt"], report)
+		if err != nil {
+			return err
+		}
+
+		routeLines, err := importGTFSRoutes(tx, content["routes.txt"], report)
+		if err != nil {
+			return err
+		}
+
+		services := readGTFSCalendar(content["calendar.txt"], content["calendar_dates.txt"], report)
+
+		trips, err := readGTFSTrips(tx, content["trips.txt"], routeLines, services, defaultTrainID, report)
+		if err != nil {
+			return err
+		}
+
+		groups := groupGTFSStopTimes(content["stop_times.txt"], trips, stopStations, report)
+		if err := importGTFSPatterns(tx, groups, routeLines, services, report); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errGTFSDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errGTFSDryRun) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func importGTFSStops(tx *gorm.DB, rows []gtfsRow, report *GTFSImportReport) (map[string]uint, error) {
+	stopStations := make(map[string]uint)
+	var children []gtfsRow
+
+	for _, row := range rows {
+		if row.get("parent_station") != "" {
+			children = append(children, row)
+			continue
+		}
+
+		stopID, name := row.get("stop_id"), row.get("stop_name")
+		if stopID == "" || name == "" {
+			report.reject("stops.txt", row.line, stopID, "stop_id and stop_name are required")
+			continue
+		}
+
+		lat, errLat := strconv.ParseFloat(row.get("stop_lat"), 64)
+		lon, errLon := strconv.ParseFloat(row.get("stop_lon"), 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			report.reject("stops.txt", row.line, stopID, "invalid stop_lat or stop_lon")
+			continue
+		}
+
+		var station models.Station
+		found := false
+		if id, err := strconv.ParseUint(stopID, 10, 64); err == nil {
+			found = tx.Limit(1).Find(&station, id).RowsAffected > 0
+		}
+		if !found {
+			found = tx.Where("name = ?", name).Limit(1).Find(&station).RowsAffected > 0
+		}
+
+		station.Name = name
+		station.Latitude = lat
+		station.Longitude = lon
+		if desc := row.get("stop_desc"); desc != "" {
+			station.Address = desc
+		}
+		if !found {
+			station.Status = "active"
+		}
+
+		if err := tx.Save(&station).Error; err != nil {
+			return nil, err
+		}
+		report.count("stations", !found)
+		stopStations[stopID] = station.ID
+	}
+
+	// Các điểm dừng con (sân ga) được quy về trạm cha
+	for _, row := range children {
+		parentID, ok := stopStations[row.get("parent_station")]
+		if !ok {
+			report.reject("stops.txt", row.line, row.get("stop_id"), "unknown parent_station")
+			continue
+		}
+		stopStations[row.get("stop_id")] = parentID
+	}
+
+	return stopStations, nil
+}
+
+func importGTFSRoutes(tx *gorm.DB, rows []gtfsRow, report *GTFSImportReport) (map[string]models.Line, error) {
+	routeLines := make(map[string]models.Line)
+
+	for _, row := range rows {
+		routeID := row.get("route_id")
+		if routeID == "" {
+			report.reject("routes.txt", row.line, "", "route_id is required")
+			continue
+		}
+		if routeType := row.get("route_type"); routeType != "" {
+			if _, err := strconv.Atoi(routeType); err != nil {
+				report.reject("routes.txt", row.line, routeID, "invalid route_type")
+				continue
+			}
+		}
+
+		code := row.get("route_short_name")
+		if code == "" {
+			code = routeID
+		}
+		name := row.get("route_long_name")
+		if name == "" {
+			name = code
+		}
+
+		var line models.Line
+		found := false
+		if id, err := strconv.ParseUint(routeID, 10, 64); err == nil {
+			found = tx.Limit(1).Find(&line, id).RowsAffected > 0
+		}
+		if !found {
+			found = tx.Where("code = ?", code).Limit(1).Find(&line).RowsAffected > 0
+		}
+
+		line.Code = code
+		line.Name = name
+		if color := row.get("route_color"); color != "" {
+			line.Color = color
+		}
+		if desc := row.get("route_desc"); desc != "" {
+			line.Description = desc
+		}
+
+		if err := tx.Save(&line).Error; err != nil {
+			return nil, err
+		}
+		report.count("lines", !found)
+		routeLines[routeID] = line
+	}
+
+	return routeLines, nil
+}
+
+func readGTFSCalendar(calendar []gtfsRow, calendarDates []gtfsRow, report *GTFSImportReport) map[string]*gtfsService {
+	services := make(map[string]*gtfsService)
+	dayColumns := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+	for _, row := range calendar {
+		serviceID := row.get("service_id")
+		if serviceID == "" {
+			report.reject("calendar.txt", row.line, "", "service_id is required")
+			continue
+		}
+
+		service := &gtfsService{}
+		valid := true
+		for i, column := range dayColumns {
+			switch row.get(column) {
+			case "1":
+				service.days[i] = true
+			case "0":
+			default:
+				valid = false
+			}
+		}
+		if !valid {
+			report.reject("calendar.txt", row.line, serviceID, "weekday columns must be 0 or 1")
+			continue
+		}
+
+		var errStart, errEnd error
+		service.startDate, errStart = time.ParseInLocation(gtfsDateLayout, row.get("start_date"), time.Local)
+		service.endDate, errEnd = time.ParseInLocation(gtfsDateLayout, row.get("end_date"), time.Local)
+		if errStart != nil || errEnd != nil || service.endDate.Before(service.startDate) {
+			report.reject("calendar.txt", row.line, serviceID, "invalid start_date or end_date")
+			continue
+		}
+
+		services[serviceID] = service
+	}
+
+	for _, row := range calendarDates {
+		serviceID := row.get("service_id")
+		date, err := time.ParseInLocation(gtfsDateLayout, row.get("date"), time.Local)
+		if serviceID == "" || err != nil {
+			report.reject("calendar_dates.txt", row.line, serviceID, "service_id and a valid date are required")
+			continue
+		}
+
+		exceptionType := consts.ServiceExceptionType(0)
+		switch row.get("exception_type") {
+		case "1":
+			exceptionType = consts.ServiceAdded
+		case "2":
+			exceptionType = consts.ServiceRemoved
+		default:
+			report.reject("calendar_dates.txt", row.line, serviceID, "exception_type must be 1 or 2")
+			continue
+		}
+
+		// Lịch chỉ khai báo trong calendar_dates.txt: không chạy theo thứ, chỉ chạy các ngày được thêm
+		service, ok := services[serviceID]
+		if !ok {
+			service = &gtfsService{}
+			services[serviceID] = service
+		}
+		duplicate := false
+		for _, exception := range service.exceptions {
+			duplicate = duplicate || exception.Date.Equal(date)
+		}
+		if duplicate {
+			report.reject("calendar_dates.txt", row.line, serviceID, "duplicate date for service")
+			continue
+		}
+		service.exceptions = append(service.exceptions, models.ServiceException{
+			Date:        date,
+			Type:        exceptionType,
+			Description: "GTFS calendar_dates",
+		})
+	}
+
+	return services
+}
+
+func readGTFSTrips(tx *gorm.DB, rows []gtfsRow, routeLines map[string]models.Line, services map[string]*gtfsService, defaultTrainID uint, report *GTFSImportReport) (map[string]gtfsTrip, error) {
+	trips := make(map[string]gtfsTrip)
+	trainExists := make(map[uint]bool)
+
+	checkTrain := func(id uint) (bool, error) {
+		if exists, ok := trainExists[id]; ok {
+			return exists, nil
+		}
+		var count int64
+		if err := tx.Model(&models.Train{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return false, err
+		}
+		trainExists[id] = count > 0
+		return count > 0, nil
+	}
+
+	for _, row := range rows {
+		tripID := row.get("trip_id")
+		if tripID == "" {
+			report.reject("trips.txt", row.line, "", "trip_id is required")
+			continue
+		}
+		if _, ok := routeLines[row.get("route_id")]; !ok {
+			report.reject("trips.txt", row.line, tripID, "unknown route_id")
+			continue
+		}
+		if _, ok := services[row.get("service_id")]; !ok {
+			report.reject("trips.txt", row.line, tripID, "unknown service_id")
+			continue
+		}
+		direction, err := parseGTFSDirection(row.get("direction_id"))
+		if err != nil {
+			report.reject("trips.txt", row.line, tripID, err.Error())
+			continue
+		}
+
+		// block_id là ID tàu khi file được export từ hệ thống này
+		var trainID uint
+		if blockID, err := strconv.ParseUint(row.get("block_id"), 10, 64); err == nil {
+			exists, err := checkTrain(uint(blockID))
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				trainID = uint(blockID)
+			}
+		}
+		if trainID == 0 && defaultTrainID != 0 {
+			exists, err := checkTrain(defaultTrainID)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				trainID = defaultTrainID
+			}
+		}
+		if trainID == 0 {
+			report.reject("trips.txt", row.line, tripID, "no train: block_id is not a known train ID and no valid default_train_id was given")
+			continue
+		}
+
+		trips[tripID] = gtfsTrip{
+			row:       row,
+			routeID:   row.get("route_id"),
+			serviceID: row.get("service_id"),
+			direction: direction,
+			trainID:   trainID,
+		}
+	}
+
+	return trips, nil
+}
+
+func groupGTFSStopTimes(rows []gtfsRow, trips map[string]gtfsTrip, stopStations map[string]uint, report *GTFSImportReport) []*gtfsPatternGroup {
+	stopTimes := make(map[string][]gtfsStopTime)
+	invalid := make(map[string]string)
+	var tripOrder []string
+
+	for _, row := range rows {
+		tripID := row.get("trip_id")
+		if _, ok := trips[tripID]; !ok {
+			report.reject("stop_times.txt", row.line, tripID, "unknown or rejected trip_id")
+			continue
+		}
+		if _, seen := stopTimes[tripID]; !seen {
+			tripOrder = append(tripOrder, tripID)
+		}
+
+		stopTime := gtfsStopTime{row: row}
+		var err error
+		if stopTime.sequence, err = strconv.Atoi(row.get("stop_sequence")); err != nil {
+			invalid[tripID] = fmt.Sprintf("line %d: invalid stop_sequence", row.line)
+		}
+		stationID, ok := stopStations[row.get("stop_id")]
+		if !ok {
+			invalid[tripID] = fmt.Sprintf("line %d: unknown stop_id %q", row.line, row.get("stop_id"))
+		}
+		stopTime.stationID = stationID
+
+		arrival, departure := row.get("arrival_time"), row.get("departure_time")
+		if arrival == "" {
+			arrival = departure
+		}
+		if departure == "" {
+			departure = arrival
+		}
+		if stopTime.arrival, err = parseGTFSTime(arrival); err != nil {
+			invalid[tripID] = fmt.Sprintf("line %d: untimed or invalid arrival_time", row.line)
+		}
+		if stopTime.departure, err = parseGTFSTime(departure); err != nil {
+			invalid[tripID] = fmt.Sprintf("line %d: untimed or invalid departure_time", row.line)
+		}
+
+		stopTimes[tripID] = append(stopTimes[tripID], stopTime)
+	}
+
+	groups := make(map[string]*gtfsPatternGroup)
+	var groupOrder []string
+
+	for _, tripID := range tripOrder {
+		trip := trips[tripID]
+		times := stopTimes[tripID]
+		if reason, ok := invalid[tripID]; ok {
+			report.reject("stop_times.txt", times[0].row.line, tripID, reason)
+			continue
+		}
+		if len(times) < 2 {
+			report.reject("trips.txt", trip.row.line, tripID, "trip must have at least two stop times")
+			continue
+		}
+
+		sort.Slice(times, func(i, j int) bool { return times[i].sequence < times[j].sequence })
+
+		// Giờ xuất phát làm tròn xuống phút, phần lẻ được cộng vào thời gian của từng ga
+		start := times[0].arrival - times[0].arrival%60
+		signature := []string{trip.routeID, trip.serviceID, strconv.Itoa(int(trip.direction)), strconv.FormatUint(uint64(trip.trainID), 10)}
+		var stops []models.PatternStop
+		valid := true
+		previous := 0
+		for i, stopTime := range times {
+			arrival, departure := stopTime.arrival-start, stopTime.departure-start
+			if departure < arrival || arrival < previous {
+				valid = false
+				break
+			}
+			previous = departure
+
+			stops = append(stops, models.PatternStop{
+				StopSequence:    i + 1,
+				StationID:       stopTime.stationID,
+				ArrivalOffset:   arrival,
+				DepartureOffset: departure,
+			})
+			signature = append(signature, fmt.Sprintf("%d@%d-%d", stopTime.stationID, arrival, departure))
+		}
+		if !valid {
+			report.reject("stop_times.txt", times[0].row.line, tripID, "stop times must not go back in time")
+			continue
+		}
+
+		key := strings.Join(signature, "|")
+		group, ok := groups[key]
+		if !ok {
+			group = &gtfsPatternGroup{
+				routeID:   trip.routeID,
+				serviceID: trip.serviceID,
+				direction: trip.direction,
+				trainID:   trip.trainID,
+				stops:     stops,
+			}
+			groups[key] = group
+			groupOrder = append(groupOrder, key)
+		}
+		group.starts = append(group.starts, start/60)
+	}
+
+	result := make([]*gtfsPatternGroup, 0, len(groupOrder))
+	for _, key := range groupOrder {
+		result = append(result, groups[key])
+	}
+	return result
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func importGTFSPatterns(tx *gorm.DB, groups []*gtfsPatternGroup, routeLines map[string]models.Line, services map[string]*gtfsService, report *GTFSImportReport) error {
+	for _, group := range groups {
+		line := routeLines[group.routeID]
+		service := services[group.serviceID]
+
+		sort.Ints(group.starts)
+		starts := group.starts[:0]
+		for i, start := range group.starts {
+			if i == 0 || start != group.starts[i-1] {
+				starts = append(starts, start)
+			}
+		}
+
+		for _, run := range splitHeadwayRuns(starts) {
+			headway := 0
+			if len(run) > 1 {
+				headway = run[1] - run[0]
+			}
+
+			name := fmt.Sprintf("GTFS %s %s %s %s", line.Code, group.serviceID, group.direction.ToText(), formatClock(run[0]))
+			var pattern models.ServicePattern
+			found := tx.Where("name = ? AND line_id = ?", name, line.ID).Limit(1).Find(&pattern).RowsAffected > 0
+
+			if found {
+				if err := tx.Where("pattern_id = ?", pattern.ID).Delete(&models.PatternStop{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("pattern_id = ?", pattern.ID).Delete(&models.ServiceException{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("pattern_id = ? AND start_time > ?", pattern.ID, time.Now()).Delete(&models.Trip{}).Error; err != nil {
+					return err
+				}
+			}
+
+			pattern.Name = name
+			pattern.LineID = line.ID
+			pattern.TrainID = group.trainID
+			pattern.Direction = group.direction
+			pattern.Monday, pattern.Tuesday, pattern.Wednesday, pattern.Thursday = service.days[0], service.days[1], service.days[2], service.days[3]
+			pattern.Friday, pattern.Saturday, pattern.Sunday = service.days[4], service.days[5], service.days[6]
+			pattern.StartDate = service.startDate
+			pattern.EndDate = service.endDate
+			pattern.FirstDeparture = formatClock(run[0])
+			pattern.LastDeparture = formatClock(run[len(run)-1])
+			pattern.HeadwayMinutes = headway
+			pattern.Stops = append([]models.PatternStop(nil), group.stops...)
+			pattern.Exceptions = append([]models.ServiceException(nil), service.exceptions...)
+
+			if err := tx.Save(&pattern).Error; err != nil {
+				return err
+			}
+			report.count("service_patterns", !found)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"go-metro/consts"
 	"go-metro/models"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // CreateHistoryLog tạo lịch sử giao dịch chung
@@ -21,25 +23,28 @@ func CreateHistoryLog(cardID string, userID string, balance float64, userAction
 	return config.DB.Create(&history).Error
 }
 
-// CreateSellHistoryLog tạo lịch sử bán thẻ
-func CreateSellHistoryLog(cardID string, sellerID uint, cardPriceSold float64) error {
+// CreateSellHistoryLog tạo lịch sử bán thẻ tại trạm (stationID = nil khi bán ngoài trạm) qua db,
+// thường là transaction đang tạo thẻ
+func CreateSellHistoryLog(db *gorm.DB, cardID string, sellerID uint, stationID *uint, cardPriceSold float64) error {
 	sellHistory := models.SellHistory{
 		CardID:        cardID,
 		SellerID:      sellerID,
+		StationID:     stationID,
 		CardPriceSold: cardPriceSold,
 		Time:          time.Now(),
 	}
 
-	return config.DB.Create(&sellHistory).Error
+	return db.Create(&sellHistory).Error
 }
 
-// CreateStationHistoryLog tạo lịch sử check-in/check-out tại trạm
-func CreateStationHistoryLog(action string, cardID string, stationID uint, usedBalance float64) error {
+// CreateStationHistoryLog tạo lịch sử check-in/check-out tại trạm do thiết bị deviceID ghi nhận
+func CreateStationHistoryLog(action string, cardID string, stationID uint, deviceID *uint, usedBalance float64) error {
 	stationHistory := models.StationHistory{
 		Action:      action,
 		Time:        time.Now(),
 		CardID:      cardID,
 		StationID:   stationID,
+		DeviceID:    deviceID,
 		UsedBalance: usedBalance,
 	}
 
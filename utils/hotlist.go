@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// HotlistSignatureAlgorithm là thuật toán ký danh sách chặn
+	HotlistSignatureAlgorithm = "Ed25519"
+
+	defaultHotlistSyncInterval = 5 * time.Second
+
+	// hotlistLockKey là khóa advisory tuần tự hóa việc ghi hotlist để phiên bản được commit theo thứ tự
+	hotlistLockKey = 0x686f746c
+)
+
+const (
+	// latestHotlistEntries lấy thay đổi mới nhất của từng thẻ trong khoảng phiên bản (from, to]
+	latestHotlistEntries = "SELECT DISTINCT ON (card_id) * FROM hotlist_entries WHERE id > ? AND id <= ? ORDER BY card_id, id DESC"
+	// listedHotlistCards lấy các thẻ đang nằm trong danh sách chặn
+	listedHotlistCards = "SELECT card_id FROM (SELECT DISTINCT ON (card_id) card_id, action FROM hotlist_entries ORDER BY card_id, id DESC) latest WHERE latest.action = 'add'"
+)
+
+// HotlistCard là một thẻ trong danh sách chặn
+type HotlistCard struct {
+	CardID  string        `json:"card_id"`
+	Status  consts.Status `json:"status"`
+	Version uint64        `json:"version"`
+}
+
+// Hotlist là nội dung danh sách chặn gửi xuống thiết bị.
+// Full = true khi là toàn bộ danh sách; ngược lại là các thay đổi từ phiên bản Since tới Version.
+type Hotlist struct {
+	Version     uint64        `json:"version"`
+	Since       uint64        `json:"since"`
+	Full        bool          `json:"full"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Added       []HotlistCard `json:"added"`
+	Removed     []string      `json:"removed"`
+}
+
+// SignedHotlist là Hotlist đã ký. Payload là JSON của Hotlist mã hóa base64;
+// thiết bị kiểm tra Signature trên đúng các byte đã giải mã trước khi dùng.
+type SignedHotlist struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Version   uint64 `json:"version"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// HotlistKey là khóa công khai thiết bị dùng để kiểm tra chữ ký
+type HotlistKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // base64
+}
+
+type hotlistSigner struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+}
+
+var (
+	hotlistSignerOnce sync.Once
+	hotlistSignerKey  hotlistSigner
+)
+
+// loadHotlistSigner đọc HOTLIST_SIGNING_KEY (seed Ed25519 32 byte, base64) và HOTLIST_KEY_ID.
+// Nếu chưa cấu hình, sinh khóa tạm thời; thiết bị phải tải lại khóa công khai sau mỗi lần khởi động.
+func loadHotlistSigner() hotlistSigner {
+	signer := hotlistSigner{keyID: os.Getenv("HOTLIST_KEY_ID")}
+	if signer.keyID == "" {
+		signer.keyID = "hotlist-1"
+	}
+
+	if encoded := os.Getenv("HOTLIST_SIGNING_KEY"); encoded != "" {
+		seed, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil && len(seed) == ed25519.SeedSize {
+			signer.privateKey = ed25519.NewKeyFromSeed(seed)
+			return signer
+		}
+		log.Println("⚠️ HOTLIST_SIGNING_KEY must be a base64 encoded 32-byte Ed25519 seed")
+	}
+
+	log.Println("⚠️ HOTLIST_SIGNING_KEY is not set; hotlists are signed with an ephemeral key")
+	_, signer.privateKey, _ = ed25519.GenerateKey(rand.Reader)
+	signer.keyID += "-ephemeral"
+	return signer
+}
+
+func currentHotlistSigner() hotlistSigner {
+	hotlistSignerOnce.Do(func() {
+		hotlistSignerKey = loadHotlistSigner()
+	})
+	return hotlistSignerKey
+}
+
+// GetHotlistKey trả về khóa công khai hiện tại
+func GetHotlistKey() HotlistKey {
+	signer := currentHotlistSigner()
+	return HotlistKey{
+		KeyID:     signer.keyID,
+		Algorithm: HotlistSignatureAlgorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(signer.privateKey.Public().(ed25519.PublicKey)),
+	}
+}
+
+// SignHotlist ký danh sách chặn
+func SignHotlist(hotlist Hotlist) (SignedHotlist, error) {
+	payload, err := json.Marshal(hotlist)
+	if err != nil {
+		return SignedHotlist{}, err
+	}
+
+	signer := currentHotlistSigner()
+	return SignedHotlist{
+		KeyID:     signer.keyID,
+		Algorithm: HotlistSignatureAlgorithm,
+		Version:   hotlist.Version,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(signer.privateKey, payload)),
+	}, nil
+}
+
+// CurrentHotlistVersion trả về phiên bản mới nhất của danh sách chặn
+func CurrentHotlistVersion(db *gorm.DB) (uint64, error) {
+	var version uint64
+	err := db.Model(&models.HotlistEntry{}).Select("COALESCE(MAX(id), 0)").Scan(&version).Error
+	return version, err
+}
+
+// BuildHotlist trả về các thay đổi từ phiên bản since.
+// since = 0, hoặc lớn hơn phiên bản hiện tại (thiết bị bị reset), trả về toàn bộ danh sách.
+func BuildHotlist(since uint64) (Hotlist, error) {
+	hotlist := Hotlist{
+		Since:       since,
+		GeneratedAt: time.Now(),
+		Added:       []HotlistCard{},
+		Removed:     []string{},
+	}
+
+	version, err := CurrentHotlistVersion(config.DB)
+	if err != nil {
+		return hotlist, err
+	}
+	hotlist.Version = version
+	if since == 0 || since > version {
+		hotlist.Full = true
+		hotlist.Since = 0
+	}
+
+	entries := []models.HotlistEntry{}
+	if err := config.DB.Raw(latestHotlistEntries, hotlist.Since, version).Scan(&entries).Error; err != nil {
+		return hotlist, err
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Action == models.HotlistAdd:
+			hotlist.Added = append(hotlist.Added, HotlistCard{CardID: entry.CardID, Status: entry.Status, Version: entry.ID})
+		case !hotlist.Full:
+			hotlist.Removed = append(hotlist.Removed, entry.CardID)
+		}
+	}
+	return hotlist, nil
+}
+
+// lockHotlist tuần tự hóa việc ghi hotlist đến hết transaction
+func lockHotlist(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", hotlistLockKey).Error
+}
+
+// SyncCardHotlist ghi thay đổi hotlist khi trạng thái thẻ vừa đổi; gọi trong cùng transaction với việc cập nhật thẻ.
+// Sau khi commit, gọi Hotlists.Wake() để đẩy phiên bản mới xuống thiết bị ngay.
+func SyncCardHotlist(tx *gorm.DB, card models.Card) error {
+	if err := lockHotlist(tx); err != nil {
+		return err
+	}
+
+	var latest models.HotlistEntry
+	listed := false
+	result := tx.Where("card_id = ?", card.RFID).Order("id DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		listed = latest.Action == models.HotlistAdd
+	}
+
+	if listed == card.Status.IsHotlisted() {
+		return nil
+	}
+
+	entry := models.HotlistEntry{CardID: card.RFID, Action: models.HotlistRemove, Status: card.Status}
+	if !listed {
+		entry.Action = models.HotlistAdd
+	}
+	return tx.Create(&entry).Error
+}
+
+// ReconcileHotlist đối chiếu hotlist với trạng thái thẻ hiện tại, bắt cả những thay đổi
+// không đi qua SyncCardHotlist (cập nhật trực tiếp trong database, xóa thẻ). Trả về số thay đổi đã ghi.
+func ReconcileHotlist() (int, error) {
+	changed := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockHotlist(tx); err != nil {
+			return err
+		}
+
+		var missing []models.Card
+		if err := tx.Select("rf_id", "status").
+			Where("status IN ?", consts.HotlistStatuses).
+			Where("rf_id NOT IN (" + listedHotlistCards + ")").
+			Find(&missing).Error; err != nil {
+			return err
+		}
+
+		var stale []string
+		if err := tx.Raw(listedHotlistCards+" AND latest.card_id NOT IN (SELECT rf_id FROM cards WHERE status IN ? AND deleted_at IS NULL)", consts.HotlistStatuses).
+			Scan(&stale).Error; err != nil {
+			return err
+		}
+
+		entries := make([]models.HotlistEntry, 0, len(missing)+len(stale))
+		for _, card := range missing {
+			entries = append(entries, models.HotlistEntry{CardID: card.RFID, Action: models.HotlistAdd, Status: card.Status})
+		}
+		for _, cardID := range stale {
+			entries = append(entries, models.HotlistEntry{CardID: cardID, Action: models.HotlistRemove})
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		changed = len(entries)
+		return tx.Create(&entries).Error
+	})
+	return changed, err
+}
+
+// HotlistSyncInterval đọc chu kỳ đối chiếu hotlist từ HOTLIST_SYNC_INTERVAL_SECONDS (mặc định 5 giây)
+func HotlistSyncInterval() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("HOTLIST_SYNC_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultHotlistSyncInterval
+}
+
+// HotlistHub báo phiên bản hotlist mới cho các thiết bị đang kết nối
+type HotlistHub struct {
+	mu          sync.Mutex
+	subscribers map[chan uint64]struct{}
+	wake        chan struct{}
+	version     uint64
+}
+
+// Hotlists là hub dùng chung cho toàn bộ ứng dụng
+var Hotlists = NewHotlistHub()
+
+func NewHotlistHub() *HotlistHub {
+	return &HotlistHub{
+		subscribers: make(map[chan uint64]struct{}),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Subscribe đăng ký nhận phiên bản mới; gọi hàm trả về để hủy đăng ký
+func (h *HotlistHub) Subscribe() (<-chan uint64, func()) {
+	versions := make(chan uint64, 1)
+
+	h.mu.Lock()
+	h.subscribers[versions] = struct{}{}
+	h.mu.Unlock()
+
+	return versions, func() {
+		h.mu.Lock()
+		delete(h.subscribers, versions)
+		h.mu.Unlock()
+	}
+}
+
+// publish gửi phiên bản mới mà không chờ; client đang có thông báo chưa đọc sẽ nhận phiên bản mới nhất khi tải delta
+func (h *HotlistHub) publish(version uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.version = version
+	for versions := range h.subscribers {
+		select {
+		case versions <- version:
+		default:
+		}
+	}
+}
+
+// Wake yêu cầu kiểm tra và đẩy phiên bản mới ngay. Hàm không bao giờ chặn.
+func (h *HotlistHub) Wake() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run đối chiếu hotlist định kỳ và báo thiết bị khi phiên bản thay đổi; chạy trong một goroutine riêng
+func (h *HotlistHub) Run() {
+	ticker := time.NewTicker(HotlistSyncInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := ReconcileHotlist(); err != nil {
+			log.Println("⚠️ Failed to reconcile hotlist:", err)
+		}
+
+		version, err := CurrentHotlistVersion(config.DB)
+		if err != nil {
+			log.Println("⚠️ Failed to read hotlist version:", err)
+		} else {
+			h.mu.Lock()
+			changed := version != h.version
+			h.mu.Unlock()
+			if changed {
+				h.publish(version)
+			}
+		}
+
+		select {
+		case <-h.wake:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Journey là một chuyến đi của khách, ghép từ lượt vào ga và lượt ra ga của cùng một thẻ.
+// CheckIn nil khi chỉ có lượt ra (lượt vào nằm ngoài khoảng lọc hoặc bị thiếu);
+// CheckOut nil khi khách đang đi hoặc không quẹt ra.
+type Journey struct {
+	CardID   string                 `json:"card_id"`
+	CheckIn  *models.StationHistory `json:"check_in"`
+	CheckOut *models.StationHistory `json:"check_out"`
+	Fare     float64                `json:"fare"`
+}
+
+// StartedAt là thời điểm bắt đầu chuyến (lượt vào, hoặc lượt ra nếu không có lượt vào)
+func (j Journey) StartedAt() time.Time {
+	if j.CheckIn != nil {
+		return j.CheckIn.Time
+	}
+	return j.CheckOut.Time
+}
+
+// journeyTaps đánh dấu lượt quẹt trước và sau của cùng thẻ theo thời gian
+const journeyTaps = "id, card_id, action, time, " +
+	"LAG(action) OVER (PARTITION BY card_id ORDER BY time, id) AS previous_action, " +
+	"LEAD(id) OVER (PARTITION BY card_id ORDER BY time, id) AS next_id, " +
+	"LEAD(action) OVER (PARTITION BY card_id ORDER BY time, id) AS next_action"
+
+// journeyStart là lượt quẹt mở đầu một chuyến và lượt ra được ghép với nó
+type journeyStart struct {
+	ID         uint
+	CheckOutID *uint
+}
+
+// PageJourneys ghép các lượt quẹt của taps thành chuyến đi, mới nhất trước, và chỉ nạp trang cần lấy.
+// Lượt vào được ghép với lượt ra kế tiếp của cùng thẻ; hai lượt vào liên tiếp thì lượt đầu thành
+// chuyến thiếu lượt ra. Việc ghép và phân trang chạy trong SQL nên không phải nạp toàn bộ lịch sử.
+func PageJourneys(taps *gorm.DB, offset, limit int) ([]Journey, error) {
+	marked := taps.Model(&models.StationHistory{}).Select(journeyTaps)
+
+	var starts []journeyStart
+	if err := config.DB.Table("(?) AS taps", marked).
+		Select("id, CASE WHEN action = ? AND next_action = ? THEN next_id END AS check_out_id", TapCheckIn, TapCheckOut).
+		Where("action = ? OR (action = ? AND previous_action IS DISTINCT FROM ?)", TapCheckIn, TapCheckOut, TapCheckIn).
+		Order("time DESC, id DESC").Offset(offset).Limit(limit).
+		Scan(&starts).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, 2*len(starts))
+	for _, start := range starts {
+		ids = append(ids, start.ID)
+		if start.CheckOutID != nil {
+			ids = append(ids, *start.CheckOutID)
+		}
+	}
+	journeys := make([]Journey, 0, len(starts))
+	if len(ids) == 0 {
+		return journeys, nil
+	}
+
+	var loaded []models.StationHistory
+	if err := config.DB.Preload("Card", models.WithDeleted).Preload("Station", models.WithDeleted).
+		Where("id IN ?", ids).Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.StationHistory, len(loaded))
+	for i := range loaded {
+		byID[loaded[i].ID] = &loaded[i]
+	}
+
+	for _, start := range starts {
+		tap, ok := byID[start.ID]
+		if !ok {
+			continue
+		}
+		journey := Journey{CardID: tap.CardID}
+		if tap.Action == TapCheckIn {
+			journey.CheckIn = tap
+			if start.CheckOutID != nil {
+				journey.CheckOut = byID[*start.CheckOutID]
+			}
+		} else {
+			journey.CheckOut = tap
+		}
+		if journey.CheckOut != nil {
+			journey.Fare = journey.CheckOut.UsedBalance
+		}
+		journeys = append(journeys, journey)
+	}
+	return journeys, nil
+}
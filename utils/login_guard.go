@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"go-metro/config"
+	"go-metro/models"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginGuardPolicy là ngưỡng chống dò mật khẩu
+type LoginGuardPolicy struct {
+	MaxAccountFailures int           // số lần sai liên tiếp trước khi khóa tài khoản
+	MaxIPFailures      int           // số lần sai từ một IP trước khi khóa IP
+	LockoutDuration    time.Duration // thời gian khóa tạm thời
+	BackoffBase        time.Duration // thời gian chờ sau lần sai đầu tiên, nhân đôi mỗi lần sai tiếp theo
+	BackoffMax         time.Duration
+	FailureWindow      time.Duration // bộ đếm được làm mới nếu không sai thêm trong khoảng này
+}
+
+// LoadLoginGuardPolicy đọc chính sách từ biến môi trường
+func LoadLoginGuardPolicy() LoginGuardPolicy {
+	return LoginGuardPolicy{
+		MaxAccountFailures: envInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		MaxIPFailures:      envInt("LOGIN_MAX_IP_FAILURES", 20),
+		LockoutDuration:    time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		BackoffBase:        time.Duration(envInt("LOGIN_BACKOFF_BASE_SECONDS", 1)) * time.Second,
+		BackoffMax:         time.Duration(envInt("LOGIN_BACKOFF_MAX_SECONDS", 60)) * time.Second,
+		FailureWindow:      time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// backoff trả về thời gian chờ sau failures lần sai liên tiếp
+func (p LoginGuardPolicy) backoff(failures int) time.Duration {
+	if failures <= 0 || p.BackoffBase <= 0 {
+		return 0
+	}
+	delay := time.Duration(float64(p.BackoffBase) * math.Pow(2, float64(failures-1)))
+	if delay > p.BackoffMax || delay <= 0 {
+		return p.BackoffMax
+	}
+	return delay
+}
+
+// LoginThrottledError báo đăng nhập bị tạm chặn tới thời điểm Until
+type LoginThrottledError struct {
+	Until  time.Time
+	Locked bool // true: đã vượt ngưỡng và bị khóa; false: đang trong thời gian chờ backoff
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "too many failed login attempts, temporarily locked"
+	}
+	return "too many failed login attempts, slow down"
+}
+
+// RetryAfter là số giây còn lại trước khi được thử lại
+func (e *LoginThrottledError) RetryAfter() int {
+	return int(math.Ceil(time.Until(e.Until).Seconds()))
+}
+
+// LoginAttempt là thông tin một lần đăng nhập
+type LoginAttempt struct {
+	Email     string
+	UserID    *uint
+	IPAddress string
+	UserAgent string
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LogSecurityEvent ghi sự kiện bảo mật; lỗi chỉ được ghi log để không chặn luồng chính
+func LogSecurityEvent(event models.SecurityEvent) {
+	if err := config.DB.Create(&event).Error; err != nil {
+		log.Println("⚠️ Failed to write security event:", err)
+	}
+}
+
+func securityEvent(name string, attempt LoginAttempt, detail string) models.SecurityEvent {
+	return models.SecurityEvent{
+		Event:     name,
+		UserID:    attempt.UserID,
+		Email:     normalizeLoginEmail(attempt.Email),
+		IPAddress: attempt.IPAddress,
+		UserAgent: attempt.UserAgent,
+		Detail:    detail,
+	}
+}
+
+// throttleState kiểm tra một bộ đếm còn đang chặn hay không
+func throttleState(row models.LoginThrottle, now time.Time) *LoginThrottledError {
+	if row.LockedUntil != nil && now.Before(*row.LockedUntil) {
+		return &LoginThrottledError{Until: *row.LockedUntil, Locked: true}
+	}
+	if row.BlockedUntil != nil && now.Before(*row.BlockedUntil) {
+		return &LoginThrottledError{Until: *row.BlockedUntil}
+	}
+	return nil
+}
+
+// CheckLoginAllowed trả về *LoginThrottledError nếu tài khoản hoặc IP đang bị chặn
+func CheckLoginAllowed(attempt LoginAttempt) error {
+	rows := []models.LoginThrottle{}
+	if err := config.DB.
+		Where("(scope = ? AND key = ?) OR (scope = ? AND key = ?)", models.ThrottleAccount, normalizeLoginEmail(attempt.Email), models.ThrottleIP, attempt.IPAddress).
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var blocked *LoginThrottledError
+	for _, row := range rows {
+		if state := throttleState(row, now); state != nil && (blocked == nil || state.Until.After(blocked.Until)) {
+			blocked = state
+		}
+	}
+	if blocked != nil {
+		LogSecurityEvent(securityEvent(models.EventLoginThrottled, attempt, blocked.Error()))
+		return blocked
+	}
+	return nil
+}
+
+// recordFailure tăng bộ đếm của một phạm vi; trả về true nếu lần sai này làm khóa phạm vi đó
+func recordFailure(tx *gorm.DB, scope, key string, threshold int, policy LoginGuardPolicy, now time.Time) (bool, error) {
+	row := models.LoginThrottle{Scope: scope, Key: key}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND key = ?", scope, key).
+		First(&row).Error; err != nil {
+		return false, err
+	}
+
+	// Làm mới bộ đếm khi lần sai trước đã lâu hoặc đã hết thời gian khóa
+	expiredLock := row.LockedUntil != nil && !now.Before(*row.LockedUntil)
+	if expiredLock || row.LastFailureAt == nil || now.Sub(*row.LastFailureAt) > policy.FailureWindow {
+		row.Failures = 0
+		row.LockedUntil = nil
+	}
+
+	row.Failures++
+	row.LastFailureAt = &now
+	blockedUntil := now.Add(policy.backoff(row.Failures))
+	row.BlockedUntil = &blockedUntil
+
+	locked := false
+	if threshold > 0 && row.Failures >= threshold && row.LockedUntil == nil {
+		lockedUntil := now.Add(policy.LockoutDuration)
+		row.LockedUntil = &lockedUntil
+		locked = true
+	}
+
+	return locked, tx.Save(&row).Error
+}
+
+// RecordLoginFailure ghi một lần đăng nhập sai cho cả tài khoản và IP.
+// Email không tồn tại cũng được đếm để không lộ tài khoản nào có thật.
+func RecordLoginFailure(attempt LoginAttempt, reason string) {
+	policy := LoadLoginGuardPolicy()
+	now := time.Now()
+	var accountLocked, ipLocked bool
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if accountLocked, err = recordFailure(tx, models.ThrottleAccount, normalizeLoginEmail(attempt.Email), policy.MaxAccountFailures, policy, now); err != nil {
+			return err
+		}
+		if attempt.IPAddress != "" {
+			ipLocked, err = recordFailure(tx, models.ThrottleIP, attempt.IPAddress, policy.MaxIPFailures, policy, now)
+		}
+		return err
+	})
+	if err != nil {
+		log.Println("⚠️ Failed to record login failure:", err)
+	}
+
+	LogSecurityEvent(securityEvent(models.EventLoginFailed, attempt, reason))
+	if accountLocked {
+		LogSecurityEvent(securityEvent(models.EventAccountLocked, attempt, fmt.Sprintf("locked for %s after %d failed attempts", policy.LockoutDuration, policy.MaxAccountFailures)))
+	}
+	if ipLocked {
+		LogSecurityEvent(securityEvent(models.EventIPLocked, attempt, fmt.Sprintf("locked for %s after %d failed attempts", policy.LockoutDuration, policy.MaxIPFailures)))
+	}
+}
+
+// RecordLoginSuccess xóa bộ đếm của tài khoản sau khi đăng nhập đúng.
+// Bộ đếm theo IP được giữ để kẻ tấn công không tự làm mới bằng tài khoản của chính mình.
+func RecordLoginSuccess(attempt LoginAttempt) {
+	if err := config.DB.Where("scope = ? AND key = ?", models.ThrottleAccount, normalizeLoginEmail(attempt.Email)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		log.Println("⚠️ Failed to reset login throttle:", err)
+	}
+	LogSecurityEvent(securityEvent(models.EventLoginSucceeded, attempt, ""))
+}
+
+// ErrNoLockout báo không có bộ đếm nào để mở khóa
+var ErrNoLockout = errors.New("no lockout found")
+
+// UnlockLogin xóa bộ đếm của một tài khoản (email) hoặc IP và ghi sự kiện mở khóa
+func UnlockLogin(db *gorm.DB, scope, key string, actorID uint, userID *uint) error {
+	if scope == models.ThrottleAccount {
+		key = normalizeLoginEmail(key)
+	}
+
+	result := db.Where("scope = ? AND key = ?", scope, key).Delete(&models.LoginThrottle{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNoLockout
+	}
+
+	event := models.SecurityEvent{Event: models.EventIPUnlocked, IPAddress: key, UserID: userID, ActorID: &actorID}
+	if scope == models.ThrottleAccount {
+		event = models.SecurityEvent{Event: models.EventAccountUnlocked, Email: key, UserID: userID, ActorID: &actorID}
+	}
+	LogSecurityEvent(event)
+	return nil
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailMessage là một email văn bản thuần
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer gửi email. Có thể thay bằng SetMailer (ví dụ MemoryMailer khi test).
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+// formatMail dựng email theo RFC 5322
+func formatMail(from string, message MailMessage) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// SMTPMailer gửi email qua máy chủ SMTP (STARTTLS nếu máy chủ hỗ trợ)
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(message MailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{message.To}, formatMail(m.From, message))
+}
+
+// FileMailer ghi mỗi email thành một file .eml trong Dir, dùng khi phát triển
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(message MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(message.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), formatMail(m.From, message), 0o600)
+}
+
+// MemoryMailer giữ email trong bộ nhớ, dùng khi test
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []MailMessage
+}
+
+func (m *MemoryMailer) Send(message MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages trả về bản sao các email đã gửi
+func (m *MemoryMailer) Messages() []MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MailMessage(nil), m.messages...)
+}
+
+var (
+	mailerMu sync.Mutex
+	mailer   Mailer
+)
+
+// newMailer chọn mailer theo MAILER=smtp|file|memory.
+// Mặc định dùng SMTP nếu có SMTP_HOST, ngược lại ghi file vào MAIL_DIR (mặc định ./mail).
+func newMailer() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Go Metro <no-reply@go-metro.local>"
+	}
+
+	kind := os.Getenv("MAILER")
+	if kind == "" {
+		kind = "file"
+		if os.Getenv("SMTP_HOST") != "" {
+			kind = "smtp"
+		}
+	}
+
+	switch kind {
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "memory":
+		return &MemoryMailer{}
+	default:
+		if kind != "file" {
+			log.Printf("⚠️ Unknown MAILER %q, writing emails to files", kind)
+		}
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return &FileMailer{Dir: dir, From: from}
+	}
+}
+
+// CurrentMailer trả về mailer đang dùng, khởi tạo từ biến môi trường ở lần gọi đầu
+func CurrentMailer() Mailer {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	if mailer == nil {
+		mailer = newMailer()
+	}
+	return mailer
+}
+
+// SetMailer thay mailer đang dùng
+func SetMailer(m Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	mailer = m
+}
+
+// SendMail gửi email qua mailer hiện tại
+func SendMail(message MailMessage) error {
+	return CurrentMailer().Send(message)
+}
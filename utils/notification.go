@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultNotificationPollInterval = 10 * time.Second
+
+	notificationBatchSize     = 50
+	notificationRetryBase     = 30 * time.Second
+	notificationRetryMaxDelay = time.Hour
+)
+
+// NotificationPollInterval đọc chu kỳ quét thông báo chờ gửi từ NOTIFICATION_POLL_SECONDS (mặc định 10 giây)
+func NotificationPollInterval() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("NOTIFICATION_POLL_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultNotificationPollInterval
+}
+
+// notificationMaxAttempts là số lần gửi tối đa trước khi thông báo bị đánh dấu failed (NOTIFICATION_MAX_ATTEMPTS, mặc định 5)
+func notificationMaxAttempts() int {
+	return envInt("NOTIFICATION_MAX_ATTEMPTS", 5)
+}
+
+// notificationRetryDelay là thời gian chờ trước lần gửi lại thứ attempts: 30 giây, gấp đôi mỗi lần, tối đa 1 giờ
+func notificationRetryDelay(attempts int) time.Duration {
+	delay := notificationRetryBase
+	for i := 1; i < attempts && delay < notificationRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > notificationRetryMaxDelay {
+		delay = notificationRetryMaxDelay
+	}
+	return delay
+}
+
+// LoadNotificationPreference trả về lựa chọn nhận thông báo đã lưu, hoặc lựa chọn mặc định
+func LoadNotificationPreference(db *gorm.DB, userID uint) (models.NotificationPreference, error) {
+	var saved models.NotificationPreference
+	result := db.Where("user_id = ?", userID).Limit(1).Find(&saved)
+	if result.Error != nil {
+		return saved, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	return saved, nil
+}
+
+// SaveNotificationPreference lưu lựa chọn nhận thông báo của người dùng
+func SaveNotificationPreference(preference *models.NotificationPreference) error {
+	return config.DB.Omit("User").Clauses(clause.OnConflict{UpdateAll: true}).Create(preference).Error
+}
+
+// QueueNotification ghi thông báo cho từng kênh người dùng đã bật trong transaction tx.
+// Thông báo trùng dedupKey trên cùng kênh bị bỏ qua nên gọi lại cho cùng một sự kiện là an toàn.
+// Email chỉ gửi tới địa chỉ đã xác minh; SMS gửi tới số điện thoại trong hồ sơ.
+func QueueNotification(tx *gorm.DB, userID uint, event consts.NotificationEvent, dedupKey, subject, body string) error {
+	preference, err := LoadNotificationPreference(tx, userID)
+	if err != nil {
+		return err
+	}
+	if !preference.Wants(event) {
+		return nil
+	}
+
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	type target struct {
+		channel   consts.NotificationChannel
+		recipient string
+	}
+	var targets []target
+	if preference.Email && user.EmailVerified() {
+		targets = append(targets, target{consts.ChannelEmail, user.Email})
+	}
+	if preference.SMS && user.Phone != "" {
+		targets = append(targets, target{consts.ChannelSMS, user.Phone})
+	}
+	if preference.Push && preference.PushToken != "" {
+		targets = append(targets, target{consts.ChannelPush, preference.PushToken})
+	}
+
+	now := time.Now()
+	for _, t := range targets {
+		notification := models.Notification{
+			UserID:        userID,
+			Event:         event,
+			Channel:       t.channel,
+			DedupKey:      dedupKey,
+			Recipient:     t.recipient,
+			Subject:       subject,
+			Body:          body,
+			Status:        consts.NotificationPending,
+			NextAttemptAt: now,
+		}
+		if err := tx.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(&notification).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyCardHolder ghi thông báo cho chủ thẻ trong savepoint của tx: lỗi chỉ được ghi log,
+// không làm hỏng giao dịch của thẻ. Thẻ vô danh không có ai để báo.
+func notifyCardHolder(tx *gorm.DB, card models.Card, event consts.NotificationEvent, dedupKey, subject, body string) {
+	if !card.Registered() {
+		return
+	}
+	if err := tx.Transaction(func(tx *gorm.DB) error {
+		return QueueNotification(tx, *card.UserID, event, dedupKey, subject, body)
+	}); err != nil {
+		log.Println("⚠️ Failed to queue notification:", err)
+	}
+}
+
+// NotifyLowBalance báo chủ thẻ khi số dư xuống dưới ngưỡng họ đặt, tối đa một lần mỗi ngày cho mỗi thẻ
+func NotifyLowBalance(tx *gorm.DB, card models.Card) {
+	if !card.Registered() {
+		return
+	}
+	preference, err := LoadNotificationPreference(tx, *card.UserID)
+	if err != nil {
+		log.Println("⚠️ Failed to load notification preference:", err)
+		return
+	}
+	if !preference.LowBalance || card.Balance >= preference.LowBalanceThreshold {
+		return
+	}
+
+	notifyCardHolder(tx, card, consts.NotifyLowBalance,
+		fmt.Sprintf("low_balance:%s:%s", card.RFID, time.Now().Format("2006-01-02")),
+		"Số dư thẻ Go Metro sắp hết",
+		fmt.Sprintf("Số dư thẻ %s còn %.0f đ, thấp hơn mức cảnh báo %.0f đ. "+
+			"Cần tối thiểu %.0f đ để vào ga, vui lòng nạp thêm tiền.\n",
+			card.RFID, card.Balance, preference.LowBalanceThreshold, MinCheckInBalance))
+}
+
+// NotifyTopUp xác nhận nạp tiền cho chủ thẻ; at là thời điểm nạp, dùng để phân biệt các lần nạp
+func NotifyTopUp(tx *gorm.DB, card models.Card, amount float64, at time.Time) {
+	notifyCardHolder(tx, card, consts.NotifyTopUp,
+		fmt.Sprintf("topup:%s:%d", card.RFID, at.UnixNano()),
+		"Nạp tiền thẻ Go Metro thành công",
+		fmt.Sprintf("Thẻ %s vừa được nạp %.0f đ lúc %s. Số dư hiện tại: %.0f đ.\n",
+			card.RFID, amount, at.Format("15:04 02/01/2006"), card.Balance))
+}
+
+// NotifyCardBlocked báo chủ thẻ khi thẻ bị khóa
+func NotifyCardBlocked(tx *gorm.DB, card models.Card) {
+	now := time.Now()
+	notifyCardHolder(tx, card, consts.NotifyCardBlocked,
+		fmt.Sprintf("card_blocked:%s:%d", card.RFID, now.Unix()),
+		"Thẻ Go Metro đã bị khóa",
+		fmt.Sprintf("Thẻ %s đã bị khóa lúc %s và không thể dùng để qua cổng. "+
+			"Nếu bạn không yêu cầu khóa thẻ, vui lòng liên hệ quầy dịch vụ.\n",
+			card.RFID, now.Format("15:04 02/01/2006")))
+}
+
+// NotifyCardExpiring báo chủ thẻ thẻ sắp hết hạn, một lần cho mỗi hạn dùng
+func NotifyCardExpiring(tx *gorm.DB, card models.Card) {
+	if card.ExpiresAt == nil {
+		return
+	}
+	notifyCardHolder(tx, card, consts.NotifyCardExpiring,
+		fmt.Sprintf("card_expiring:%s:%s", card.RFID, card.ExpiresAt.Format("2006-01-02")),
+		"Thẻ Go Metro sắp hết hạn",
+		fmt.Sprintf("Thẻ %s sẽ hết hạn vào %s. Vui lòng gia hạn hoặc đổi thẻ tại quầy dịch vụ; số dư còn lại có thể chuyển sang thẻ mới.\n",
+			card.RFID, card.ExpiresAt.In(time.Local).Format("02/01/2006")))
+}
+
+// deliverNotification gửi một thông báo và ghi kết quả; lỗi gửi được hẹn gửi lại với backoff
+func deliverNotification(tx *gorm.DB, notification *models.Notification, now time.Time) error {
+	notification.Attempts++
+
+	err := fmt.Errorf("no notifier for channel %s", notification.Channel)
+	if notifier := CurrentNotifier(notification.Channel); notifier != nil {
+		err = notifier.Send(NotificationMessage{
+			Channel:   notification.Channel,
+			Event:     notification.Event,
+			Recipient: notification.Recipient,
+			Subject:   notification.Subject,
+			Body:      notification.Body,
+		})
+	}
+
+	if err == nil {
+		notification.Status = consts.NotificationSent
+		notification.SentAt = &now
+		notification.LastError = ""
+	} else {
+		notification.LastError = err.Error()
+		if notification.Attempts >= notificationMaxAttempts() {
+			notification.Status = consts.NotificationFailed
+		} else {
+			notification.NextAttemptAt = now.Add(notificationRetryDelay(notification.Attempts))
+		}
+	}
+
+	return tx.Model(notification).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at").
+		Updates(notification).Error
+}
+
+// DispatchNotifications gửi các thông báo đến hạn. Thông báo đang được tiến trình khác gửi bị bỏ qua
+// (SKIP LOCKED) nên có thể chạy nhiều instance. Trả về số thông báo đã gửi thành công.
+func DispatchNotifications() (int, error) {
+	sent := 0
+	for {
+		var due []models.Notification
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND next_attempt_at <= ?", consts.NotificationPending, now).
+				Order("id").Limit(notificationBatchSize).Find(&due).Error; err != nil {
+				return err
+			}
+
+			for i := range due {
+				if err := deliverNotification(tx, &due[i], now); err != nil {
+					return err
+				}
+				if due[i].Status == consts.NotificationSent {
+					sent++
+				}
+			}
+			return nil
+		})
+		if err != nil || len(due) < notificationBatchSize {
+			return sent, err
+		}
+	}
+}
+
+// NotificationDispatcher gửi thông báo chờ gửi định kỳ hoặc ngay khi được đánh thức
+type NotificationDispatcher struct {
+	wake chan struct{}
+}
+
+// Notifications là dispatcher dùng chung cho toàn bộ ứng dụng
+var Notifications = &NotificationDispatcher{wake: make(chan struct{}, 1)}
+
+// Wake yêu cầu gửi các thông báo mới ngay; gọi sau khi commit transaction đã ghi thông báo. Hàm không bao giờ chặn.
+func (d *NotificationDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run gửi thông báo đến hạn; chạy trong một goroutine riêng
+func (d *NotificationDispatcher) Run() {
+	ticker := time.NewTicker(NotificationPollInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := DispatchNotifications(); err != nil {
+			log.Println("⚠️ Failed to dispatch notifications:", err)
+		}
+
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
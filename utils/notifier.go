@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-metro/consts"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// NotificationMessage là nội dung gửi tới một người nhận trên một kênh
+type NotificationMessage struct {
+	Channel   consts.NotificationChannel
+	Event     consts.NotificationEvent
+	Recipient string // email, số điện thoại hoặc push token
+	Subject   string
+	Body      string
+}
+
+// Notifier gửi thông báo trên một kênh. Có thể thay bằng SetNotifier (ví dụ LogNotifier khi test).
+type Notifier interface {
+	Send(message NotificationMessage) error
+}
+
+// EmailNotifier gửi thông báo qua mailer hiện tại
+type EmailNotifier struct{}
+
+func (EmailNotifier) Send(message NotificationMessage) error {
+	return SendMail(MailMessage{To: message.Recipient, Subject: message.Subject, Body: message.Body})
+}
+
+// GatewayNotifier gửi thông báo tới dịch vụ SMS/push bên ngoài qua HTTP POST JSON
+// {"to", "event", "subject", "body"}; mọi mã trạng thái ngoài 2xx được coi là lỗi để gửi lại.
+type GatewayNotifier struct {
+	URL    string
+	Token  string // gửi kèm "Authorization: Bearer <Token>" nếu có
+	Client *http.Client
+}
+
+func (n *GatewayNotifier) Send(message NotificationMessage) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      message.Recipient,
+		"event":   string(message.Event),
+		"subject": message.Subject,
+		"body":    message.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if n.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	response, err := n.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s gateway returned %s", message.Channel, response.Status)
+	}
+	return nil
+}
+
+// LogNotifier chỉ ghi log và giữ thông báo trong bộ nhớ, dùng khi phát triển và test
+type LogNotifier struct {
+	mu       sync.Mutex
+	messages []NotificationMessage
+}
+
+func (n *LogNotifier) Send(message NotificationMessage) error {
+	log.Printf("🔔 [%s] %s to %s: %s", message.Channel, message.Event, message.Recipient, message.Subject)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+// Messages trả về bản sao các thông báo đã gửi
+func (n *LogNotifier) Messages() []NotificationMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]NotificationMessage(nil), n.messages...)
+}
+
+var (
+	notifiersMu sync.Mutex
+	notifiers   map[consts.NotificationChannel]Notifier
+)
+
+// newGatewayNotifier dùng GatewayNotifier nếu có URL, ngược lại chỉ ghi log
+func newGatewayNotifier(urlKey, tokenKey string, fallback Notifier) Notifier {
+	url := os.Getenv(urlKey)
+	if url == "" {
+		return fallback
+	}
+	return &GatewayNotifier{URL: url, Token: os.Getenv(tokenKey), Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// newNotifiers chọn notifier cho từng kênh theo biến môi trường.
+// NOTIFIER=log gửi mọi kênh vào LogNotifier. Ngược lại email đi qua mailer (MAILER),
+// SMS qua SMS_GATEWAY_URL và push qua PUSH_GATEWAY_URL; kênh chưa cấu hình gateway chỉ ghi log.
+func newNotifiers() map[consts.NotificationChannel]Notifier {
+	logger := &LogNotifier{}
+	if os.Getenv("NOTIFIER") == "log" {
+		return map[consts.NotificationChannel]Notifier{
+			consts.ChannelEmail: logger,
+			consts.ChannelSMS:   logger,
+			consts.ChannelPush:  logger,
+		}
+	}
+
+	return map[consts.NotificationChannel]Notifier{
+		consts.ChannelEmail: EmailNotifier{},
+		consts.ChannelSMS:   newGatewayNotifier("SMS_GATEWAY_URL", "SMS_GATEWAY_TOKEN", logger),
+		consts.ChannelPush:  newGatewayNotifier("PUSH_GATEWAY_URL", "PUSH_GATEWAY_TOKEN", logger),
+	}
+}
+
+// CurrentNotifier trả về notifier của kênh, khởi tạo từ biến môi trường ở lần gọi đầu
+func CurrentNotifier(channel consts.NotificationChannel) Notifier {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	if notifiers == nil {
+		notifiers = newNotifiers()
+	}
+	return notifiers[channel]
+}
+
+// SetNotifier thay notifier của một kênh
+func SetNotifier(channel consts.NotificationChannel, n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	if notifiers == nil {
+		notifiers = newNotifiers()
+	}
+	notifiers[channel] = n
+}
@@ -0,0 +1,516 @@
+package utils
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotOrgMember             = errors.New("user is not a member of an organization")
+	ErrUserInOtherOrg           = errors.New("user already belongs to an organization")
+	ErrCardInOtherOrg           = errors.New("card already belongs to an organization")
+	ErrCardNotInOrg             = errors.New("card does not belong to this organization")
+	ErrHolderNotInOrg           = errors.New("card holder is not a member of this organization")
+	ErrLastOrgOwner             = errors.New("organization must keep at least one owner")
+	ErrWalletInsufficient       = errors.New("insufficient wallet balance")
+	ErrCardFundingLimitExceeded = errors.New("monthly funding limit of the card exceeded")
+)
+
+// OrgMembership trả về tư cách thành viên tổ chức của người dùng
+func OrgMembership(userID uint) (models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := config.DB.Where("user_id = ?", userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, ErrNotOrgMember
+	}
+	return member, err
+}
+
+// lockOrganization khóa ví của tổ chức trong transaction
+func lockOrganization(tx *gorm.DB, orgID uint) (models.Organization, error) {
+	var org models.Organization
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, orgID).Error
+	return org, err
+}
+
+// monthStart là đầu tháng (theo giờ địa phương) chứa thời điểm at
+func monthStart(at time.Time) time.Time {
+	at = at.In(time.Local)
+	return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.Local)
+}
+
+// CardMonthlyFunding là tổng tiền ví chung đã nạp cho thẻ trong tháng chứa thời điểm at
+func CardMonthlyFunding(db *gorm.DB, orgID uint, rfID string, at time.Time) (float64, error) {
+	start := monthStart(at)
+	var funded float64
+	err := db.Model(&models.WalletTransaction{}).
+		Where("organization_id = ? AND card_id = ? AND type = ?", orgID, rfID, consts.WalletTopup).
+		Where("created_at >= ? AND created_at < ?", start, start.AddDate(0, 1, 0)).
+		Select("COALESCE(SUM(-amount), 0)").Scan(&funded).Error
+	return funded, err
+}
+
+// DepositToWallet nạp tiền vào ví chung của tổ chức
+func DepositToWallet(db *gorm.DB, orgID uint, amount float64, actorID uint, note string) (models.WalletTransaction, error) {
+	var entry models.WalletTransaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		org, err := lockOrganization(tx, orgID)
+		if err != nil {
+			return err
+		}
+
+		org.WalletBalance += amount
+		if err := tx.Model(&org).Update("wallet_balance", org.WalletBalance).Error; err != nil {
+			return err
+		}
+		entry = models.WalletTransaction{
+			OrganizationID: org.ID,
+			Type:           consts.WalletDeposit,
+			Amount:         amount,
+			BalanceAfter:   org.WalletBalance,
+			ActorID:        &actorID,
+			Note:           note,
+		}
+		return tx.Create(&entry).Error
+	})
+	return entry, err
+}
+
+// FundCardTopUp dùng ví chung nạp tiền cho thẻ của tổ chức, trong hạn mức nạp tháng của thẻ.
+// Ví và thẻ được khóa trong cùng transaction nên hai lượt nạp đồng thời không vượt số dư hay hạn mức.
+func FundCardTopUp(orgID uint, rfID string, amount float64, actorID uint) (models.Card, models.WalletTransaction, error) {
+	var card models.Card
+	var entry models.WalletTransaction
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		org, err := lockOrganization(tx, orgID)
+		if err != nil {
+			return err
+		}
+
+		var link models.OrganizationCard
+		if err := tx.Where("organization_id = ? AND card_id = ?", orgID, rfID).First(&link).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCardNotInOrg
+			}
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", rfID).First(&card).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if link.MonthlyFundingLimit > 0 {
+			funded, err := CardMonthlyFunding(tx, orgID, rfID, now)
+			if err != nil {
+				return err
+			}
+			if funded+amount > link.MonthlyFundingLimit {
+				return ErrCardFundingLimitExceeded
+			}
+		}
+		if org.WalletBalance < amount {
+			return ErrWalletInsufficient
+		}
+
+		org.WalletBalance -= amount
+		if err := tx.Model(&org).Update("wallet_balance", org.WalletBalance).Error; err != nil {
+			return err
+		}
+		card.Balance += amount
+		if err := tx.Model(&card).Update("balance", card.Balance).Error; err != nil {
+			return err
+		}
+
+		entry = models.WalletTransaction{
+			OrganizationID: org.ID,
+			Type:           consts.WalletTopup,
+			Amount:         -amount,
+			BalanceAfter:   org.WalletBalance,
+			CardID:         &card.RFID,
+			ActorID:        &actorID,
+			CreatedAt:      now,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		history := models.History{
+			CardID:     card.RFID,
+			Time:       now,
+			UserID:     strconv.FormatUint(uint64(actorID), 10),
+			Balance:    card.Balance,
+			CardAction: consts.CardActionTopup,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		if err := RecordEvent(tx, consts.DomainCardToppedUp, card.RFID, map[string]interface{}{
+			"amount":          amount,
+			"balance":         card.Balance,
+			"source":          "org_wallet",
+			"organization_id": org.ID,
+			"actor_id":        actorID,
+		}); err != nil {
+			return err
+		}
+
+		NotifyTopUp(tx, card, amount, now)
+		return nil
+	})
+	if err == nil {
+		Notifications.Wake()
+		Outbox.Wake()
+	}
+	return card, entry, err
+}
+
+// OrgStatementCard là tổng tiền ví chung đã nạp cho một thẻ và tổng thẻ đã chi trong kỳ sao kê
+type OrgStatementCard struct {
+	CardID              string  `json:"card_id"`
+	UserID              *uint   `json:"user_id"` // người giữ thẻ
+	Topups              float64 `json:"topups"`
+	MonthlyFundingLimit float64 `json:"monthly_funding_limit"`
+	Fares               float64 `json:"fares"`
+	TransfersOut        float64 `json:"transfers_out"`
+}
+
+// OrgCardDebit là một khoản chi của thẻ tổ chức: tiền vé khi ra ga hoặc chuyển số dư sang thẻ khác
+type OrgCardDebit struct {
+	CardID     string               `json:"card_id"`
+	Type       consts.CardDebitType `json:"type"`
+	Amount     float64              `json:"amount"`
+	StationID  *uint                `json:"station_id,omitempty"`  // ga ra (với fare)
+	TransferID *uint                `json:"transfer_id,omitempty"` // lần chuyển (với transfer)
+	ToCardID   string               `json:"to_card_id,omitempty"`
+	Time       time.Time            `json:"time"`
+}
+
+// OrgStatement là sao kê ví chung của tổ chức trong một tháng
+type OrgStatement struct {
+	OrganizationID uint                       `json:"organization_id"`
+	Month          string                     `json:"month"` // YYYY-MM
+	From           time.Time                  `json:"from"`
+	To             time.Time                  `json:"to"`
+	OpeningBalance float64                    `json:"opening_balance"`
+	Deposits       float64                    `json:"deposits"`
+	Topups         float64                    `json:"topups"`
+	ClosingBalance float64                    `json:"closing_balance"`
+	Fares          float64                    `json:"fares"`         // tiền vé các thẻ đã trả
+	TransfersOut   float64                    `json:"transfers_out"` // số dư các thẻ đã chuyển đi
+	Cards          []OrgStatementCard         `json:"cards"`
+	Transactions   []models.WalletTransaction `json:"transactions"`
+	CardDebits     []OrgCardDebit             `json:"card_debits"`
+}
+
+// orgCardDebits lấy tiền vé và các lần chuyển số dư đi của các thẻ cardIDs trong [from, to)
+func orgCardDebits(cardIDs []string, from, to time.Time) ([]OrgCardDebit, error) {
+	debits := []OrgCardDebit{}
+	if len(cardIDs) == 0 {
+		return debits, nil
+	}
+
+	var fares []models.StationHistory
+	if err := config.DB.Where("card_id IN ? AND action = ? AND used_balance > 0", cardIDs, TapCheckOut).
+		Where("time >= ? AND time < ?", from, to).
+		Find(&fares).Error; err != nil {
+		return nil, err
+	}
+	for _, fare := range fares {
+		stationID := fare.StationID
+		debits = append(debits, OrgCardDebit{
+			CardID:    fare.CardID,
+			Type:      consts.CardDebitFare,
+			Amount:    fare.UsedBalance,
+			StationID: &stationID,
+			Time:      fare.Time,
+		})
+	}
+
+	var transfers []models.BalanceTransfer
+	if err := config.DB.Where("from_card_id IN ? AND status = ?", cardIDs, consts.TransferCompleted).
+		Where("completed_at >= ? AND completed_at < ?", from, to).
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	for _, transfer := range transfers {
+		transferID := transfer.ID
+		debits = append(debits, OrgCardDebit{
+			CardID:     transfer.FromCardID,
+			Type:       consts.CardDebitTransfer,
+			Amount:     transfer.Amount,
+			TransferID: &transferID,
+			ToCardID:   transfer.ToCardID,
+			Time:       *transfer.CompletedAt,
+		})
+	}
+
+	sort.SliceStable(debits, func(i, j int) bool { return debits[i].Time.Before(debits[j].Time) })
+	return debits, nil
+}
+
+// BuildOrgStatement lập sao kê tháng month (YYYY-MM, giờ địa phương) từ sổ cái của ví chung,
+// kèm các khoản chi (tiền vé, chuyển số dư) của thẻ thuộc tổ chức hoặc được ví nạp trong kỳ
+func BuildOrgStatement(orgID uint, month string) (OrgStatement, error) {
+	statement := OrgStatement{OrganizationID: orgID, Month: month, Cards: []OrgStatementCard{}}
+
+	from, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return statement, err
+	}
+	statement.From = from
+	statement.To = from.AddDate(0, 1, 0)
+
+	var previous models.WalletTransaction
+	if err := config.DB.Where("organization_id = ? AND created_at < ?", orgID, statement.From).
+		Order("id DESC").Limit(1).Find(&previous).Error; err != nil {
+		return statement, err
+	}
+	statement.OpeningBalance = previous.BalanceAfter
+	statement.ClosingBalance = previous.BalanceAfter
+
+	statement.Transactions = []models.WalletTransaction{}
+	if err := config.DB.Where("organization_id = ? AND created_at >= ? AND created_at < ?", orgID, statement.From, statement.To).
+		Order("id ASC").Find(&statement.Transactions).Error; err != nil {
+		return statement, err
+	}
+
+	topups := make(map[string]float64)
+	for _, entry := range statement.Transactions {
+		switch entry.Type {
+		case consts.WalletDeposit:
+			statement.Deposits += entry.Amount
+		case consts.WalletTopup:
+			statement.Topups -= entry.Amount
+			if entry.CardID != nil {
+				topups[*entry.CardID] -= entry.Amount
+			}
+		}
+		statement.ClosingBalance = entry.BalanceAfter
+	}
+
+	var links []models.OrganizationCard
+	if err := config.DB.Where("organization_id = ?", orgID).Order("card_id").Find(&links).Error; err != nil {
+		return statement, err
+	}
+	limits := make(map[string]float64, len(links))
+	for _, link := range links {
+		limits[link.CardID] = link.MonthlyFundingLimit
+		if _, ok := topups[link.CardID]; !ok {
+			topups[link.CardID] = 0
+		}
+	}
+
+	holders := make(map[string]*uint)
+	cardIDs := make([]string, 0, len(topups))
+	for cardID := range topups {
+		cardIDs = append(cardIDs, cardID)
+	}
+	sort.Strings(cardIDs)
+	var cards []models.Card
+	if err := config.DB.Unscoped().Where("rf_id IN ?", cardIDs).Find(&cards).Error; err != nil {
+		return statement, err
+	}
+	for _, card := range cards {
+		holders[card.RFID] = card.UserID
+	}
+
+	statement.CardDebits, err = orgCardDebits(cardIDs, statement.From, statement.To)
+	if err != nil {
+		return statement, err
+	}
+	fares := make(map[string]float64)
+	transfersOut := make(map[string]float64)
+	for _, debit := range statement.CardDebits {
+		switch debit.Type {
+		case consts.CardDebitFare:
+			statement.Fares += debit.Amount
+			fares[debit.CardID] += debit.Amount
+		case consts.CardDebitTransfer:
+			statement.TransfersOut += debit.Amount
+			transfersOut[debit.CardID] += debit.Amount
+		}
+	}
+
+	for _, cardID := range cardIDs {
+		statement.Cards = append(statement.Cards, OrgStatementCard{
+			CardID:              cardID,
+			UserID:              holders[cardID],
+			Topups:              topups[cardID],
+			MonthlyFundingLimit: limits[cardID],
+			Fares:               fares[cardID],
+			TransfersOut:        transfersOut[cardID],
+		})
+	}
+	return statement, nil
+}
+
+// CreateOrganization tạo tổ chức với ownerID là chủ tài khoản đầu tiên
+func CreateOrganization(db *gorm.DB, name string, orgType consts.OrgType, ownerID uint) (models.Organization, error) {
+	org := models.Organization{Name: name, Type: orgType}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.OrganizationMember{}).Where("user_id = ?", ownerID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUserInOtherOrg
+		}
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Organization", "User").Create(&models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           consts.OrgOwner,
+		}).Error
+	})
+	return org, err
+}
+
+// ownerCount đếm số chủ tài khoản còn lại của tổ chức
+func ownerCount(tx *gorm.DB, orgID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, consts.OrgOwner).Count(&count).Error
+	return count, err
+}
+
+// SetOrgMember thêm người dùng vào tổ chức, hoặc đổi vai trò nếu đã là thành viên
+func SetOrgMember(db *gorm.DB, orgID, userID uint, role consts.OrgRole) (models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+
+		err := tx.Where("user_id = ?", userID).First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			member = models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role}
+			return tx.Omit("Organization", "User").Create(&member).Error
+		}
+		if err != nil {
+			return err
+		}
+		if member.OrganizationID != orgID {
+			return ErrUserInOtherOrg
+		}
+
+		if member.Role == consts.OrgOwner && role != consts.OrgOwner {
+			owners, err := ownerCount(tx, orgID)
+			if err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return ErrLastOrgOwner
+			}
+		}
+		member.Role = role
+		return tx.Model(&member).Update("role", role).Error
+	})
+	return member, err
+}
+
+// RemoveOrgMember xóa người dùng khỏi tổ chức; thẻ họ đang giữ vẫn thuộc tổ chức
+func RemoveOrgMember(db *gorm.DB, orgID, userID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+
+		var member models.OrganizationMember
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotOrgMember
+			}
+			return err
+		}
+		if member.Role == consts.OrgOwner {
+			owners, err := ownerCount(tx, orgID)
+			if err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return ErrLastOrgOwner
+			}
+		}
+		return tx.Delete(&member).Error
+	})
+}
+
+// AttachOrgCard đưa thẻ vào tổ chức. holderID (nếu có) là thành viên được giao thẻ;
+// thẻ đã có chủ thì chủ thẻ phải là thành viên của tổ chức.
+func AttachOrgCard(db *gorm.DB, orgID uint, rfID string, holderID *uint, fundingLimit float64) (models.OrganizationCard, error) {
+	link := models.OrganizationCard{OrganizationID: orgID, CardID: rfID, MonthlyFundingLimit: fundingLimit}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+
+		var card models.Card
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", rfID).First(&card).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.OrganizationCard{}).Where("card_id = ?", rfID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCardInOtherOrg
+		}
+
+		if holderID == nil {
+			holderID = card.UserID
+		}
+		if holderID != nil {
+			if err := tx.Where("organization_id = ? AND user_id = ?", orgID, *holderID).First(&models.OrganizationMember{}).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrHolderNotInOrg
+				}
+				return err
+			}
+			if card.UserID == nil || *card.UserID != *holderID {
+				if err := tx.Model(&card).Update("user_id", *holderID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Omit("Organization").Create(&link).Error
+	})
+	return link, err
+}
+
+// SetOrgCardFundingLimit đổi hạn mức nạp tiền hằng tháng từ ví chung của thẻ (0: không giới hạn)
+func SetOrgCardFundingLimit(orgID uint, rfID string, fundingLimit float64) (models.OrganizationCard, error) {
+	var link models.OrganizationCard
+	if err := config.DB.Where("organization_id = ? AND card_id = ?", orgID, rfID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return link, ErrCardNotInOrg
+		}
+		return link, err
+	}
+	link.MonthlyFundingLimit = fundingLimit
+	return link, config.DB.Model(&link).Update("monthly_funding_limit", fundingLimit).Error
+}
+
+// DetachOrgCard gỡ thẻ khỏi tổ chức; thẻ vẫn thuộc về người đang giữ
+func DetachOrgCard(db *gorm.DB, orgID uint, rfID string) error {
+	result := db.Where("organization_id = ? AND card_id = ?", orgID, rfID).Delete(&models.OrganizationCard{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCardNotInOrg
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOutboxPollInterval = 5 * time.Second
+
+	outboxBatchSize     = 100
+	outboxMaxRounds     = 20
+	outboxRetryBase     = 5 * time.Second
+	outboxRetryMaxDelay = 10 * time.Minute
+
+	// outboxLockKey là khóa advisory đảm bảo chỉ một instance công bố outbox tại một thời điểm,
+	// nhờ đó sự kiện của cùng một thẻ không bị hai instance gửi song song
+	outboxLockKey = 0x6f757462
+
+	// outboxQueueHeads lấy sự kiện chưa công bố sớm nhất của từng thẻ
+	outboxQueueHeads = "SELECT DISTINCT ON (card_id) id FROM outbox_events WHERE published_at IS NULL ORDER BY card_id, id"
+)
+
+// OutboxPollInterval đọc chu kỳ quét outbox từ OUTBOX_POLL_SECONDS (mặc định 5 giây)
+func OutboxPollInterval() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("OUTBOX_POLL_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultOutboxPollInterval
+}
+
+// OutboxRetention là thời gian giữ sự kiện đã công bố (OUTBOX_RETENTION_DAYS, mặc định 7 ngày)
+func OutboxRetention() time.Duration {
+	return time.Duration(envInt("OUTBOX_RETENTION_DAYS", 7)) * 24 * time.Hour
+}
+
+// outboxRetryDelay là thời gian chờ trước lần gửi lại thứ attempts: 5 giây, gấp đôi mỗi lần, tối đa 10 phút
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxRetryMaxDelay {
+		delay = outboxRetryMaxDelay
+	}
+	return delay
+}
+
+// DomainEvent là sự kiện nghiệp vụ gửi tới sink. Version là phiên bản schema của Data;
+// Sequence tăng dần theo thứ tự ghi và không giảm giữa các sự kiện của cùng một thẻ.
+type DomainEvent struct {
+	ID         string                 `json:"id"`
+	Type       consts.DomainEventType `json:"type"`
+	Version    int                    `json:"version"`
+	Sequence   uint                   `json:"sequence"`
+	CardID     string                 `json:"card_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// newEventID tạo UUID phiên bản 4
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// RecordEvent ghi sự kiện vào outbox trong transaction tx của thay đổi dữ liệu: sự kiện chỉ tồn tại
+// khi thay đổi được commit. Sau khi commit, gọi Outbox.Wake() để công bố ngay.
+func RecordEvent(tx *gorm.DB, eventType consts.DomainEventType, cardID string, data map[string]interface{}) error {
+	eventID, err := newEventID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	event := models.OutboxEvent{
+		EventID:       eventID,
+		Type:          eventType,
+		Version:       eventType.Version(),
+		CardID:        cardID,
+		Data:          data,
+		OccurredAt:    now,
+		NextAttemptAt: now,
+	}
+	return tx.Create(&event).Error
+}
+
+// toDomainEvent dựng envelope gửi tới sink từ bản ghi outbox
+func toDomainEvent(event models.OutboxEvent) DomainEvent {
+	return DomainEvent{
+		ID:         event.EventID,
+		Type:       event.Type,
+		Version:    event.Version,
+		Sequence:   event.ID,
+		CardID:     event.CardID,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Data,
+	}
+}
+
+// publishEvent gửi sự kiện tới mọi sink và ghi kết quả. Sink lỗi làm cả sự kiện được gửi lại sau,
+// kể cả tới các sink đã nhận (giao ít nhất một lần).
+func publishEvent(tx *gorm.DB, event *models.OutboxEvent, sinks []EventSink, now time.Time) error {
+	var sendErr error
+	for _, sink := range sinks {
+		if err := sink.Send(toDomainEvent(*event)); err != nil {
+			sendErr = fmt.Errorf("%s: %w", sink.Name(), err)
+			break
+		}
+	}
+
+	event.Attempts++
+	if sendErr == nil {
+		event.PublishedAt = &now
+		event.LastError = ""
+	} else {
+		event.LastError = sendErr.Error()
+		event.NextAttemptAt = now.Add(outboxRetryDelay(event.Attempts))
+	}
+
+	return tx.Model(event).
+		Select("published_at", "attempts", "next_attempt_at", "last_error").
+		Updates(event).Error
+}
+
+// PublishOutbox công bố các sự kiện chưa công bố. Mỗi vòng chỉ xét sự kiện sớm nhất của từng thẻ,
+// nên sự kiện của một thẻ không bao giờ vượt sự kiện trước đó đang chờ gửi lại, còn thẻ khác không bị chặn.
+// Trả về số sự kiện đã công bố.
+func PublishOutbox() (int, error) {
+	sinks := CurrentEventSinks()
+	published := 0
+	for round := 0; round < outboxMaxRounds; round++ {
+		progressed := false
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var locked bool
+			if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if !locked {
+				return nil
+			}
+
+			now := time.Now()
+			var heads []models.OutboxEvent
+			if err := tx.Where("id IN ("+outboxQueueHeads+")").
+				Where("next_attempt_at <= ?", now).
+				Order("id").Limit(outboxBatchSize).Find(&heads).Error; err != nil {
+				return err
+			}
+
+			for i := range heads {
+				if err := publishEvent(tx, &heads[i], sinks, now); err != nil {
+					return err
+				}
+				if heads[i].PublishedAt != nil {
+					published++
+					progressed = true
+				}
+			}
+			return nil
+		})
+		if err != nil || !progressed {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+// PurgePublishedEvents xóa sự kiện đã công bố lâu hơn OutboxRetention
+func PurgePublishedEvents() (int64, error) {
+	result := config.DB.Where("published_at < ?", time.Now().Add(-OutboxRetention())).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// OutboxDispatcher công bố outbox định kỳ hoặc ngay khi được đánh thức
+type OutboxDispatcher struct {
+	wake chan struct{}
+}
+
+// Outbox là dispatcher dùng chung cho toàn bộ ứng dụng
+var Outbox = &OutboxDispatcher{wake: make(chan struct{}, 1)}
+
+// Wake yêu cầu công bố sự kiện mới ngay; gọi sau khi commit transaction đã ghi sự kiện. Hàm không bao giờ chặn.
+func (d *OutboxDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run công bố outbox và dọn sự kiện cũ mỗi giờ; chạy trong một goroutine riêng
+func (d *OutboxDispatcher) Run() {
+	ticker := time.NewTicker(OutboxPollInterval())
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		if _, err := PublishOutbox(); err != nil {
+			log.Println("⚠️ Failed to publish outbox:", err)
+		}
+
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		case <-purge.C:
+			if _, err := PurgePublishedEvents(); err != nil {
+				log.Println("⚠️ Failed to purge published events:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventSink nhận sự kiện nghiệp vụ từ outbox. Send trả lỗi khi chưa nhận được sự kiện;
+// dispatcher sẽ gửi lại nên sink có thể nhận một sự kiện nhiều lần (bên nhận loại trùng theo id).
+type EventSink interface {
+	Name() string
+	Send(event DomainEvent) error
+}
+
+// WriterSink ghi mỗi sự kiện thành một dòng JSON (JSON Lines), dùng cho stdout hoặc file
+type WriterSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Send(event DomainEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// NewFileSink mở file để ghi thêm sự kiện
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterSink{name: "file:" + path, w: file}, nil
+}
+
+// NewStdoutSink ghi sự kiện ra stdout
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{name: "stdout", w: os.Stdout}
+}
+
+// WebhookSink gửi mỗi sự kiện bằng HTTP POST JSON; mọi mã trạng thái ngoài 2xx được coi là lỗi để gửi lại
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.URL
+}
+
+func (s *WebhookSink) Send(event DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-ID", event.ID)
+	request.Header.Set("X-Event-Type", string(event.Type))
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", response.Status)
+	}
+	return nil
+}
+
+var (
+	eventSinksMu   sync.Mutex
+	eventSinks     []EventSink
+	eventSinksInit bool
+)
+
+// newEventSinks luôn gồm WebhookSubscriptionSink, thêm các sink trong OUTBOX_SINKS: danh sách cách nhau
+// bởi dấu phẩy gồm "stdout", "file:<đường dẫn>" và "webhook:<url>". Sink không hợp lệ bị bỏ qua kèm cảnh báo.
+func newEventSinks() []EventSink {
+	sinks := []EventSink{&WebhookSubscriptionSink{}}
+	for _, spec := range strings.Split(os.Getenv("OUTBOX_SINKS"), ",") {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+		case spec == "stdout":
+			sinks = append(sinks, NewStdoutSink())
+		case strings.HasPrefix(spec, "file:"):
+			sink, err := NewFileSink(strings.TrimPrefix(spec, "file:"))
+			if err != nil {
+				log.Println("⚠️ Failed to open outbox file sink:", err)
+				continue
+			}
+			sinks = append(sinks, sink)
+		case strings.HasPrefix(spec, "webhook:"):
+			sinks = append(sinks, &WebhookSink{
+				URL:    strings.TrimPrefix(spec, "webhook:"),
+				Client: &http.Client{Timeout: 10 * time.Second},
+			})
+		default:
+			log.Printf("⚠️ Unknown outbox sink %q", spec)
+		}
+	}
+	return sinks
+}
+
+// CurrentEventSinks trả về các sink đang dùng, khởi tạo từ biến môi trường ở lần gọi đầu
+func CurrentEventSinks() []EventSink {
+	eventSinksMu.Lock()
+	defer eventSinksMu.Unlock()
+	if !eventSinksInit {
+		eventSinks = newEventSinks()
+		eventSinksInit = true
+	}
+	return append([]EventSink(nil), eventSinks...)
+}
+
+// AddEventSink thêm một sink (ví dụ sink ghi vào bộ nhớ khi test)
+func AddEventSink(sink EventSink) {
+	eventSinksMu.Lock()
+	defer eventSinksMu.Unlock()
+	if !eventSinksInit {
+		eventSinks = newEventSinks()
+		eventSinksInit = true
+	}
+	eventSinks = append(eventSinks, sink)
+}
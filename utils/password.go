@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+	ErrWeakPassword      = errors.New("password does not meet policy")
+)
+
+// PasswordHasher băm và kiểm tra mật khẩu theo một thuật toán.
+// Chuỗi băm tự mô tả thuật toán và tham số để có thể đổi cấu hình mà không làm hỏng mật khẩu cũ.
+type PasswordHasher interface {
+	// ID là tên thuật toán, dùng trong cấu hình PASSWORD_HASHER
+	ID() string
+	// Hash trả về chuỗi băm đã gồm salt và tham số
+	Hash(password string) (string, error)
+	// Supports cho biết chuỗi băm có thuộc thuật toán này hay không
+	Supports(encoded string) bool
+	// Verify so khớp mật khẩu với chuỗi băm
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash cho biết chuỗi băm dùng tham số yếu hơn cấu hình hiện tại
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher lưu theo định dạng PHC: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (h Argon2idHasher) ID() string { return "argon2id" }
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+type argon2Params struct {
+	version     int
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func decodeArgon2id(encoded string) (argon2Params, error) {
+	var params argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, ErrUnknownHashFormat
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &params.version); err != nil {
+		return params, ErrUnknownHashFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, ErrUnknownHashFormat
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, ErrUnknownHashFormat
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, ErrUnknownHashFormat
+	}
+	return params, nil
+}
+
+func (h Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	if params.version != argon2.Version {
+		return false, ErrUnknownHashFormat
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.version != argon2.Version ||
+		params.memory < h.Memory ||
+		params.iterations < h.Iterations ||
+		params.parallelism < h.Parallelism ||
+		uint32(len(params.salt)) < h.SaltLength ||
+		uint32(len(params.key)) < h.KeyLength
+}
+
+// BcryptHasher lưu theo định dạng chuẩn của bcrypt: $2a$<cost>$<salt+hash>
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) ID() string { return "bcrypt" }
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// LegacyMD5Hasher chỉ dùng để kiểm tra mật khẩu cũ (MD5 không salt, 32 ký tự hex).
+// Mật khẩu cũ luôn cần băm lại ngay sau lần đăng nhập thành công.
+type LegacyMD5Hasher struct{}
+
+func (h LegacyMD5Hasher) ID() string { return "md5" }
+
+func (h LegacyMD5Hasher) Hash(password string) (string, error) {
+	return "", fmt.Errorf("md5 is only supported for verifying legacy passwords")
+}
+
+func (h LegacyMD5Hasher) Supports(encoded string) bool {
+	if len(encoded) != md5.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+func (h LegacyMD5Hasher) Verify(password, encoded string) (bool, error) {
+	hash := md5.Sum([]byte(password))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hash[:])), []byte(strings.ToLower(encoded))) == 1, nil
+}
+
+func (h LegacyMD5Hasher) NeedsRehash(encoded string) bool { return true }
+
+// envInt đọc biến môi trường kiểu số nguyên dương, trả về giá trị mặc định nếu không hợp lệ
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// newPasswordHasher tạo hasher theo cấu hình PASSWORD_HASHER (argon2id hoặc bcrypt, mặc định argon2id)
+func newPasswordHasher() PasswordHasher {
+	if strings.EqualFold(os.Getenv("PASSWORD_HASHER"), "bcrypt") {
+		return BcryptHasher{Cost: envInt("BCRYPT_COST", 12)}
+	}
+	return Argon2idHasher{
+		Memory:      uint32(envInt("ARGON2_MEMORY_KIB", 64*1024)),
+		Iterations:  uint32(envInt("ARGON2_ITERATIONS", 3)),
+		Parallelism: uint8(envInt("ARGON2_PARALLELISM", 2)),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+var (
+	passwordsOnce sync.Once
+	passwords     PasswordHasher
+)
+
+// CurrentPasswordHasher trả về hasher dùng cho mật khẩu mới; các hasher còn lại chỉ để kiểm tra mật khẩu cũ.
+// Cấu hình được đọc ở lần gọi đầu tiên, sau khi .env đã được nạp.
+func CurrentPasswordHasher() PasswordHasher {
+	passwordsOnce.Do(func() {
+		passwords = newPasswordHasher()
+	})
+	return passwords
+}
+
+var passwordVerifiers = []PasswordHasher{
+	Argon2idHasher{},
+	BcryptHasher{},
+	LegacyMD5Hasher{},
+}
+
+// HashPassword băm mật khẩu bằng hasher hiện hành
+func HashPassword(password string) (string, error) {
+	return CurrentPasswordHasher().Hash(password)
+}
+
+// CheckPassword so khớp mật khẩu với chuỗi băm đã lưu ở bất kỳ định dạng nào được hỗ trợ.
+// needsRehash = true khi mật khẩu đúng nhưng chuỗi băm nên được thay bằng HashPassword.
+func CheckPassword(password, encoded string) (ok bool, needsRehash bool, err error) {
+	hasher := CurrentPasswordHasher()
+	if hasher.Supports(encoded) {
+		ok, err = hasher.Verify(password, encoded)
+		return ok, ok && hasher.NeedsRehash(encoded), err
+	}
+
+	for _, verifier := range passwordVerifiers {
+		if verifier.Supports(encoded) {
+			ok, err = verifier.Verify(password, encoded)
+			return ok, ok, err
+		}
+	}
+	return false, false, ErrUnknownHashFormat
+}
+
+// PasswordPolicy là quy định độ mạnh mật khẩu khi đăng ký và đổi mật khẩu
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// LoadPasswordPolicy đọc quy định mật khẩu từ biến môi trường
+func LoadPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     envInt("PASSWORD_MAX_LENGTH", 72),
+		RequireUpper:  os.Getenv("PASSWORD_REQUIRE_UPPER") == "true",
+		RequireLower:  os.Getenv("PASSWORD_REQUIRE_LOWER") == "true",
+		RequireDigit:  os.Getenv("PASSWORD_REQUIRE_DIGIT") != "false",
+		RequireSymbol: os.Getenv("PASSWORD_REQUIRE_SYMBOL") == "true",
+	}
+}
+
+// Validate kiểm tra mật khẩu; lỗi trả về bọc ErrWeakPassword và liệt kê các điều kiện chưa đạt
+func (p PasswordPolicy) Validate(password string) error {
+	var upper, lower, digit, symbol bool
+	length := 0
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("at most %d characters", p.MaxLength))
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "a symbol")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: password must contain %s", ErrWeakPassword, strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// permissionCacheTTL là thời gian giữ bảng phân quyền trong bộ nhớ trước khi đọc lại từ DB
+const permissionCacheTTL = time.Minute
+
+// permissionCache giữ bảng role → quyền để middleware không phải truy vấn DB mỗi request
+type permissionCache struct {
+	mu       sync.RWMutex
+	roles    map[consts.Role]map[consts.Permission]bool
+	loadedAt time.Time
+}
+
+var permissions = &permissionCache{}
+
+func (p *permissionCache) get() (map[consts.Role]map[consts.Permission]bool, error) {
+	p.mu.RLock()
+	if p.roles != nil && time.Since(p.loadedAt) < permissionCacheTTL {
+		roles := p.roles
+		p.mu.RUnlock()
+		return roles, nil
+	}
+	p.mu.RUnlock()
+
+	var rows []models.RolePermission
+	if err := config.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	roles := make(map[consts.Role]map[consts.Permission]bool)
+	for _, row := range rows {
+		if roles[row.Role] == nil {
+			roles[row.Role] = make(map[consts.Permission]bool)
+		}
+		roles[row.Role][row.Permission] = true
+	}
+
+	p.mu.Lock()
+	p.roles = roles
+	p.loadedAt = time.Now()
+	p.mu.Unlock()
+	return roles, nil
+}
+
+func (p *permissionCache) invalidate() {
+	p.mu.Lock()
+	p.roles = nil
+	p.mu.Unlock()
+}
+
+// InvalidatePermissions buộc lần kiểm tra quyền kế tiếp đọc lại bảng role_permissions
+func InvalidatePermissions() {
+	permissions.invalidate()
+}
+
+// HasPermission cho biết role có được cấp quyền hay không
+func HasPermission(role consts.Role, permission consts.Permission) (bool, error) {
+	// Admin luôn giữ quyền phân quyền để không tự khóa mình khỏi hệ thống
+	if role == consts.AdminRole && permission == consts.PermissionRoleManage {
+		return true, nil
+	}
+
+	roles, err := permissions.get()
+	if err != nil {
+		return false, err
+	}
+	return roles[role][permission], nil
+}
+
+// GetRolePermissions trả về danh sách quyền của role, sắp xếp theo tên
+func GetRolePermissions(role consts.Role) ([]consts.Permission, error) {
+	roles, err := permissions.get()
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make([]consts.Permission, 0, len(roles[role]))
+	for permission := range roles[role] {
+		granted = append(granted, permission)
+	}
+	sort.Slice(granted, func(i, j int) bool { return granted[i] < granted[j] })
+	return granted, nil
+}
+
+// SetRolePermissions thay toàn bộ quyền của role và trả về danh sách quyền mới, sắp xếp theo tên.
+// Người gọi gọi InvalidatePermissions sau khi commit.
+func SetRolePermissions(db *gorm.DB, role consts.Role, granted []consts.Permission) ([]consts.Permission, error) {
+	permissionList := make([]consts.Permission, 0, len(granted))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.RolePermission, 0, len(granted))
+		seen := make(map[consts.Permission]bool)
+		for _, permission := range granted {
+			if seen[permission] {
+				continue
+			}
+			seen[permission] = true
+			rows = append(rows, models.RolePermission{Role: role, Permission: permission})
+			permissionList = append(permissionList, permission)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+
+	sort.Slice(permissionList, func(i, j int) bool { return permissionList[i] < permissionList[j] })
+	return permissionList, err
+}
+
+// RequirePermission middleware kiểm tra role trong JWT có đủ tất cả các quyền yêu cầu.
+// Phải đặt sau AuthMiddleware.
+func RequirePermission(required ...consts.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("role")
+		if !exists {
+			c.JSON(401, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		role, _ := value.(int)
+
+		// Tài khoản chưa xác minh email chưa được dùng quyền nào
+		if verified, _ := c.Get("email_verified"); verified != true {
+			c.JSON(403, gin.H{"error": "Email verification required"})
+			c.Abort()
+			return
+		}
+
+		// Role bắt buộc 2FA (mặc định admin) chỉ được dùng quyền sau khi đã bật 2FA
+		if missing, _ := c.Get("two_factor_missing"); missing == true {
+			c.JSON(403, gin.H{"error": "Two-factor authentication required"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range required {
+			ok, err := HasPermission(consts.Role(role), permission)
+			if err != nil {
+				log.Println("⚠️ Failed to load role permissions:", err)
+				c.JSON(500, gin.H{"error": "Failed to check permissions"})
+				c.Abort()
+				return
+			}
+			if !ok {
+				c.JSON(403, gin.H{"error": "Permission required: " + string(permission)})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-metro/config"
+	"go-metro/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultRefreshTokenTTL là thời hạn mặc định của refresh token
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrUserInactive        = errors.New("user is not active")
+)
+
+// TokenPair là cặp access token + refresh token trả về cho client
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"` // giây
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// ClientInfo là thông tin thiết bị gắn với một phiên đăng nhập
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// RefreshTokenTTL đọc thời hạn refresh token từ REFRESH_TOKEN_TTL_HOURS (mặc định 30 ngày)
+func RefreshTokenTTL() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultRefreshTokenTTL
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokenPair tạo refresh token mới trong familyID và access token đi kèm
+func issueTokenPair(tx *gorm.DB, user models.User, familyID string, client ClientInfo) (TokenPair, models.RefreshToken, error) {
+	var pair TokenPair
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return pair, models.RefreshToken{}, err
+	}
+
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+	}
+	if err := tx.Omit("User").Create(&record).Error; err != nil {
+		return pair, record, err
+	}
+
+	access, err := GenerateToken(user, familyID)
+	if err != nil {
+		return pair, record, err
+	}
+
+	pair = TokenPair{
+		AccessToken:      access,
+		RefreshToken:     raw,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(AccessTokenTTL() / time.Second),
+		RefreshExpiresAt: record.ExpiresAt,
+	}
+	return pair, record, nil
+}
+
+// IssueTokenPair mở phiên đăng nhập mới (một family refresh token mới) cho user
+func IssueTokenPair(user models.User, client ClientInfo) (TokenPair, error) {
+	familyID, err := randomToken(24)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, _, err := issueTokenPair(config.DB, user, familyID, client)
+	return pair, err
+}
+
+// revokeFamily thu hồi mọi refresh token còn hiệu lực của một phiên
+func revokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RotateRefreshToken đổi refresh token lấy cặp token mới; token cũ chỉ dùng được một lần.
+// Nếu token đã dùng hoặc đã thu hồi được gửi lại, toàn bộ phiên (family) bị thu hồi.
+func RotateRefreshToken(raw string, client ClientInfo) (TokenPair, error) {
+	var pair TokenPair
+	revoke := "" // phiên cần thu hồi sau khi transaction rollback
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(raw)).
+			First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.UsedAt != nil || current.RevokedAt != nil {
+			revoke = current.FamilyID
+			return ErrRefreshTokenReused
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		var user models.User
+		if err := tx.First(&user, current.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Tài khoản đã bị xóa
+				revoke = current.FamilyID
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if user.Status != "active" {
+			revoke = current.FamilyID
+			return ErrUserInactive
+		}
+
+		next, record, err := issueTokenPair(tx, user, current.FamilyID, client)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"used_at":     now,
+			"replaced_by": record.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		pair = next
+		return nil
+	})
+
+	// Thu hồi cả phiên (token bị dùng lại, tài khoản bị khóa hoặc đã xóa) ngoài transaction đã rollback ở trên
+	if err != nil && revoke != "" {
+		if revokeErr := revokeFamily(config.DB, revoke); revokeErr != nil {
+			log.Println("⚠️ Failed to revoke refresh token family:", revokeErr)
+		}
+	}
+
+	return pair, err
+}
+
+// RevokeRefreshToken đăng xuất phiên chứa refresh token
+func RevokeRefreshToken(raw string) error {
+	var current models.RefreshToken
+	if err := config.DB.Where("token_hash = ?", hashToken(raw)).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+	return revokeFamily(config.DB, current.FamilyID)
+}
+
+// RevokeUserSessions thu hồi mọi phiên đăng nhập của user (đăng xuất khỏi mọi thiết bị)
+func RevokeUserSessions(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxGenerateDays giới hạn số ngày sinh chuyến trong một lần gọi
+const MaxGenerateDays = 31
+
+// GenerateTrips sinh các chuyến cụ thể từ các mẫu vận hành trong khoảng [from, to].
+// Chuyến đã tồn tại (cùng mẫu, cùng giờ xuất phát) được bỏ qua nên có thể gọi lại nhiều lần.
+func GenerateTrips(db *gorm.DB, from time.Time, to time.Time) (int, error) {
+	var patterns []models.ServicePattern
+	if err := db.
+		Preload("Stops", func(db *gorm.DB) *gorm.DB { return db.Order("stop_sequence ASC") }).
+		Preload("Exceptions").
+		Find(&patterns).Error; err != nil {
+		return 0, err
+	}
+
+	created := 0
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for day := start; !day.After(to); day = day.AddDate(0, 0, 1) {
+		serviceDate := day
+		for _, pattern := range patterns {
+			if len(pattern.Stops) == 0 || !pattern.RunsOn(day) {
+				continue
+			}
+
+			departures, err := pattern.DepartureTimes(day)
+			if err != nil {
+				return created, err
+			}
+
+			patternID := pattern.ID
+			for _, departure := range departures {
+				trip := models.Trip{
+					StartTime:   departure,
+					EndTime:     departure.Add(pattern.Duration()),
+					Direction:   pattern.Direction.ToText(),
+					TrainID:     pattern.TrainID,
+					PatternID:   &patternID,
+					ServiceDate: &serviceDate,
+				}
+
+				result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&trip)
+				if result.Error != nil {
+					return created, result.Error
+				}
+				created += int(result.RowsAffected)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// GetNextDepartures trả về các lượt tàu sắp rời ga stationID kể từ thời điểm from.
+// Giờ rời dự kiến đã cộng độ trễ; chuyến bị hủy, đã hoàn thành hoặc đã qua ga thì bị loại.
+func GetNextDepartures(stationID uint, from time.Time, limit int) ([]models.Departure, error) {
+	var departures []models.Departure
+
+	expected := "trips.start_time + (pattern_stops.departure_offset + COALESCE(trip_progresses.delay_seconds, 0)) * INTERVAL '1 second'"
+	err := config.DB.Table("trips").
+		Select(`trips.id AS trip_id, pattern_stops.station_id, lines.code AS line_code, lines.name AS line_name,
+			trips.direction, trains.name AS train_name, pattern_stops.stop_sequence,
+			trips.start_time + pattern_stops.arrival_offset * INTERVAL '1 second' AS arrival_time,
+			trips.start_time + pattern_stops.departure_offset * INTERVAL '1 second' AS departure_time,
+			COALESCE(trip_progresses.status, 'scheduled') AS status,
+			COALESCE(trip_progresses.delay_seconds, 0) AS delay_seconds,
+			COALESCE(trip_progresses.delay_seconds, 0) > ? AS late,
+			`+expected+` AS expected_departure_time`, int(TripLateThreshold()/time.Second)).
+		Joins("JOIN pattern_stops ON pattern_stops.pattern_id = trips.pattern_id").
+		Joins("JOIN service_patterns ON service_patterns.id = trips.pattern_id").
+		Joins("JOIN lines ON lines.id = service_patterns.line_id").
+		Joins("LEFT JOIN trains ON trains.id = trips.train_id").
+		Joins("LEFT JOIN trip_progresses ON trip_progresses.trip_id = trips.id").
+		Where("pattern_stops.station_id = ?", stationID).
+		Where(expected+" >= ?", from).
+		Where("COALESCE(trip_progresses.status, 'scheduled') NOT IN ?", []string{string(consts.TripCancelled), string(consts.TripCompleted)}).
+		Where("trip_progresses.id IS NULL OR trip_progresses.status = ? OR trip_progresses.current_stop_sequence < pattern_stops.stop_sequence OR (trip_progresses.status = ? AND trip_progresses.current_stop_sequence = pattern_stops.stop_sequence)",
+			consts.TripScheduled, consts.TripAtStation).
+		Order("expected_departure_time ASC").
+		Limit(limit).
+		Scan(&departures).Error
+
+	return departures, err
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SoftDeleteRetention đọc thời gian giữ bản ghi đã xóa mềm từ SOFT_DELETE_RETENTION_DAYS (mặc định 90 ngày)
+func SoftDeleteRetention() time.Duration {
+	return time.Duration(envInt("SOFT_DELETE_RETENTION_DAYS", 90)) * 24 * time.Hour
+}
+
+// softDeletePurge mô tả một bảng có xóa mềm. Bản ghi còn được lịch sử tham chiếu (điều kiện referenced)
+// được giữ lại vĩnh viễn để báo cáo cũ vẫn hiển thị được. Dữ liệu chỉ thuộc về bản ghi (owned, mỗi câu
+// nhận id) được xóa cùng transaction trước khi xóa bản ghi.
+type softDeletePurge struct {
+	table      string
+	model      interface{}
+	referenced string
+	owned      []string
+}
+
+var softDeletePurges = []softDeletePurge{
+	{
+		table: "users",
+		model: &models.User{},
+		referenced: "EXISTS (SELECT 1 FROM cards WHERE cards.user_id = users.id)" +
+			" OR EXISTS (SELECT 1 FROM sell_histories WHERE sell_histories.seller_id = users.id)" +
+			" OR EXISTS (SELECT 1 FROM histories WHERE histories.user_id = CAST(users.id AS TEXT))" +
+			" OR EXISTS (SELECT 1 FROM organization_members WHERE organization_members.user_id = users.id)" +
+			" OR EXISTS (SELECT 1 FROM wallet_transactions WHERE wallet_transactions.actor_id = users.id)" +
+			" OR EXISTS (SELECT 1 FROM balance_transfers WHERE users.id IN (balance_transfers.requested_by, balance_transfers.approved_by, balance_transfers.override_by))" +
+			" OR EXISTS (SELECT 1 FROM security_events WHERE users.id IN (security_events.user_id, security_events.actor_id))" +
+			" OR EXISTS (SELECT 1 FROM audit_logs WHERE audit_logs.actor_id = users.id)" +
+			" OR EXISTS (SELECT 1 FROM service_alerts WHERE users.id IN (service_alerts.created_by, service_alerts.resolved_by))" +
+			" OR EXISTS (SELECT 1 FROM trip_progresses WHERE trip_progresses.reported_by = users.id)" +
+			" OR EXISTS (SELECT 1 FROM webhook_subscriptions WHERE webhook_subscriptions.created_by = users.id)",
+		owned: []string{
+			"DELETE FROM refresh_tokens WHERE user_id = ?",
+			"DELETE FROM user_tokens WHERE user_id = ?",
+			"DELETE FROM user_totps WHERE user_id = ?",
+			"DELETE FROM recovery_codes WHERE user_id = ?",
+			"DELETE FROM notifications WHERE user_id = ?",
+			"DELETE FROM notification_preferences WHERE user_id = ?",
+			"DELETE FROM user_stations WHERE user_id = ?",
+		},
+	},
+	{
+		table: "cards",
+		model: &models.Card{},
+		referenced: "EXISTS (SELECT 1 FROM sell_histories WHERE sell_histories.card_id = cards.rf_id)" +
+			" OR EXISTS (SELECT 1 FROM station_histories WHERE station_histories.card_id = cards.rf_id)" +
+			" OR EXISTS (SELECT 1 FROM histories WHERE histories.card_id = cards.rf_id)" +
+			" OR EXISTS (SELECT 1 FROM device_taps WHERE device_taps.card_id = cards.rf_id)",
+	},
+	{
+		table: "stations",
+		model: &models.Station{},
+		referenced: "EXISTS (SELECT 1 FROM station_histories WHERE station_histories.station_id = stations.id)" +
+			" OR EXISTS (SELECT 1 FROM sell_histories WHERE sell_histories.station_id = stations.id)" +
+			" OR EXISTS (SELECT 1 FROM devices WHERE devices.station_id = stations.id)" +
+			" OR EXISTS (SELECT 1 FROM pattern_stops WHERE pattern_stops.station_id = stations.id)",
+	},
+	{
+		table: "trains",
+		model: &models.Train{},
+		referenced: "EXISTS (SELECT 1 FROM trips WHERE trips.train_id = trains.id)" +
+			" OR EXISTS (SELECT 1 FROM service_patterns WHERE service_patterns.train_id = trains.id)",
+	},
+}
+
+// PurgeSoftDeleted xóa hẳn các bản ghi đã xóa mềm quá thời gian lưu giữ và không còn được lịch sử tham chiếu.
+// Trả về số bản ghi đã xóa theo bảng.
+func PurgeSoftDeleted() (map[string]int, error) {
+	cutoff := time.Now().Add(-SoftDeleteRetention())
+	purged := make(map[string]int)
+
+	for _, purge := range softDeletePurges {
+		var ids []uint
+		if err := config.DB.Unscoped().Model(purge.model).
+			Where("deleted_at < ?", cutoff).
+			Where("NOT ("+purge.referenced+")").
+			Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+
+		// Xóa từng bản ghi để một ràng buộc khóa ngoại không chặn cả lượt dọn
+		for _, id := range ids {
+			if err := config.DB.Transaction(func(tx *gorm.DB) error {
+				for _, statement := range purge.owned {
+					if err := tx.Exec(statement, id).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Unscoped().Delete(purge.model, id).Error
+			}); err != nil {
+				log.Printf("⚠️ Failed to purge %s %d: %v", purge.table, id, err)
+				continue
+			}
+			purged[purge.table]++
+		}
+	}
+	return purged, nil
+}
+
+// RunSoftDeletePurge dọn bản ghi đã xóa mềm mỗi giờ; chạy trong một goroutine riêng
+func RunSoftDeletePurge() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := PurgeSoftDeleted()
+		if err != nil {
+			log.Println("⚠️ Failed to purge soft-deleted records:", err)
+		}
+		for table, count := range purged {
+			log.Printf("✅ Purged %d soft-deleted %s", count, table)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"go-metro/config"
+	"go-metro/consts"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StationScope là phạm vi trạm người dùng hiện tại được thao tác.
+// Admin có phạm vi toàn mạng lưới; nhân viên chỉ thấy các trạm được phân công.
+type StationScope struct {
+	All        bool
+	StationIDs []uint
+}
+
+// GetStationScope đọc phạm vi trạm của người dùng trong JWT (phải đặt sau AuthMiddleware)
+func GetStationScope(c *gin.Context) (StationScope, error) {
+	if cached, ok := c.Get("station_scope"); ok {
+		return cached.(StationScope), nil
+	}
+
+	role, _ := c.Get("role")
+	if role, _ := role.(int); consts.Role(role) == consts.AdminRole {
+		scope := StationScope{All: true}
+		c.Set("station_scope", scope)
+		return scope, nil
+	}
+
+	userID, _ := c.Get("user_id")
+	scope := StationScope{StationIDs: []uint{}}
+	if err := config.DB.Table("user_stations").
+		Where("user_id = ?", userID).
+		Pluck("station_id", &scope.StationIDs).Error; err != nil {
+		return scope, err
+	}
+
+	c.Set("station_scope", scope)
+	return scope, nil
+}
+
+// Allows cho biết trạm có nằm trong phạm vi hay không
+func (s StationScope) Allows(stationID uint) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.StationIDs {
+		if id == stationID {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter giới hạn truy vấn vào các bản ghi có cột trạm nằm trong phạm vi
+func (s StationScope) Filter(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if s.All {
+			return db
+		}
+		if len(s.StationIDs) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", s.StationIDs)
+	}
+}
@@ -0,0 +1,292 @@
+package utils
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MinCheckInBalance là số dư tối thiểu để vào ga
+	MinCheckInBalance = 5000.0
+	// FlatFare là giá vé cố định trừ khi ra ga
+	FlatFare = 5000.0
+
+	TapCheckIn  = "checkin"
+	TapCheckOut = "checkout"
+)
+
+var (
+	ErrCardNotFound        = errors.New("card not found")
+	ErrCardBlocked         = errors.New("card is blocked")
+	ErrCardExpired         = errors.New("card is expired")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidTapAction    = errors.New("action must be checkin or checkout")
+)
+
+// TapPolicy quyết định cách xử lý lượt quẹt không hợp lệ.
+// Quẹt trực tuyến bị từ chối ngay; quẹt ngoại tuyến đã cho khách qua cổng nên có thể được chấp nhận.
+type TapPolicy struct {
+	AllowNegativeBalance bool // ghi nợ khi số dư không đủ
+	AcceptBlockedCards   bool // vẫn ghi nhận và trừ tiền thẻ bị khóa hoặc hết hạn
+}
+
+// OnlineTapPolicy áp dụng cho lượt quẹt gửi trực tiếp từ cổng
+var OnlineTapPolicy = TapPolicy{}
+
+// OfflineTapPolicy đọc chính sách cho lượt quẹt ngoại tuyến:
+// OFFLINE_BALANCE_POLICY=allow_negative|reject (mặc định allow_negative),
+// OFFLINE_BLOCKED_CARD_POLICY=reject|accept (mặc định reject)
+func OfflineTapPolicy() TapPolicy {
+	return TapPolicy{
+		AllowNegativeBalance: os.Getenv("OFFLINE_BALANCE_POLICY") != "reject",
+		AcceptBlockedCards:   os.Getenv("OFFLINE_BLOCKED_CARD_POLICY") == "accept",
+	}
+}
+
+// Tap là một lượt quẹt thẻ tại cổng
+type Tap struct {
+	Action    string
+	CardID    string
+	StationID uint
+	DeviceID  *uint
+	Time      time.Time
+	Offline   bool
+}
+
+// TapOutcome là kết quả ghi nhận một lượt quẹt
+type TapOutcome struct {
+	History    models.StationHistory
+	Card       models.Card
+	OldBalance float64
+	Fare       float64
+	Debt       bool // số dư âm sau lượt quẹt
+}
+
+// ProcessTap ghi nhận lượt quẹt trong transaction tx: khóa thẻ, kiểm tra theo policy,
+// trừ tiền khi ra ga và tạo StationHistory
+func ProcessTap(tx *gorm.DB, tap Tap, policy TapPolicy) (TapOutcome, error) {
+	var outcome TapOutcome
+
+	if tap.Action != TapCheckIn && tap.Action != TapCheckOut {
+		return outcome, ErrInvalidTapAction
+	}
+	if tap.Time.IsZero() {
+		tap.Time = time.Now()
+	}
+
+	var card models.Card
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("rf_id = ?", tap.CardID).
+		First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return outcome, ErrCardNotFound
+		}
+		return outcome, err
+	}
+	outcome.OldBalance = card.Balance
+
+	if card.Status.IsHotlisted() && !policy.AcceptBlockedCards {
+		if card.Status == consts.ExpiredStatus {
+			return outcome, ErrCardExpired
+		}
+		return outcome, ErrCardBlocked
+	}
+
+	switch tap.Action {
+	case TapCheckIn:
+		if card.Balance < MinCheckInBalance && !policy.AllowNegativeBalance {
+			return outcome, ErrInsufficientBalance
+		}
+	case TapCheckOut:
+		outcome.Fare = FlatFare
+		if card.Balance < outcome.Fare && !policy.AllowNegativeBalance {
+			return outcome, ErrInsufficientBalance
+		}
+		card.Balance -= outcome.Fare
+		if err := tx.Model(&card).Update("balance", card.Balance).Error; err != nil {
+			return outcome, err
+		}
+	}
+
+	outcome.History = models.StationHistory{
+		Action:      tap.Action,
+		Time:        tap.Time,
+		CardID:      tap.CardID,
+		StationID:   tap.StationID,
+		DeviceID:    tap.DeviceID,
+		UsedBalance: outcome.Fare,
+		Offline:     tap.Offline,
+	}
+	if err := tx.Omit("Card", "Station", "Device").Create(&outcome.History).Error; err != nil {
+		return outcome, err
+	}
+
+	eventType := consts.DomainCardCheckedIn
+	if tap.Action == TapCheckOut {
+		eventType = consts.DomainCardCheckedOut
+	}
+	if err := RecordEvent(tx, eventType, card.RFID, map[string]interface{}{
+		"station_history_id": outcome.History.ID,
+		"station_id":         tap.StationID,
+		"device_id":          tap.DeviceID,
+		"tapped_at":          tap.Time,
+		"offline":            tap.Offline,
+		"fare":               outcome.Fare,
+		"balance":            card.Balance,
+	}); err != nil {
+		return outcome, err
+	}
+	if tap.Action == TapCheckOut {
+		if err := recordJourney(tx, outcome.History); err != nil {
+			return outcome, err
+		}
+	}
+
+	// Cảnh báo chủ thẻ trước khi số dư không đủ để vào ga
+	NotifyLowBalance(tx, card)
+
+	outcome.Card = card
+	outcome.Debt = card.Balance < 0
+	return outcome, nil
+}
+
+// recordJourney ghi sự kiện journey.completed cho lượt ra ga exit, ghép với lượt quẹt liền trước của thẻ
+// nếu đó là lượt vào ga; không ghép được thì thông tin ga vào để trống
+func recordJourney(tx *gorm.DB, exit models.StationHistory) error {
+	data := map[string]interface{}{
+		"station_history_id": exit.ID,
+		"entry_station_id":   nil,
+		"started_at":         nil,
+		"exit_station_id":    exit.StationID,
+		"ended_at":           exit.Time,
+		"fare":               exit.UsedBalance,
+	}
+
+	var previous models.StationHistory
+	err := tx.Where("card_id = ? AND id <> ? AND time <= ?", exit.CardID, exit.ID, exit.Time).
+		Order("time DESC, id DESC").
+		First(&previous).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && previous.Action == TapCheckIn {
+		data["entry_station_id"] = previous.StationID
+		data["started_at"] = previous.Time
+	}
+
+	return RecordEvent(tx, consts.DomainJourneyCompleted, exit.CardID, data)
+}
+
+// RecordTap ghi nhận một lượt quẹt trực tuyến trong transaction riêng
+func RecordTap(tap Tap) (TapOutcome, error) {
+	var outcome TapOutcome
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		outcome, err = ProcessTap(tx, tap, OnlineTapPolicy)
+		return err
+	})
+	if err == nil {
+		Notifications.Wake()
+		Outbox.Wake()
+	}
+	return outcome, err
+}
+
+// ErrTapInFuture báo thời gian quẹt của thiết bị vượt quá thời gian máy chủ
+var ErrTapInFuture = errors.New("tap timestamp is in the future")
+
+// OfflineTap là một lượt quẹt cổng đã lưu khi mất kết nối
+type OfflineTap struct {
+	Sequence uint64
+	Action   string
+	CardID   string
+	TappedAt time.Time
+}
+
+// isTapRejection cho biết lỗi là do lượt quẹt không hợp lệ (ghi nhận là rejected) hay lỗi hệ thống
+func isTapRejection(err error) bool {
+	return errors.Is(err, ErrCardNotFound) ||
+		errors.Is(err, ErrCardBlocked) ||
+		errors.Is(err, ErrCardExpired) ||
+		errors.Is(err, ErrInsufficientBalance) ||
+		errors.Is(err, ErrInvalidTapAction) ||
+		errors.Is(err, ErrTapInFuture)
+}
+
+// ReplayOfflineTap ghi nhận một lượt quẹt ngoại tuyến của thiết bị theo policy.
+// duplicate = true khi số thứ tự đã được xử lý trước đó; khi đó trả về kết quả đã lưu.
+// Lỗi trả về là lỗi hệ thống: lượt quẹt không được lưu và thiết bị có thể gửi lại.
+func ReplayOfflineTap(device models.Device, offline OfflineTap, policy TapPolicy) (record models.DeviceTap, duplicate bool, err error) {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		record = models.DeviceTap{
+			DeviceID: device.ID,
+			Sequence: offline.Sequence,
+			Action:   offline.Action,
+			CardID:   offline.CardID,
+			TappedAt: offline.TappedAt,
+			Result:   models.TapRejected,
+		}
+
+		// Giữ chỗ số thứ tự trước; request đồng thời cùng số thứ tự sẽ chờ rồi bỏ qua
+		result := tx.Omit("Device", "StationHistory").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return tx.Where("device_id = ? AND sequence = ?", device.ID, offline.Sequence).
+				First(&record).Error
+		}
+
+		var outcome TapOutcome
+		var tapErr error
+		if offline.TappedAt.After(time.Now().Add(DeviceSignatureSkew())) {
+			tapErr = ErrTapInFuture
+		} else {
+			outcome, tapErr = ProcessTap(tx, Tap{
+				Action:    offline.Action,
+				CardID:    offline.CardID,
+				StationID: device.StationID,
+				DeviceID:  &device.ID,
+				Time:      offline.TappedAt,
+				Offline:   true,
+			}, policy)
+		}
+
+		switch {
+		case tapErr == nil:
+			record.Result = models.TapAccepted
+			if outcome.Debt {
+				record.Result = models.TapAcceptedWithDebt
+			}
+			record.StationHistoryID = &outcome.History.ID
+			record.Balance = outcome.Card.Balance
+		case isTapRejection(tapErr):
+			record.Reason = tapErr.Error()
+			record.Balance = outcome.OldBalance
+		default:
+			return tapErr
+		}
+
+		return tx.Model(&record).Updates(map[string]interface{}{
+			"result":             record.Result,
+			"reason":             record.Reason,
+			"station_history_id": record.StationHistoryID,
+			"balance":            record.Balance,
+		}).Error
+	})
+	if err == nil && record.StationHistoryID != nil {
+		Notifications.Wake()
+		Outbox.Wake()
+	}
+	return record, duplicate, err
+}
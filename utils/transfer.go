@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransferSameCard      = errors.New("source and destination cards must be different")
+	ErrTransferNeedsOverride = errors.New("source card is blocked or expired, a staff override is required")
+	ErrTransferTargetClosed  = errors.New("destination card is blocked or expired")
+	ErrTransferDailyLimit    = errors.New("daily transfer limit of the source card exceeded")
+	ErrTransferNotPending    = errors.New("transfer is no longer pending")
+	ErrTransferOwnerMismatch = errors.New("cards must belong to the same registered owner unless overriding a blocked or expired source card")
+)
+
+// TransferDailyLimit là tổng số tiền tối đa được chuyển đi từ một thẻ trong một ngày
+// (CARD_TRANSFER_DAILY_LIMIT, mặc định 500000; 0: không giới hạn)
+func TransferDailyLimit() float64 {
+	// envInt bỏ qua giá trị 0 nên đọc trực tiếp để có thể tắt giới hạn
+	if limit, err := strconv.ParseFloat(os.Getenv("CARD_TRANSFER_DAILY_LIMIT"), 64); err == nil && limit >= 0 {
+		return limit
+	}
+	return 500000
+}
+
+// dayStart là đầu ngày (theo giờ địa phương) chứa thời điểm at
+func dayStart(at time.Time) time.Time {
+	at = at.In(time.Local)
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// CardTransferredToday là tổng số tiền đã chuyển đi từ thẻ trong ngày chứa thời điểm at
+func CardTransferredToday(db *gorm.DB, rfID string, at time.Time) (float64, error) {
+	start := dayStart(at)
+	var sent float64
+	err := db.Model(&models.BalanceTransfer{}).
+		Where("from_card_id = ? AND status = ?", rfID, consts.TransferCompleted).
+		Where("completed_at >= ? AND completed_at < ?", start, start.AddDate(0, 0, 1)).
+		Select("COALESCE(SUM(amount), 0)").Scan(&sent).Error
+	return sent, err
+}
+
+// lockCardPair khóa hai thẻ theo thứ tự rf_id để hai lần chuyển ngược chiều đồng thời không bị deadlock
+func lockCardPair(tx *gorm.DB, fromID, toID string) (from, to models.Card, err error) {
+	first, second := &from, &to
+	firstID, secondID := fromID, toID
+	if toID < fromID {
+		first, second = &to, &from
+		firstID, secondID = toID, fromID
+	}
+
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", firstID).First(first).Error; err != nil {
+		return
+	}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("rf_id = ?", secondID).First(second).Error
+	return
+}
+
+// checkTransfer kiểm tra trạng thái hai thẻ, số dư và hạn mức ngày của thẻ nguồn
+func checkTransfer(tx *gorm.DB, from, to models.Card, amount float64, override bool, now time.Time) error {
+	if from.Status.IsHotlisted() && !override {
+		return ErrTransferNeedsOverride
+	}
+	if to.Status.IsHotlisted() {
+		return ErrTransferTargetClosed
+	}
+	if from.Balance < amount {
+		return ErrInsufficientBalance
+	}
+
+	if limit := TransferDailyLimit(); limit > 0 {
+		sent, err := CardTransferredToday(tx, from.RFID, now)
+		if err != nil {
+			return err
+		}
+		if sent+amount > limit {
+			return ErrTransferDailyLimit
+		}
+	}
+	return nil
+}
+
+// executeTransfer chuyển tiền giữa hai thẻ đã khóa và ghi cặp bút toán transfer_out/transfer_in vào History
+func executeTransfer(tx *gorm.DB, transfer *models.BalanceTransfer, from, to *models.Card, override bool, actorID uint) error {
+	now := time.Now()
+	if err := checkTransfer(tx, *from, *to, transfer.Amount, override, now); err != nil {
+		return err
+	}
+
+	transfer.Status = consts.TransferCompleted
+	transfer.CompletedAt = &now
+	if err := tx.Save(transfer).Error; err != nil {
+		return err
+	}
+
+	from.Balance -= transfer.Amount
+	if err := tx.Model(from).Update("balance", from.Balance).Error; err != nil {
+		return err
+	}
+	to.Balance += transfer.Amount
+	if err := tx.Model(to).Update("balance", to.Balance).Error; err != nil {
+		return err
+	}
+
+	actor := strconv.FormatUint(uint64(actorID), 10)
+	entries := []models.History{
+		{
+			CardID:     from.RFID,
+			Time:       now,
+			UserID:     actor,
+			Balance:    from.Balance,
+			CardAction: consts.CardActionTransferOut,
+			TransferID: &transfer.ID,
+		},
+		{
+			CardID:     to.RFID,
+			Time:       now,
+			UserID:     actor,
+			Balance:    to.Balance,
+			CardAction: consts.CardActionTransferIn,
+			TransferID: &transfer.ID,
+		},
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return err
+	}
+
+	NotifyLowBalance(tx, *from)
+	return nil
+}
+
+// RequestTransfer chuyển số dư từ thẻ của người dùng sang thẻ khác. Nếu thẻ nhận cũng của người dùng
+// thì tiền được chuyển ngay; nếu không, lần chuyển chờ chủ thẻ nhận đồng ý qua ApproveTransfer.
+// Thẻ nguồn bị khóa hoặc hết hạn chỉ được chuyển bởi nhân viên (StaffTransfer).
+func RequestTransfer(requesterID uint, fromID, toID string, amount float64) (models.BalanceTransfer, error) {
+	transfer := models.BalanceTransfer{
+		FromCardID:  fromID,
+		ToCardID:    toID,
+		Amount:      amount,
+		RequestedBy: requesterID,
+	}
+	if fromID == toID {
+		return transfer, ErrTransferSameCard
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		from, to, err := lockCardPair(tx, fromID, toID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCardNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Thẻ nguồn của người khác được báo là không tồn tại
+		if from.UserID == nil || *from.UserID != requesterID {
+			return ErrCardNotFound
+		}
+
+		if to.UserID != nil && *to.UserID == requesterID {
+			return executeTransfer(tx, &transfer, &from, &to, false, requesterID)
+		}
+		if !to.Registered() {
+			return ErrCardNotRegistered
+		}
+
+		// Kiểm tra trước để báo lỗi sớm; điều kiện được kiểm tra lại khi chủ thẻ nhận đồng ý
+		if err := checkTransfer(tx, from, to, amount, false, time.Now()); err != nil {
+			return err
+		}
+		transfer.Status = consts.TransferPending
+		return tx.Create(&transfer).Error
+	})
+	return transfer, err
+}
+
+// lockPendingTransfer khóa lần chuyển đang chờ duyệt
+func lockPendingTransfer(tx *gorm.DB, transferID uint) (models.BalanceTransfer, error) {
+	var transfer models.BalanceTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, transferID).Error; err != nil {
+		return transfer, err
+	}
+	if transfer.Status != consts.TransferPending {
+		return transfer, ErrTransferNotPending
+	}
+	return transfer, nil
+}
+
+// ApproveTransfer được chủ thẻ nhận gọi để thực hiện lần chuyển đang chờ.
+// Trạng thái thẻ, số dư và hạn mức ngày được kiểm tra lại tại thời điểm đồng ý.
+func ApproveTransfer(transferID, approverID uint) (models.BalanceTransfer, error) {
+	var transfer models.BalanceTransfer
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if transfer, err = lockPendingTransfer(tx, transferID); err != nil {
+			return err
+		}
+
+		from, to, err := lockCardPair(tx, transfer.FromCardID, transfer.ToCardID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCardNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if to.UserID == nil || *to.UserID != approverID {
+			return gorm.ErrRecordNotFound
+		}
+		// Thẻ nguồn đã đổi chủ sau khi tạo yêu cầu
+		if from.UserID == nil || *from.UserID != transfer.RequestedBy {
+			return ErrCardNotFound
+		}
+
+		transfer.ApprovedBy = &approverID
+		return executeTransfer(tx, &transfer, &from, &to, false, approverID)
+	})
+	return transfer, err
+}
+
+// RejectTransfer được chủ thẻ nhận gọi để từ chối lần chuyển đang chờ
+func RejectTransfer(transferID, approverID uint) (models.BalanceTransfer, error) {
+	var transfer models.BalanceTransfer
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if transfer, err = lockPendingTransfer(tx, transferID); err != nil {
+			return err
+		}
+
+		var to models.Card
+		if err := tx.Where("rf_id = ? AND user_id = ?", transfer.ToCardID, approverID).First(&to).Error; err != nil {
+			return err
+		}
+
+		transfer.Status = consts.TransferRejected
+		transfer.ApprovedBy = &approverID
+		return tx.Model(&transfer).Select("status", "approved_by").Updates(&transfer).Error
+	})
+	return transfer, err
+}
+
+// CancelTransfer được người gửi gọi để hủy lần chuyển chưa được duyệt
+func CancelTransfer(transferID, requesterID uint) (models.BalanceTransfer, error) {
+	var transfer models.BalanceTransfer
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if transfer, err = lockPendingTransfer(tx, transferID); err != nil {
+			return err
+		}
+		if transfer.RequestedBy != requesterID {
+			return gorm.ErrRecordNotFound
+		}
+		transfer.Status = consts.TransferCancelled
+		return tx.Model(&transfer).Update("status", transfer.Status).Error
+	})
+	return transfer, err
+}
+
+// StaffTransfer được nhân viên dùng để chuyển số dư ngay giữa hai thẻ của cùng một chủ thẻ đã đăng ký,
+// ví dụ khi khách mang thẻ cũ và thẻ mới tới quầy. override cho phép chuyển từ thẻ bị khóa hoặc hết hạn
+// (kể cả sang thẻ của chủ khác) và cần ghi lý do; chuyển giữa hai chủ thẻ khác nhau phải qua RequestTransfer.
+func StaffTransfer(db *gorm.DB, staffID uint, fromID, toID string, amount float64, override bool, reason string) (models.BalanceTransfer, error) {
+	transfer := models.BalanceTransfer{
+		FromCardID:  fromID,
+		ToCardID:    toID,
+		Amount:      amount,
+		RequestedBy: staffID,
+		Reason:      reason,
+	}
+	if fromID == toID {
+		return transfer, ErrTransferSameCard
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		from, to, err := lockCardPair(tx, fromID, toID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCardNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if override && from.Status.IsHotlisted() {
+			transfer.OverrideBy = &staffID
+		} else if from.UserID == nil || to.UserID == nil || *from.UserID != *to.UserID {
+			return ErrTransferOwnerMismatch
+		}
+		return executeTransfer(tx, &transfer, &from, &to, override, staffID)
+	})
+	return transfer, err
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultLateThreshold là độ trễ mặc định để coi một lượt đến là trễ
+const defaultLateThreshold = 2 * time.Minute
+
+var (
+	ErrTripNotFound      = errors.New("trip not found")
+	ErrStationNotOnTrip  = errors.New("station is not a stop of this trip")
+	ErrTripAlreadyClosed = errors.New("trip is already completed or cancelled")
+)
+
+// TripPositionReport là một bản tin vị trí do tàu gửi về
+type TripPositionReport struct {
+	StationID    uint
+	Status       consts.TripStatus
+	DelaySeconds *int // nil: tự tính từ giờ báo về so với lịch
+	ReportedBy   uint
+}
+
+// TripLateThreshold đọc ngưỡng trễ từ TRIP_LATE_THRESHOLD_SECONDS (mặc định 120 giây)
+func TripLateThreshold() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("TRIP_LATE_THRESHOLD_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultLateThreshold
+}
+
+func loadTripWithStops(tripID uint) (models.Trip, []models.PatternStop, error) {
+	var trip models.Trip
+	if err := config.DB.Preload("Train", models.WithDeleted).First(&trip, tripID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return trip, nil, ErrTripNotFound
+		}
+		return trip, nil, err
+	}
+
+	var stops []models.PatternStop
+	if trip.PatternID != nil {
+		if err := config.DB.Preload("Station").
+			Where("pattern_id = ?", *trip.PatternID).
+			Order("stop_sequence ASC").
+			Find(&stops).Error; err != nil {
+			return trip, nil, err
+		}
+	}
+
+	return trip, stops, nil
+}
+
+func loadTripProgress(tripID uint) (models.TripProgress, error) {
+	progress := models.TripProgress{TripID: tripID, Status: consts.TripScheduled}
+	err := config.DB.Preload("CurrentStation").Where("trip_id = ?", tripID).Limit(1).Find(&progress).Error
+	return progress, err
+}
+
+// predictStops tính giờ đến/rời dự kiến tại các ga dựa trên độ trễ hiện tại
+func predictStops(trip models.Trip, progress models.TripProgress, stops []models.PatternStop) []models.StopPrediction {
+	delay := time.Duration(progress.DelaySeconds) * time.Second
+	threshold := TripLateThreshold()
+
+	predictions := make([]models.StopPrediction, 0, len(stops))
+	for _, stop := range stops {
+		scheduledArrival := trip.StartTime.Add(time.Duration(stop.ArrivalOffset) * time.Second)
+		scheduledDeparture := trip.StartTime.Add(time.Duration(stop.DepartureOffset) * time.Second)
+
+		passed := false
+		switch progress.Status {
+		case consts.TripCompleted:
+			passed = true
+		case consts.TripAtStation:
+			passed = stop.StopSequence < progress.CurrentStopSequence
+		case consts.TripInTransit:
+			passed = stop.StopSequence <= progress.CurrentStopSequence
+		}
+
+		predictions = append(predictions, models.StopPrediction{
+			StopSequence:       stop.StopSequence,
+			StationID:          stop.StationID,
+			StationName:        stop.Station.Name,
+			ScheduledArrival:   scheduledArrival,
+			ScheduledDeparture: scheduledDeparture,
+			PredictedArrival:   scheduledArrival.Add(delay),
+			PredictedDeparture: scheduledDeparture.Add(delay),
+			Passed:             passed,
+			Late:               !passed && progress.Status != consts.TripCancelled && delay > threshold,
+		})
+	}
+
+	return predictions
+}
+
+// GetTripProgressDetail trả về trạng thái thời gian thực và giờ dự kiến tại các ga của chuyến
+func GetTripProgressDetail(tripID uint) (*models.TripProgressDetail, error) {
+	trip, stops, err := loadTripWithStops(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := loadTripProgress(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TripProgressDetail{
+		Trip:     trip,
+		Progress: progress,
+		Stops:    predictStops(trip, progress, stops),
+	}, nil
+}
+
+// ReportTripPosition cập nhật trạng thái thời gian thực của chuyến từ một bản tin vị trí
+func ReportTripPosition(tripID uint, report TripPositionReport) (*models.TripProgressDetail, error) {
+	trip, stops, err := loadTripWithStops(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := loadTripProgress(tripID)
+	if err != nil {
+		return nil, err
+	}
+	if progress.Status == consts.TripCompleted || progress.Status == consts.TripCancelled {
+		return nil, ErrTripAlreadyClosed
+	}
+
+	now := time.Now()
+	progress.Status = report.Status
+	progress.ReportedBy = report.ReportedBy
+	progress.ReportedAt = now
+
+	if report.Status != consts.TripCancelled {
+		// Tìm ga trong lộ trình, bắt đầu từ ga hiện tại để hỗ trợ tuyến vòng
+		var current *models.PatternStop
+		for i := range stops {
+			if stops[i].StationID == report.StationID && stops[i].StopSequence >= progress.CurrentStopSequence {
+				current = &stops[i]
+				break
+			}
+		}
+		if current == nil && len(stops) > 0 {
+			return nil, ErrStationNotOnTrip
+		}
+
+		stationID := report.StationID
+		progress.CurrentStationID = &stationID
+
+		if current != nil {
+			progress.CurrentStopSequence = current.StopSequence
+
+			if report.DelaySeconds == nil {
+				reference := trip.StartTime.Add(time.Duration(current.ArrivalOffset) * time.Second)
+				if report.Status == consts.TripInTransit {
+					reference = trip.StartTime.Add(time.Duration(current.DepartureOffset) * time.Second)
+				}
+				progress.DelaySeconds = int(now.Sub(reference) / time.Second)
+			}
+		}
+	}
+
+	if report.DelaySeconds != nil {
+		progress.DelaySeconds = *report.DelaySeconds
+	}
+	progress.Late = progress.Status != consts.TripCancelled &&
+		time.Duration(progress.DelaySeconds)*time.Second > TripLateThreshold()
+
+	if err := config.DB.Omit("CurrentStation").Save(&progress).Error; err != nil {
+		return nil, err
+	}
+
+	stationIDs := make([]uint, 0, len(stops))
+	for _, stop := range stops {
+		stationIDs = append(stationIDs, stop.StationID)
+	}
+	Boards.MarkDirty(stationIDs...)
+
+	return GetTripProgressDetail(tripID)
+}
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// loginChallengeTTL là thời gian chờ nhập mã xác thực hai bước sau khi đúng mật khẩu
+	loginChallengeTTL = 5 * time.Minute
+
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrolment has not been started")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this role")
+)
+
+// TwoFactorRequired cho biết role có bắt buộc bật xác thực hai bước không.
+// Đọc TWO_FACTOR_REQUIRED_ROLES, danh sách tên role phân cách bởi dấu phẩy (mặc định "admin").
+func TwoFactorRequired(role consts.Role) bool {
+	roles, ok := os.LookupEnv("TWO_FACTOR_REQUIRED_ROLES")
+	if !ok {
+		roles = consts.AdminRole.ToText()
+	}
+	for _, name := range strings.Split(roles, ",") {
+		if strings.EqualFold(strings.TrimSpace(name), role.ToText()) {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginTOTPEnrollment tạo secret mới cho user chưa bật 2FA; secret chỉ có hiệu lực sau EnableTOTP.
+// Gọi lại sẽ thay secret đang chờ xác nhận.
+func BeginTOTPEnrollment(user models.User) (secret string, uri string, err error) {
+	if user.TwoFactorEnabled() {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err = NewTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	record := models.UserTOTP{UserID: user.ID, Secret: secret}
+	if err := config.DB.Omit("User").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret": secret, "last_used_step": 0, "updated_at": time.Now()}),
+	}).Create(&record).Error; err != nil {
+		return "", "", err
+	}
+
+	return secret, TOTPProvisioningURI(secret, user.Email), nil
+}
+
+// EnableTOTP xác nhận secret đang chờ bằng một mã đúng, bật 2FA và trả về bộ mã khôi phục mới.
+// Mã khôi phục chỉ hiển thị một lần.
+func EnableTOTP(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TwoFactorEnabled() {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		if err := verifyTOTPCode(tx, userID, code); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("two_factor_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableTwoFactor xóa secret, mã khôi phục và tắt 2FA của user
+func DisableTwoFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", userID).Update("two_factor_enabled_at", nil).Error
+}
+
+// verifyTOTPCode kiểm tra mã TOTP và ghi lại bước đã dùng để mã không dùng lại được
+func verifyTOTPCode(tx *gorm.DB, userID uint, code string) error {
+	var record models.UserTOTP
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+
+	step, ok := VerifyTOTP(record.Secret, code, time.Now(), record.LastUsedStep)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return tx.Model(&record).Update("last_used_step", step).Error
+}
+
+// VerifySecondFactor kiểm tra mã TOTP hoặc mã khôi phục của user đã bật 2FA.
+// Trả về true nếu đã dùng một mã khôi phục.
+func VerifySecondFactor(tx *gorm.DB, userID uint, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return false, verifyTOTPCode(tx, userID, code)
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// recoveryCodeEncoding dùng bảng base32 chữ thường, tránh nhầm 0/O và 1/l khi đọc mã in ra giấy
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// newRecoveryCode tạo mã dạng xxxxx-xxxxx (50 bit)
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := recoveryCodeEncoding.EncodeToString(buf)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// replaceRecoveryCodes hủy mã khôi phục cũ và tạo bộ mã mới
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	if err := tx.Omit("User").Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes tạo bộ mã khôi phục mới sau khi xác nhận bằng mã TOTP
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if !user.TwoFactorEnabled() {
+			return ErrTwoFactorNotEnabled
+		}
+		if err := verifyTOTPCode(tx, userID, code); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes đếm số mã khôi phục chưa dùng
+func RemainingRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// IssueLoginChallenge tạo token cho bước hai của đăng nhập sau khi user đã nhập đúng mật khẩu
+func IssueLoginChallenge(userID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(loginChallengeTTL)
+	raw, err := createUserToken(config.DB, userID, models.TokenLoginChallenge, loginChallengeTTL)
+	return raw, expiresAt, err
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"go-metro/config"
+	"go-metro/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEmailVerificationTTL = 48 * time.Hour
+	defaultPasswordResetTTL     = 30 * time.Minute
+
+	// userTokenResendInterval chặn gửi lại email liên tục cho cùng một mục đích
+	userTokenResendInterval = time.Minute
+)
+
+var (
+	ErrInvalidUserToken  = errors.New("invalid or already used token")
+	ErrUserTokenExpired  = errors.New("token expired")
+	ErrUserTokenThrottle = errors.New("please wait before requesting another email")
+	ErrEmailNotVerified  = errors.New("email address is not verified")
+)
+
+// EmailVerificationTTL đọc thời hạn link xác minh email từ EMAIL_VERIFICATION_TTL_HOURS (mặc định 48 giờ)
+func EmailVerificationTTL() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultEmailVerificationTTL
+}
+
+// PasswordResetTTL đọc thời hạn link đặt lại mật khẩu từ PASSWORD_RESET_TTL_MINUTES (mặc định 30 phút)
+func PasswordResetTTL() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_TTL_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultPasswordResetTTL
+}
+
+// appBaseURL là địa chỉ frontend dùng trong link gửi qua email (APP_BASE_URL)
+func appBaseURL() string {
+	if base := os.Getenv("APP_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// IssueUserToken tạo token gửi qua email cho user; các token cũ cùng mục đích hết hiệu lực
+func IssueUserToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	var raw string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var recent int64
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-userTokenResendInterval)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return ErrUserTokenThrottle
+		}
+
+		var err error
+		raw, err = createUserToken(tx, userID, purpose, ttl)
+		return err
+	})
+	return raw, err
+}
+
+// createUserToken tạo token dùng một lần và hủy các token chưa dùng cùng mục đích của user
+func createUserToken(tx *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+
+	return raw, tx.Omit("User").Create(&models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(ttl),
+	}).Error
+}
+
+// ConsumeUserToken đánh dấu token đã dùng và trả về bản ghi; mỗi token chỉ dùng được một lần.
+// Gọi trong transaction tx để thay đổi đi kèm (đổi mật khẩu, xác minh email) cùng commit.
+func ConsumeUserToken(tx *gorm.DB, raw, purpose string) (models.UserToken, error) {
+	var token models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(raw), purpose).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, ErrInvalidUserToken
+		}
+		return token, err
+	}
+	if token.UsedAt != nil {
+		return token, ErrInvalidUserToken
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return token, ErrUserTokenExpired
+	}
+
+	// Điều kiện used_at IS NULL đảm bảo hai request đồng thời không cùng dùng được token
+	now := time.Now()
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return token, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return token, ErrInvalidUserToken
+	}
+	token.UsedAt = &now
+	return token, nil
+}
+
+// SendVerificationEmail gửi link xác minh email cho user
+func SendVerificationEmail(user models.User) error {
+	token, err := IssueUserToken(user.ID, models.TokenEmailVerification, EmailVerificationTTL())
+	if err != nil {
+		return err
+	}
+
+	return SendMail(MailMessage{
+		To:      user.Email,
+		Subject: "Xác minh email tài khoản Go Metro",
+		Body: fmt.Sprintf("Xin chào %s,\n\n"+
+			"Vui lòng xác minh email của bạn bằng link sau (hiệu lực %s):\n%s/verify-email?token=%s\n\n"+
+			"Nếu bạn không tạo tài khoản, hãy bỏ qua email này.\n",
+			user.FullName, EmailVerificationTTL(), appBaseURL(), token),
+	})
+}
+
+// SendPasswordResetEmail gửi link đặt lại mật khẩu cho user
+func SendPasswordResetEmail(user models.User) error {
+	token, err := IssueUserToken(user.ID, models.TokenPasswordReset, PasswordResetTTL())
+	if err != nil {
+		return err
+	}
+
+	return SendMail(MailMessage{
+		To:      user.Email,
+		Subject: "Đặt lại mật khẩu Go Metro",
+		Body: fmt.Sprintf("Xin chào %s,\n\n"+
+			"Bạn (hoặc ai đó) vừa yêu cầu đặt lại mật khẩu. Dùng link sau để đặt mật khẩu mới (hiệu lực %s, chỉ dùng một lần):\n%s/reset-password?token=%s\n\n"+
+			"Nếu bạn không yêu cầu, hãy bỏ qua email này; mật khẩu hiện tại vẫn giữ nguyên.\n",
+			user.FullName, PasswordResetTTL(), appBaseURL(), token),
+	})
+}
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metro/config"
+	"go-metro/consts"
+	"go-metro/models"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultWebhookPollInterval = 5 * time.Second
+
+	webhookBatchSize     = 20
+	webhookRetryBase     = 30 * time.Second
+	webhookRetryMaxDelay = 6 * time.Hour
+	webhookTimeout       = 10 * time.Second
+
+	// webhookClaimLease là thời gian một lô lần gửi được giữ cho instance đã nhận; instance dừng giữa chừng
+	// thì lần gửi chưa ghi kết quả được gửi lại khi hết hạn
+	webhookClaimLease = webhookBatchSize*webhookTimeout + time.Minute
+
+	// webhookErrorBodyLimit là số byte tối đa của response lỗi được lưu vào last_error
+	webhookErrorBodyLimit = 512
+)
+
+var ErrWebhookNotDead = errors.New("only dead deliveries can be redelivered")
+
+// WebhookPollInterval đọc chu kỳ quét webhook từ WEBHOOK_POLL_SECONDS (mặc định 5 giây)
+func WebhookPollInterval() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_POLL_SECONDS")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultWebhookPollInterval
+}
+
+// webhookMaxAttempts là số lần gửi tối đa trước khi đưa vào dead-letter (WEBHOOK_MAX_ATTEMPTS, mặc định 8)
+func webhookMaxAttempts() int {
+	return envInt("WEBHOOK_MAX_ATTEMPTS", 8)
+}
+
+// webhookRetryDelay là thời gian chờ trước lần gửi lại thứ attempts: 30 giây, gấp đôi mỗi lần, tối đa 6 giờ
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMaxDelay {
+		delay = webhookRetryMaxDelay
+	}
+	return delay
+}
+
+// NewWebhookSecret tạo secret ký payload cho subscription
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// SignWebhookPayload tính chữ ký gửi trong header X-Webhook-Signature: HMAC-SHA256 bằng secret của
+// "<timestamp>.<payload>". Bên nhận tính lại để xác thực và từ chối timestamp quá cũ để chống gửi lặp.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSubscriptionSink là sink của outbox tạo một lần gửi cho mỗi subscription đang bật có đăng ký
+// loại sự kiện. Việc gửi HTTP do Webhooks đảm nhận nên một đối tác lỗi không chặn outbox.
+type WebhookSubscriptionSink struct{}
+
+func (s *WebhookSubscriptionSink) Name() string {
+	return "webhook_subscriptions"
+}
+
+func (s *WebhookSubscriptionSink) Send(event DomainEvent) error {
+	var subscriptions []models.WebhookSubscription
+	if err := config.DB.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Status:         consts.WebhookPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = string(payload)
+	}
+
+	// Outbox gửi lại sự kiện khi có lỗi; lần gửi đã tạo cho subscription được giữ nguyên
+	if err := config.DB.Omit("Subscription").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error; err != nil {
+		return err
+	}
+	Webhooks.Wake()
+	return nil
+}
+
+// sendWebhook gửi payload đã ký tới subscription; mọi mã trạng thái ngoài 2xx được coi là lỗi
+func sendWebhook(client *http.Client, subscription models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-ID", delivery.EventID)
+	request.Header.Set("X-Event-Type", string(delivery.EventType))
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	request.Header.Set("X-Webhook-Signature", SignWebhookPayload(subscription.Secret, timestamp, payload))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, webhookErrorBodyLimit))
+		return response.StatusCode, fmt.Errorf("webhook returned %s: %s", response.Status, bytes.TrimSpace(body))
+	}
+	return response.StatusCode, nil
+}
+
+// deliverWebhook gửi một lần và ghi kết quả: thành công thì delivered, lỗi thì hẹn gửi lại theo
+// exponential backoff, hết số lần thử thì chuyển sang dead. Subscription đã tắt cũng chuyển sang dead.
+// Gửi HTTP nằm ngoài transaction; kết quả được ghi bằng một câu update riêng.
+func deliverWebhook(client *http.Client, delivery *models.WebhookDelivery) error {
+	var subscription models.WebhookSubscription
+	if err := config.DB.First(&subscription, delivery.SubscriptionID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !subscription.Active {
+		delivery.Status = consts.WebhookDead
+		delivery.LastError = "subscription is inactive"
+	} else {
+		statusCode, err := sendWebhook(client, subscription, *delivery)
+		now = time.Now()
+		delivery.Attempts++
+		delivery.LastStatusCode = statusCode
+		switch {
+		case err == nil:
+			delivery.Status = consts.WebhookDelivered
+			delivery.DeliveredAt = &now
+			delivery.LastError = ""
+		case delivery.Attempts >= webhookMaxAttempts():
+			delivery.Status = consts.WebhookDead
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = now.Add(webhookRetryDelay(delivery.Attempts))
+		}
+	}
+
+	return config.DB.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+// claimWebhookDeliveries nhận một lô lần gửi đến hạn trong một transaction ngắn: dời next_attempt_at
+// thêm webhookClaimLease để instance khác không gửi trùng trong lúc lô đang được gửi
+func claimWebhookDeliveries() ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", consts.WebhookPending, now).
+			Order("id").Limit(webhookBatchSize).Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(due))
+		for _, delivery := range due {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(webhookClaimLease)).Error
+	})
+	return due, err
+}
+
+// DeliverWebhooks gửi các lần gửi đến hạn theo từng lô. Các instance chạy song song không nhận
+// cùng một lần gửi. Trả về số lần gửi thành công.
+func DeliverWebhooks() (int, error) {
+	client := &http.Client{Timeout: webhookTimeout}
+	delivered := 0
+	for {
+		due, err := claimWebhookDeliveries()
+		if err != nil {
+			return delivered, err
+		}
+
+		for i := range due {
+			if err := deliverWebhook(client, &due[i]); err != nil {
+				// Lần gửi được gửi lại khi hết thời gian giữ
+				log.Printf("⚠️ Failed to record webhook delivery %d: %v", due[i].ID, err)
+				continue
+			}
+			if due[i].Status == consts.WebhookDelivered {
+				delivered++
+			} else if due[i].Status == consts.WebhookDead {
+				log.Printf("⚠️ Webhook delivery %d moved to dead letters: %s", due[i].ID, due[i].LastError)
+			}
+		}
+
+		if len(due) < webhookBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// RedeliverWebhook đưa một lần gửi trong dead-letter về hàng đợi để gửi lại ngay với số lần thử mới.
+// Người gọi đánh thức Webhooks sau khi commit.
+func RedeliverWebhook(db *gorm.DB, deliveryID uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, deliveryID).Error; err != nil {
+			return err
+		}
+		if delivery.Status != consts.WebhookDead {
+			return ErrWebhookNotDead
+		}
+
+		delivery.Status = consts.WebhookPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		delivery.LastError = ""
+		return tx.Model(&delivery).
+			Select("status", "attempts", "next_attempt_at", "last_error").
+			Updates(&delivery).Error
+	})
+	return delivery, err
+}
+
+// WebhookDispatcher gửi webhook định kỳ hoặc ngay khi được đánh thức
+type WebhookDispatcher struct {
+	wake chan struct{}
+}
+
+// Webhooks là dispatcher dùng chung cho toàn bộ ứng dụng
+var Webhooks = &WebhookDispatcher{wake: make(chan struct{}, 1)}
+
+// Wake yêu cầu gửi các lần gửi mới ngay. Hàm không bao giờ chặn.
+func (d *WebhookDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run gửi webhook đến hạn; chạy trong một goroutine riêng
+func (d *WebhookDispatcher) Run() {
+	ticker := time.NewTicker(WebhookPollInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := DeliverWebhooks(); err != nil {
+			log.Println("⚠️ Failed to deliver webhooks:", err)
+		}
+
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}